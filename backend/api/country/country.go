@@ -0,0 +1,16 @@
+package country
+
+// Stats counts the locations stored for one country and the published
+// holidays at them. Cities are compared ignoring case.
+type Stats struct {
+	CountryCode string
+	Cities      int64
+	Locations   int64
+	Holidays    int64
+}
+
+type CityStats struct {
+	City      string
+	Locations int64
+	Holidays  int64
+}
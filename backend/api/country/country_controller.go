@@ -0,0 +1,53 @@
+package country
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(service *Service) *Controller {
+	return &Controller{service: service}
+}
+
+func (c *Controller) GetCountries(w http.ResponseWriter, r *http.Request) {
+	all := false
+	if value := r.URL.Query().Get("all"); value != "" {
+		var err error
+		if all, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "all must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	countries, err := c.service.GetCountries(r.Context(), all)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(countries)
+}
+
+func (c *Controller) GetCities(w http.ResponseWriter, r *http.Request) {
+	cities, err := c.service.GetCities(r.Context(), mux.Vars(r)["countryCode"])
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cities)
+}
@@ -0,0 +1,15 @@
+package country
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNotFound = errors.New("country not found")
+
+// CountryRepository aggregates locations by their ISO 3166 country code.
+// Locations without a country are left out.
+type CountryRepository interface {
+	ListStats(ctx context.Context) ([]Stats, error)
+	ListCityStats(ctx context.Context, countryCode string) ([]CityStats, error)
+}
@@ -0,0 +1,81 @@
+package country
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/nikolaypleshkov/uni-api/api/country/dto"
+	"github.com/nikolaypleshkov/uni-api/iso3166"
+)
+
+type Service struct {
+	repo CountryRepository
+}
+
+func NewService(repo CountryRepository) *Service {
+	return &Service{repo: repo}
+}
+
+// GetCountries lists the countries that have locations, ordered by name.
+// With all set it lists every ISO 3166 country, with zero counts for those
+// without locations.
+func (s *Service) GetCountries(ctx context.Context, all bool) ([]dto.ResponseCountryDTO, error) {
+	stats, err := s.repo.ListStats(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query country stats", "error", err)
+		return nil, err
+	}
+
+	byCode := make(map[string]Stats, len(stats))
+	for _, stat := range stats {
+		byCode[stat.CountryCode] = stat
+	}
+
+	countryDTOs := []dto.ResponseCountryDTO{}
+	for _, country := range iso3166.All() {
+		stat, ok := byCode[country.Alpha2]
+		if !ok && !all {
+			continue
+		}
+		countryDTOs = append(countryDTOs, dto.ResponseCountryDTO{
+			Code:          country.Alpha2,
+			Alpha3:        country.Alpha3,
+			Name:          country.Name,
+			CityCount:     stat.Cities,
+			LocationCount: stat.Locations,
+			HolidayCount:  stat.Holidays,
+		})
+	}
+
+	sort.Slice(countryDTOs, func(i, j int) bool { return countryDTOs[i].Name < countryDTOs[j].Name })
+	return countryDTOs, nil
+}
+
+// GetCities lists the cities with locations in the country given by code,
+// name or alias, ordered by name.
+func (s *Service) GetCities(ctx context.Context, code string) ([]dto.ResponseCityDTO, error) {
+	country, ok := iso3166.Lookup(code)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, code)
+	}
+
+	stats, err := s.repo.ListCityStats(ctx, country.Alpha2)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query city stats", "country_code", country.Alpha2, "error", err)
+		return nil, err
+	}
+
+	cityDTOs := []dto.ResponseCityDTO{}
+	for _, stat := range stats {
+		cityDTOs = append(cityDTOs, dto.ResponseCityDTO{
+			Name:          stat.City,
+			CountryCode:   country.Alpha2,
+			LocationCount: stat.Locations,
+			HolidayCount:  stat.Holidays,
+		})
+	}
+
+	return cityDTOs, nil
+}
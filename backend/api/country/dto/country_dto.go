@@ -0,0 +1,17 @@
+package dto
+
+type ResponseCountryDTO struct {
+	Code          string `json:"code"`
+	Alpha3        string `json:"alpha3"`
+	Name          string `json:"name"`
+	CityCount     int64  `json:"cityCount"`
+	LocationCount int64  `json:"locationCount"`
+	HolidayCount  int64  `json:"holidayCount"`
+}
+
+type ResponseCityDTO struct {
+	Name          string `json:"name"`
+	CountryCode   string `json:"countryCode"`
+	LocationCount int64  `json:"locationCount"`
+	HolidayCount  int64  `json:"holidayCount"`
+}
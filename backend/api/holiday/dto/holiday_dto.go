@@ -1,33 +1,173 @@
-package dto
-
-import location "github.com/nikolaypleshkov/uni-api/api/location/dto"
-
-type CreateHolidayDTO struct {
-	Title     string `json:"title"`
-	StartDate string `json:"startDate"`
-	Duration  int32  `json:"duration"`
-	FreeSlots int32  `json:"freeSlots"`
-	Price     string `json:"price"`
-	Location  int64  `json:"location"`
-}
-
-type UpdateHolidayDTO struct {
-	ID        int64   `json:"id"`
-	Title     string  `json:"title"`
-	StartDate string  `json:"startDate"`
-	Duration  int32   `json:"duration"`
-	FreeSlots int32   `json:"freeSlots"`
-	Price     float64 `json:"price"`
-	Location  int64   `json:"location"`
-}
-
-type ResponseHolidayDTO struct {
-	ID         int64                        `json:"id"`
-	Title      string                       `json:"title"`
-	StartDate  string                       `json:"startDate"`
-	Duration   int32                        `json:"duration"`
-	FreeSlots  int32                        `json:"freeSlots"`
-	Price      string                       `json:"price"`
-	Location   location.ResponseLocationDTO `json:"location"`
-	LocationID int64                        `json:"location_id"`
-}
+package dto
+
+import (
+	"time"
+
+	location "github.com/nikolaypleshkov/uni-api/api/location/dto"
+)
+
+type CreateHolidayDTO struct {
+	Title         string           `json:"title"`
+	Description   string           `json:"description"`
+	StartDate     string           `json:"startDate"`
+	Duration      int32            `json:"duration"`
+	FreeSlots     int32            `json:"freeSlots"`
+	Price         string           `json:"price"`
+	Location      int64            `json:"location"`
+	Included      []string         `json:"included"`
+	Excluded      []string         `json:"excluded"`
+	Accommodation AccommodationDTO `json:"accommodation"`
+	Status        string           `json:"status"`
+	PublishAt     *time.Time       `json:"publishAt"`
+	UnpublishAt   *time.Time       `json:"unpublishAt"`
+}
+
+type UpdateHolidayDTO struct {
+	ID            int64            `json:"id"`
+	Title         string           `json:"title"`
+	Description   string           `json:"description"`
+	StartDate     string           `json:"startDate"`
+	Duration      int32            `json:"duration"`
+	FreeSlots     int32            `json:"freeSlots"`
+	Price         float64          `json:"price"`
+	Location      int64            `json:"location"`
+	Included      []string         `json:"included"`
+	Excluded      []string         `json:"excluded"`
+	Accommodation AccommodationDTO `json:"accommodation"`
+	// Status, when empty, leaves the status and schedule as they are.
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publishAt"`
+	UnpublishAt *time.Time `json:"unpublishAt"`
+	Version     int64      `json:"-"`
+}
+
+type AccommodationDTO struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Stars       int32  `json:"stars"`
+	Description string `json:"description"`
+}
+
+type ResponseHolidayDTO struct {
+	ID            int64                        `json:"id"`
+	Title         string                       `json:"title"`
+	Description   string                       `json:"description"`
+	StartDate     string                       `json:"startDate"`
+	Duration      int32                        `json:"duration"`
+	FreeSlots     int32                        `json:"freeSlots"`
+	Price         string                       `json:"price"`
+	Location      location.ResponseLocationDTO `json:"location"`
+	LocationID    int64                        `json:"location_id"`
+	Included      []string                     `json:"included"`
+	Excluded      []string                     `json:"excluded"`
+	Accommodation AccommodationDTO             `json:"accommodation"`
+	Status        string                       `json:"status"`
+	PublishAt     *time.Time                   `json:"publishAt"`
+	UnpublishAt   *time.Time                   `json:"unpublishAt"`
+	// Template is the ID of the template the holiday is a departure of.
+	Template *int64 `json:"template"`
+	Version  int64  `json:"version"`
+	// Tags are the slugs of the holiday's tags.
+	Tags []string `json:"tags"`
+	// DistanceKm is set on results of a search near a point.
+	DistanceKm *float64 `json:"distanceKm,omitempty"`
+}
+
+type StopDTO struct {
+	Location  int64 `json:"location"`
+	DayOffset int32 `json:"dayOffset"`
+	Nights    int32 `json:"nights"`
+}
+
+type UpdateItineraryDTO struct {
+	Stops []StopDTO `json:"stops"`
+}
+
+type ResponseStopDTO struct {
+	Position  int32                        `json:"position"`
+	DayOffset int32                        `json:"dayOffset"`
+	Nights    int32                        `json:"nights"`
+	Location  location.ResponseLocationDTO `json:"location"`
+}
+
+type ResponseItineraryDTO struct {
+	HolidayID int64             `json:"holidayId"`
+	Duration  int32             `json:"duration"`
+	Stops     []ResponseStopDTO `json:"stops"`
+	// Version is the holiday's.
+	Version int64 `json:"-"`
+}
+
+type HolidayTagsDTO struct {
+	Tags []string `json:"tags"`
+}
+
+// HolidayTranslationsDTO holds a holiday's translations by language tag.
+type HolidayTranslationsDTO struct {
+	Translations map[string]HolidayTranslationDTO `json:"translations"`
+}
+
+type HolidayTranslationDTO struct {
+	Title                    string `json:"title"`
+	Description              string `json:"description"`
+	AccommodationDescription string `json:"accommodationDescription"`
+}
+
+// ResponseAvailabilityDTO is a month of a location's availability
+// calendar. Days lists the days that have departures, in order.
+type ResponseAvailabilityDTO struct {
+	Month string               `json:"month"`
+	Days  []AvailabilityDayDTO `json:"days"`
+}
+
+// AvailabilityDayDTO lists the holidays starting on Date, cheapest first.
+// FreeSlots adds up their free slots and LowestPrice is the lowest price of
+// those with any left, or nil when they are all sold out.
+type AvailabilityDayDTO struct {
+	Date        string                `json:"date"`
+	FreeSlots   int32                 `json:"freeSlots"`
+	LowestPrice *string               `json:"lowestPrice"`
+	Holidays    []AvailableHolidayDTO `json:"holidays"`
+}
+
+type AvailableHolidayDTO struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Duration  int32  `json:"duration"`
+	FreeSlots int32  `json:"freeSlots"`
+	Price     string `json:"price"`
+}
+
+// ResponseHolidaySearchDTO is the holiday list with facet counts over it.
+type ResponseHolidaySearchDTO struct {
+	Holidays []ResponseHolidayDTO `json:"holidays"`
+	Facets   HolidayFacetsDTO     `json:"facets"`
+}
+
+type HolidayFacetsDTO struct {
+	Tags      []TagFacetDTO     `json:"tags"`
+	Countries []CountryFacetDTO `json:"countries"`
+	Durations []RangeFacetDTO   `json:"durations"`
+	Prices    []RangeFacetDTO   `json:"prices"`
+}
+
+type TagFacetDTO struct {
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type CountryFacetDTO struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// RangeFacetDTO counts the holidays with a value from From up to, but not
+// including, To. A nil To has no upper bound.
+type RangeFacetDTO struct {
+	Key   string   `json:"key"`
+	From  float64  `json:"from"`
+	To    *float64 `json:"to"`
+	Count int64    `json:"count"`
+}
@@ -1,11 +1,75 @@
-package holiday
-
-type Holiday struct {
-	ID         int64  `json:"id"`
-	Title      string `json:"title"`
-	StartDate  string `json:"startDate"`
-	Duration   int32  `json:"duration"`
-	FreeSlots  int32  `json:"freeSlots"`
-	Price      string `json:"price"`
-	LocationID int64  `json:"location"`
-}
+package holiday
+
+import "time"
+
+// Holiday is stored in the default language; Translations override its
+// text in others.
+type Holiday struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	StartDate   string `json:"startDate"`
+	Duration    int32  `json:"duration"`
+	FreeSlots   int32  `json:"freeSlots"`
+	Price       string `json:"price"`
+	LocationID  int64  `json:"location"`
+	// Included and Excluded list the Services the price does and does not
+	// cover.
+	Included      []string      `json:"included"`
+	Excluded      []string      `json:"excluded"`
+	Accommodation Accommodation `json:"accommodation"`
+	// Status is one of Draft, Published or Archived. Only published
+	// holidays are listed to customers and can be reserved.
+	Status string `json:"status"`
+	// PublishAt schedules a draft to be published and UnpublishAt a holiday
+	// to go back to draft; nil when nothing is scheduled.
+	PublishAt   *time.Time `json:"publishAt"`
+	UnpublishAt *time.Time `json:"unpublishAt"`
+	// TemplateID is the template the holiday is a departure of, or 0.
+	// Creating and updating holidays leaves it as it is.
+	TemplateID int64 `json:"template"`
+	Version    int64 `json:"version"`
+}
+
+const (
+	Draft     = "draft"
+	Published = "published"
+	// Archived holidays have started; they are kept for their reservations.
+	Archived = "archived"
+)
+
+var Statuses = []string{Draft, Published, Archived}
+
+// Accommodation describes where the holiday stays. Type is one of
+// AccommodationTypes, or empty when not given; Stars is the official
+// rating from 1 to 5, or 0 when unrated.
+type Accommodation struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Stars       int32  `json:"stars"`
+	Description string `json:"description"`
+}
+
+// Services are the codes a holiday's price can include or exclude. Clients
+// show them in the customer's language.
+var Services = []string{"accommodation", "excursions", "flights", "guide", "insurance", "meals", "transfers", "visa"}
+
+var AccommodationTypes = []string{"apartment", "camping", "guesthouse", "hostel", "hotel", "resort", "villa"}
+
+// Translation holds a holiday's text in one language other than the
+// default. Empty fields fall back to the default language.
+type Translation struct {
+	Language                 string
+	Title                    string
+	Description              string
+	AccommodationDescription string
+}
+
+// Stop is one leg of a holiday's itinerary: Nights nights at the location,
+// arriving DayOffset days after the start date. An itinerary lists its
+// stops in order.
+type Stop struct {
+	LocationID int64
+	DayOffset  int32
+	Nights     int32
+}
@@ -0,0 +1,89 @@
+package holiday
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+)
+
+const monthLayout = "2006-01"
+
+// availabilityCacheControl lets shared caches serve a calendar for a short
+// while, since free slots change with every booking.
+const availabilityCacheControl = "public, max-age=60"
+
+// GetAvailability builds the availability calendar of the published
+// holidays at a location, or visiting it, that start in a month. The
+// location is matched as in a holiday listing and the month is written as
+// 2026-07.
+func (s *Service) GetAvailability(ctx context.Context, queryParams url.Values) (dto.ResponseAvailabilityDTO, error) {
+	value := strings.TrimSpace(queryParams.Get("location"))
+	if value == "" {
+		return dto.ResponseAvailabilityDTO{}, fmt.Errorf("%w: location is required", ErrInvalidFilter)
+	}
+	month, err := time.Parse(monthLayout, queryParams.Get("month"))
+	if err != nil {
+		return dto.ResponseAvailabilityDTO{}, fmt.Errorf("%w: month %q is not a month such as 2026-07", ErrInvalidFilter, queryParams.Get("month"))
+	}
+
+	holidays, err := s.repo.List(ctx, HolidayFilter{
+		Location:    parseLocationMatch(value),
+		StartFrom:   month.Format(time.DateOnly),
+		StartBefore: month.AddDate(0, 1, 0).Format(time.DateOnly),
+		Statuses:    []string{Published},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query availability", "error", err)
+		return dto.ResponseAvailabilityDTO{}, err
+	}
+
+	return availability(month, holidays), nil
+}
+
+func availability(month time.Time, holidays []Holiday) dto.ResponseAvailabilityDTO {
+	slices.SortStableFunc(holidays, func(a, b Holiday) int {
+		return cmp.Or(cmp.Compare(a.StartDate, b.StartDate), cmp.Compare(priceValue(a.Price), priceValue(b.Price)))
+	})
+
+	calendar := dto.ResponseAvailabilityDTO{Month: month.Format(monthLayout), Days: []dto.AvailabilityDayDTO{}}
+	for _, holiday := range holidays {
+		date := holiday.StartDate[:min(len(holiday.StartDate), len(time.DateOnly))]
+		if len(calendar.Days) == 0 || calendar.Days[len(calendar.Days)-1].Date != date {
+			calendar.Days = append(calendar.Days, dto.AvailabilityDayDTO{Date: date})
+		}
+
+		day := &calendar.Days[len(calendar.Days)-1]
+		day.FreeSlots += holiday.FreeSlots
+		if holiday.FreeSlots > 0 && day.LowestPrice == nil {
+			price := holiday.Price
+			day.LowestPrice = &price
+		}
+		day.Holidays = append(day.Holidays, dto.AvailableHolidayDTO{
+			ID:        holiday.ID,
+			Title:     holiday.Title,
+			Duration:  holiday.Duration,
+			FreeSlots: holiday.FreeSlots,
+			Price:     holiday.Price,
+		})
+	}
+
+	return calendar
+}
+
+// priceValue orders prices that are not numbers last.
+func priceValue(price string) float64 {
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return math.Inf(1)
+	}
+	return value
+}
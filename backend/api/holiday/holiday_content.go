@@ -0,0 +1,192 @@
+package holiday
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	"github.com/nikolaypleshkov/uni-api/locale"
+)
+
+// NormalizeContent lower-cases the service codes and accommodation type of
+// a holiday and checks them against the known values. A service may be
+// listed once and cannot be both included and excluded.
+func NormalizeContent(holiday *Holiday) error {
+	holiday.Description = strings.TrimSpace(holiday.Description)
+
+	var err error
+	if holiday.Included, err = normalizeServices(holiday.Included); err != nil {
+		return err
+	}
+	if holiday.Excluded, err = normalizeServices(holiday.Excluded); err != nil {
+		return err
+	}
+	for _, service := range holiday.Included {
+		if slices.Contains(holiday.Excluded, service) {
+			return fmt.Errorf("%w: %q is both included and excluded", ErrInvalidContent, service)
+		}
+	}
+
+	accommodation := &holiday.Accommodation
+	accommodation.Name = strings.TrimSpace(accommodation.Name)
+	accommodation.Type = strings.ToLower(strings.TrimSpace(accommodation.Type))
+	accommodation.Description = strings.TrimSpace(accommodation.Description)
+	if accommodation.Type != "" && !slices.Contains(AccommodationTypes, accommodation.Type) {
+		return fmt.Errorf("%w: accommodation type %q is not one of %s", ErrInvalidContent, accommodation.Type, strings.Join(AccommodationTypes, ", "))
+	}
+	if accommodation.Stars < 0 || accommodation.Stars > 5 {
+		return fmt.Errorf("%w: accommodation stars must be from 0 to 5", ErrInvalidContent)
+	}
+
+	return nil
+}
+
+func normalizeServices(services []string) ([]string, error) {
+	normalized := []string{}
+	for _, service := range services {
+		service = strings.ToLower(strings.TrimSpace(service))
+		if !slices.Contains(Services, service) {
+			return nil, fmt.Errorf("%w: %q is not one of %s", ErrInvalidContent, service, strings.Join(Services, ", "))
+		}
+		if !slices.Contains(normalized, service) {
+			normalized = append(normalized, service)
+		}
+	}
+	return normalized, nil
+}
+
+func (s *Service) GetHolidayTranslations(ctx context.Context, holidayID int64) (dto.HolidayTranslationsDTO, error) {
+	if _, err := s.GetHolidayDTO(ctx, holidayID); err != nil {
+		return dto.HolidayTranslationsDTO{}, err
+	}
+
+	translations, err := s.repo.ListTranslations(ctx, []int64{holidayID})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get holiday translations", "holiday_id", holidayID, "error", err)
+		return dto.HolidayTranslationsDTO{}, err
+	}
+
+	translationsDTO := dto.HolidayTranslationsDTO{Translations: map[string]dto.HolidayTranslationDTO{}}
+	for _, t := range translations[holidayID] {
+		translationsDTO.Translations[t.Language] = dto.HolidayTranslationDTO{
+			Title:                    t.Title,
+			Description:              t.Description,
+			AccommodationDescription: t.AccommodationDescription,
+		}
+	}
+
+	return translationsDTO, nil
+}
+
+// ReplaceHolidayTranslations replaces all of the holiday's translations.
+// Languages are BCP 47 tags other than the default language, which is the
+// holiday itself. A non-zero version makes the write conditional, as for
+// UpdateHoliday.
+func (s *Service) ReplaceHolidayTranslations(ctx context.Context, holidayID int64, translationsDTO dto.HolidayTranslationsDTO, version int64) (dto.HolidayTranslationsDTO, error) {
+	given, err := locale.Translations(translationsDTO.Translations)
+	if err != nil {
+		return dto.HolidayTranslationsDTO{}, fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+	translations := make([]Translation, len(given))
+	for i, g := range given {
+		translations[i] = Translation{
+			Language:                 g.Language,
+			Title:                    strings.TrimSpace(g.Text.Title),
+			Description:              strings.TrimSpace(g.Text.Description),
+			AccommodationDescription: strings.TrimSpace(g.Text.AccommodationDescription),
+		}
+		if t := translations[i]; t.Title == "" && t.Description == "" && t.AccommodationDescription == "" {
+			return dto.HolidayTranslationsDTO{}, fmt.Errorf("%w: the %s translation is empty", ErrInvalidTranslation, t.Language)
+		}
+	}
+
+	err = s.repo.ReplaceTranslations(ctx, holidayID, translations, version)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+			slog.ErrorContext(ctx, "Failed to replace holiday translations", "holiday_id", holidayID, "error", err)
+		}
+		return dto.HolidayTranslationsDTO{}, err
+	}
+
+	return s.GetHolidayTranslations(ctx, holidayID)
+}
+
+// Localize translates holidays, and the locations they embed, into the
+// language that best serves ranges among those each one is translated
+// into. Text without a translation stays in the default language. It
+// returns the languages used.
+func (s *Service) Localize(ctx context.Context, holidays []dto.ResponseHolidayDTO, ranges []string) ([]string, error) {
+	if len(holidays) == 0 {
+		return nil, nil
+	}
+	if len(ranges) == 0 {
+		return []string{locale.Default}, nil
+	}
+
+	holidayIDs := make([]int64, len(holidays))
+	for i, holiday := range holidays {
+		holidayIDs[i] = holiday.ID
+	}
+	translations, err := s.repo.ListTranslations(ctx, holidayIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query holiday translations", "error", err)
+		return nil, err
+	}
+
+	var languages []string
+	locations := make([]locationdto.ResponseLocationDTO, len(holidays))
+	for i := range holidays {
+		language := translate(&holidays[i], translations[holidays[i].ID], ranges)
+		if !slices.Contains(languages, language) {
+			languages = append(languages, language)
+		}
+		locations[i] = holidays[i].Location
+	}
+
+	locationLanguages, err := s.locationService.Localize(ctx, locations, ranges)
+	if err != nil {
+		return nil, err
+	}
+	for i := range holidays {
+		holidays[i].Location = locations[i]
+	}
+	for _, language := range locationLanguages {
+		if !slices.Contains(languages, language) {
+			languages = append(languages, language)
+		}
+	}
+
+	return languages, nil
+}
+
+// translate applies the translation that best serves ranges to holiday and
+// returns its language.
+func translate(holiday *dto.ResponseHolidayDTO, translations []Translation, ranges []string) string {
+	available := make([]string, len(translations))
+	for i, t := range translations {
+		available[i] = t.Language
+	}
+	language := locale.Match(ranges, available)
+
+	for _, t := range translations {
+		if t.Language != language {
+			continue
+		}
+		if t.Title != "" {
+			holiday.Title = t.Title
+		}
+		if t.Description != "" {
+			holiday.Description = t.Description
+		}
+		if t.AccommodationDescription != "" {
+			holiday.Accommodation.Description = t.AccommodationDescription
+		}
+	}
+
+	return language
+}
@@ -1,128 +1,598 @@
-package holiday
-
-import (
-	"encoding/json"
-	"net/http"
-	"strconv"
-
-	"github.com/gorilla/mux"
-	"github.com/nikolaypleshkov/uni-api/api/holiday/dto"
-)
-
-type Controller struct {
-	service *Service
-}
-
-func convertHolidayToDTO(holiday Holiday) dto.CreateHolidayDTO {
-	return dto.CreateHolidayDTO{
-		Title:     holiday.Title,
-		StartDate: holiday.StartDate,
-		Duration:  holiday.Duration,
-		FreeSlots: holiday.FreeSlots,
-		Price:     holiday.Price,
-		Location:  holiday.LocationID,
-	}
-}
-
-func NewController(service *Service) *Controller {
-	return &Controller{
-		service: service,
-	}
-}
-
-func (c *Controller) CreateHoliday(w http.ResponseWriter, r *http.Request) {
-	var holiday Holiday
-	err := json.NewDecoder(r.Body).Decode(&holiday)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	createHolidayDTO := convertHolidayToDTO(holiday)
-
-	createdHoliday, err := c.service.CreateHoliday(createHolidayDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(createdHoliday)
-}
-
-func (c *Controller) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	holidayIDStr := params["holidayId"]
-
-	holidayID, err := strconv.ParseInt(holidayIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
-		return
-	}
-
-	err = c.service.DeleteHoliday(holidayID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-func (c *Controller) GetHolidays(w http.ResponseWriter, r *http.Request) {
-	queryParams := r.URL.Query()
-
-	holidays, err := c.service.GetHolidays(queryParams)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	holidaysJSON, err := json.Marshal(holidays)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(holidaysJSON)
-}
-
-func (c *Controller) GetHoliday(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	holidayIDStr := params["holidayId"]
-
-	holidayID, err := strconv.ParseInt(holidayIDStr, 10, 64)
-	if err != nil {
-		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
-		return
-	}
-
-	holiday, err := c.service.GetHoliday(holidayID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(holiday)
-}
-
-func (c *Controller) UpdateHoliday(w http.ResponseWriter, r *http.Request) {
-	var updateDTO dto.UpdateHolidayDTO
-
-	err := json.NewDecoder(r.Body).Decode(&updateDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	err = c.service.UpdateHoliday(updateDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updateDTO)
-}
+package holiday
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	"github.com/nikolaypleshkov/uni-api/etag"
+	"github.com/nikolaypleshkov/uni-api/locale"
+	"github.com/nikolaypleshkov/uni-api/mergepatch"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func convertHolidayToDTO(holiday Holiday) dto.CreateHolidayDTO {
+	return dto.CreateHolidayDTO{
+		Title:     holiday.Title,
+		StartDate: holiday.StartDate,
+		Duration:  holiday.Duration,
+		FreeSlots: holiday.FreeSlots,
+		Price:     holiday.Price,
+		Location:  holiday.LocationID,
+
+		Description: holiday.Description,
+		Included:    holiday.Included,
+		Excluded:    holiday.Excluded,
+		Accommodation: dto.AccommodationDTO{
+			Name:        holiday.Accommodation.Name,
+			Type:        holiday.Accommodation.Type,
+			Stars:       holiday.Accommodation.Stars,
+			Description: holiday.Accommodation.Description,
+		},
+		Status:      holiday.Status,
+		PublishAt:   holiday.PublishAt,
+		UnpublishAt: holiday.UnpublishAt,
+	}
+}
+
+func NewController(service *Service) *Controller {
+	return &Controller{
+		service: service,
+	}
+}
+
+func (c *Controller) CreateHoliday(w http.ResponseWriter, r *http.Request) {
+	var holiday Holiday
+	err := json.NewDecoder(r.Body).Decode(&holiday)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createHolidayDTO := convertHolidayToDTO(holiday)
+
+	createdHoliday, err := c.service.CreateHoliday(r.Context(), createHolidayDTO)
+	if errors.Is(err, ErrInvalidContent) || errors.Is(err, ErrInvalidStatus) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(createdHoliday)
+}
+
+func (c *Controller) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayIDStr := params["holidayId"]
+
+	holidayID, err := strconv.ParseInt(holidayIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentHoliday(w, r, holidayID)
+	if !ok || !etag.CheckIfMatch(w, r, holidayETag(current)) {
+		return
+	}
+
+	err = c.service.DeleteHoliday(r.Context(), holidayID, current.Version)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) GetHolidays(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	facets := false
+	if value := queryParams.Get("facets"); value != "" {
+		var err error
+		if facets, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "facets must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ranges := locale.Preferences(w, r)
+
+	var holidays any
+	var languages []string
+	var err error
+	if facets {
+		var search dto.ResponseHolidaySearchDTO
+		search, err = c.service.SearchHolidays(r.Context(), queryParams)
+		if err == nil {
+			languages, err = c.service.Localize(r.Context(), search.Holidays, ranges)
+		}
+		holidays = search
+	} else {
+		var list []dto.ResponseHolidayDTO
+		list, err = c.service.GetHolidays(r.Context(), queryParams)
+		if err == nil {
+			languages, err = c.service.Localize(r.Context(), list, ranges)
+		}
+		holidays = list
+	}
+	if errors.Is(err, ErrInvalidFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	holidaysJSON, err := json.Marshal(holidays)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	locale.SetContentLanguage(w, languages)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(holidaysJSON)
+}
+
+// GetAvailability serves the calendar with a tag of its content, as it
+// changes with any booking rather than with one version.
+func (c *Controller) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	calendar, err := c.service.GetAvailability(r.Context(), r.URL.Query())
+	if errors.Is(err, ErrInvalidFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	calendarJSON, err := json.Marshal(calendar)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", availabilityCacheControl)
+	if etag.NotModified(w, r, etag.Digest(calendarJSON)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(calendarJSON)
+}
+
+func (c *Controller) GetHoliday(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayIDStr := params["holidayId"]
+
+	holidayID, err := strconv.ParseInt(holidayIDStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	ranges := locale.Preferences(w, r)
+
+	holiday, err := c.service.GetHoliday(r.Context(), holidayID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	holidays := []dto.ResponseHolidayDTO{holiday}
+	languages, err := c.service.Localize(r.Context(), holidays, ranges)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	holiday = holidays[0]
+
+	locale.SetContentLanguage(w, languages)
+	if etag.NotModified(w, r, etag.Localized(holidayETag(holiday), languages)) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(holiday)
+}
+
+func (c *Controller) UpdateHoliday(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	var updateDTO dto.UpdateHolidayDTO
+
+	err = json.NewDecoder(r.Body).Decode(&updateDTO)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if updateDTO.ID != 0 && updateDTO.ID != holidayID {
+		http.Error(w, "Holiday ID in body does not match the URL", http.StatusBadRequest)
+		return
+	}
+	updateDTO.ID = holidayID
+
+	current, ok := c.currentHoliday(w, r, holidayID)
+	if !ok || !etag.CheckIfMatch(w, r, holidayETag(current)) {
+		return
+	}
+	updateDTO.Version = current.Version
+
+	err = c.service.UpdateHoliday(r.Context(), updateDTO)
+	if errors.Is(err, ErrInvalidItinerary) || errors.Is(err, ErrInvalidContent) || errors.Is(err, ErrInvalidStatus) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	updated, err := c.service.GetHoliday(r.Context(), holidayID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", holidayETag(updated))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (c *Controller) PatchHoliday(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	if !mergepatch.AcceptsContentType(r.Header.Get("Content-Type")) {
+		http.Error(w, "Content-Type must be "+mergepatch.MediaType, http.StatusUnsupportedMediaType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentHoliday(w, r, holidayID)
+	if !ok || !etag.CheckIfMatch(w, r, holidayETag(current)) {
+		return
+	}
+
+	holiday, err := c.service.PatchHoliday(r.Context(), holidayID, patch, current.Version)
+	if errors.Is(err, mergepatch.ErrInvalidPatch) || errors.Is(err, ErrInvalidItinerary) || errors.Is(err, ErrInvalidContent) || errors.Is(err, ErrInvalidStatus) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", holidayETag(holiday))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(holiday)
+}
+
+func (c *Controller) GetItinerary(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	itinerary, ok := c.currentItinerary(w, r, holidayID)
+	if !ok || etag.NotModified(w, r, itineraryETag(itinerary)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(itinerary)
+}
+
+// ReplaceItinerary replaces the holiday's stops. The itinerary is part of
+// the holiday, so the request is conditional on the itinerary's ETag and
+// changes the holiday's too.
+func (c *Controller) ReplaceItinerary(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	var itineraryDTO dto.UpdateItineraryDTO
+	if err := json.NewDecoder(r.Body).Decode(&itineraryDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentItinerary(w, r, holidayID)
+	if !ok || !etag.CheckIfMatch(w, r, itineraryETag(current)) {
+		return
+	}
+
+	itinerary, err := c.service.ReplaceItinerary(r.Context(), holidayID, itineraryDTO, current.Version)
+	if !c.writeItineraryError(w, err) {
+		return
+	}
+
+	w.Header().Set("ETag", itineraryETag(itinerary))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(itinerary)
+}
+
+// DeleteItinerary removes every stop of the holiday.
+func (c *Controller) DeleteItinerary(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentItinerary(w, r, holidayID)
+	if !ok || !etag.CheckIfMatch(w, r, itineraryETag(current)) {
+		return
+	}
+
+	_, err = c.service.ReplaceItinerary(r.Context(), holidayID, dto.UpdateItineraryDTO{}, current.Version)
+	if !c.writeItineraryError(w, err) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeItineraryError writes the response for a failed itinerary write and
+// returns whether err is nil.
+func (c *Controller) writeItineraryError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrInvalidItinerary):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrUnknownLocation):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrVersionMismatch):
+		etag.PreconditionFailed(w)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
+
+func (c *Controller) GetHolidayTags(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentHoliday(w, r, holidayID)
+	if !ok || etag.NotModified(w, r, holidayETag(current)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.HolidayTagsDTO{Tags: current.Tags})
+}
+
+// ReplaceHolidayTags sets the holiday's tags. Like the itinerary, the tags
+// are part of the holiday and share its ETag.
+func (c *Controller) ReplaceHolidayTags(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	var tagsDTO dto.HolidayTagsDTO
+	if err := json.NewDecoder(r.Body).Decode(&tagsDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentHoliday(w, r, holidayID)
+	if !ok || !etag.CheckIfMatch(w, r, holidayETag(current)) {
+		return
+	}
+
+	tags, err := c.service.ReplaceHolidayTags(r.Context(), holidayID, tagsDTO, current.Version)
+	if errors.Is(err, ErrUnknownTag) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if updated, err := c.service.GetHoliday(r.Context(), holidayID); err == nil {
+		w.Header().Set("ETag", holidayETag(updated))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+func (c *Controller) GetHolidayTranslations(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentHoliday(w, r, holidayID)
+	if !ok || etag.NotModified(w, r, holidayETag(current)) {
+		return
+	}
+
+	translations, err := c.service.GetHolidayTranslations(r.Context(), holidayID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(translations)
+}
+
+// ReplaceHolidayTranslations replaces the holiday's translations. They are
+// part of the holiday and share its ETag.
+func (c *Controller) ReplaceHolidayTranslations(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	holidayID, err := strconv.ParseInt(params["holidayId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid holiday ID", http.StatusBadRequest)
+		return
+	}
+
+	var translationsDTO dto.HolidayTranslationsDTO
+	if err := json.NewDecoder(r.Body).Decode(&translationsDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentHoliday(w, r, holidayID)
+	if !ok || !etag.CheckIfMatch(w, r, holidayETag(current)) {
+		return
+	}
+
+	translations, err := c.service.ReplaceHolidayTranslations(r.Context(), holidayID, translationsDTO, current.Version)
+	if errors.Is(err, ErrInvalidTranslation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if updated, err := c.service.GetHoliday(r.Context(), holidayID); err == nil {
+		w.Header().Set("ETag", holidayETag(updated))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(translations)
+}
+
+// currentHoliday loads the holiday a conditional request refers to. It
+// writes the error response itself and returns false when that fails.
+func (c *Controller) currentHoliday(w http.ResponseWriter, r *http.Request, holidayID int64) (dto.ResponseHolidayDTO, bool) {
+	holiday, err := c.service.GetHoliday(r.Context(), holidayID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return dto.ResponseHolidayDTO{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return dto.ResponseHolidayDTO{}, false
+	}
+
+	return holiday, true
+}
+
+// currentItinerary loads the itinerary a request refers to, like
+// currentHoliday.
+func (c *Controller) currentItinerary(w http.ResponseWriter, r *http.Request, holidayID int64) (dto.ResponseItineraryDTO, bool) {
+	itinerary, err := c.service.GetItinerary(r.Context(), holidayID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return dto.ResponseItineraryDTO{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return dto.ResponseItineraryDTO{}, false
+	}
+
+	return itinerary, true
+}
+
+// itineraryETag covers the location of every stop, so editing one of them
+// invalidates cached itineraries.
+func itineraryETag(itinerary dto.ResponseItineraryDTO) string {
+	versions := []int64{itinerary.Version}
+	for _, stop := range itinerary.Stops {
+		versions = append(versions, stop.Location.Version)
+	}
+	return etag.Format(versions...)
+}
+
+// holidayETag covers the embedded location too, so editing the location
+// invalidates cached holidays.
+func holidayETag(holiday dto.ResponseHolidayDTO) string {
+	return etag.Format(holiday.Version, holiday.Location.Version)
+}
@@ -0,0 +1,154 @@
+package holiday
+
+import (
+	"cmp"
+	"context"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	"github.com/nikolaypleshkov/uni-api/iso3166"
+)
+
+// band is one bucket of a range facet, from inclusive to to exclusive. A
+// zero to has no upper bound.
+type band struct {
+	key      string
+	from, to float64
+}
+
+var (
+	durationBands = []band{
+		{key: "1-3", from: 1, to: 4},
+		{key: "4-7", from: 4, to: 8},
+		{key: "8-14", from: 8, to: 15},
+		{key: "15+", from: 15},
+	}
+	priceBands = []band{
+		{key: "0-500", from: 0, to: 500},
+		{key: "500-1000", from: 500, to: 1000},
+		{key: "1000-2000", from: 1000, to: 2000},
+		{key: "2000+", from: 2000},
+	}
+)
+
+// SearchHolidays lists holidays like GetHolidays and adds facet counts over
+// the result: per tag, per country visited, per duration band and per price
+// band.
+func (s *Service) SearchHolidays(ctx context.Context, queryParams url.Values) (dto.ResponseHolidaySearchDTO, error) {
+	holidays, err := s.GetHolidays(ctx, queryParams)
+	if err != nil {
+		return dto.ResponseHolidaySearchDTO{}, err
+	}
+	facets, err := s.facets(ctx, holidays)
+	if err != nil {
+		return dto.ResponseHolidaySearchDTO{}, err
+	}
+
+	if holidays == nil {
+		holidays = []dto.ResponseHolidayDTO{}
+	}
+	return dto.ResponseHolidaySearchDTO{Holidays: holidays, Facets: facets}, nil
+}
+
+func (s *Service) facets(ctx context.Context, holidays []dto.ResponseHolidayDTO) (dto.HolidayFacetsDTO, error) {
+	countries, err := s.visitedCountries(ctx, holidays)
+	if err != nil {
+		return dto.HolidayFacetsDTO{}, err
+	}
+	tags, err := s.tagService.GetTags(ctx)
+	if err != nil {
+		return dto.HolidayFacetsDTO{}, err
+	}
+
+	tagCounts := make(map[string]int64)
+	countryCounts := make(map[string]int64)
+	durations := make([]float64, 0, len(holidays))
+	prices := make([]float64, 0, len(holidays))
+	for _, holiday := range holidays {
+		for _, slug := range holiday.Tags {
+			tagCounts[slug]++
+		}
+		for _, code := range countries[holiday.ID] {
+			countryCounts[code]++
+		}
+		durations = append(durations, float64(holiday.Duration))
+		if price, err := strconv.ParseFloat(holiday.Price, 64); err == nil {
+			prices = append(prices, price)
+		}
+	}
+
+	facets := dto.HolidayFacetsDTO{
+		Tags:      []dto.TagFacetDTO{},
+		Countries: []dto.CountryFacetDTO{},
+		Durations: rangeFacets(durationBands, durations),
+		Prices:    rangeFacets(priceBands, prices),
+	}
+	for _, tag := range tags {
+		if count := tagCounts[tag.Slug]; count > 0 {
+			facets.Tags = append(facets.Tags, dto.TagFacetDTO{Slug: tag.Slug, Name: tag.Name, Count: count})
+		}
+	}
+	for code, count := range countryCounts {
+		country, _ := iso3166.Lookup(code)
+		facets.Countries = append(facets.Countries, dto.CountryFacetDTO{Code: code, Name: country.Name, Count: count})
+	}
+
+	slices.SortStableFunc(facets.Tags, func(a, b dto.TagFacetDTO) int { return cmp.Compare(b.Count, a.Count) })
+	slices.SortFunc(facets.Countries, func(a, b dto.CountryFacetDTO) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Code, b.Code))
+	})
+	return facets, nil
+}
+
+// visitedCountries returns the distinct country codes of each holiday's
+// location and itinerary stops, by holiday ID.
+func (s *Service) visitedCountries(ctx context.Context, holidays []dto.ResponseHolidayDTO) (map[int64][]string, error) {
+	ids := make([]int64, len(holidays))
+	for i, holiday := range holidays {
+		ids[i] = holiday.ID
+	}
+	itineraries, err := s.repo.ListItineraries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := s.locationService.GetAllLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	countryOf := make(map[int64]string, len(locations))
+	for _, location := range locations {
+		countryOf[location.ID] = location.CountryCode
+	}
+
+	countries := make(map[int64][]string, len(holidays))
+	for _, holiday := range holidays {
+		codes := []string{holiday.Location.CountryCode}
+		for _, stop := range itineraries[holiday.ID] {
+			codes = append(codes, countryOf[stop.LocationID])
+		}
+		slices.Sort(codes)
+		codes = slices.Compact(codes)
+		countries[holiday.ID] = slices.DeleteFunc(codes, func(code string) bool { return code == "" })
+	}
+
+	return countries, nil
+}
+
+func rangeFacets(bands []band, values []float64) []dto.RangeFacetDTO {
+	facets := make([]dto.RangeFacetDTO, len(bands))
+	for i, b := range bands {
+		facets[i] = dto.RangeFacetDTO{Key: b.key, From: b.from}
+		if b.to != 0 {
+			to := b.to
+			facets[i].To = &to
+		}
+		for _, value := range values {
+			if value >= b.from && (b.to == 0 || value < b.to) {
+				facets[i].Count++
+			}
+		}
+	}
+	return facets
+}
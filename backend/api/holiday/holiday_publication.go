@@ -0,0 +1,105 @@
+package holiday
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// normalizePublication checks the status and schedule of a holiday. A
+// holiday without a status is published, as holidays were before they had
+// one, so clients that never set it keep working, unless publishAt
+// schedules its publication, which makes it a draft until then. Schedules that cannot apply are
+// dropped: a published holiday has nothing left to publish and an archived
+// one is never published or taken back again. Times are kept to the
+// millisecond, as they are stored.
+func normalizePublication(holiday *Holiday) error {
+	holiday.Status = strings.ToLower(strings.TrimSpace(holiday.Status))
+	if holiday.Status == "" {
+		holiday.Status = Published
+		if holiday.PublishAt != nil {
+			holiday.Status = Draft
+		}
+	}
+	if !slices.Contains(Statuses, holiday.Status) {
+		return fmt.Errorf("%w: %q is not one of %s", ErrInvalidStatus, holiday.Status, strings.Join(Statuses, ", "))
+	}
+
+	switch holiday.Status {
+	case Published:
+		holiday.PublishAt = nil
+	case Archived:
+		holiday.PublishAt, holiday.UnpublishAt = nil, nil
+	}
+	holiday.PublishAt, holiday.UnpublishAt = scheduleTime(holiday.PublishAt), scheduleTime(holiday.UnpublishAt)
+
+	if holiday.PublishAt != nil && holiday.UnpublishAt != nil && !holiday.UnpublishAt.After(*holiday.PublishAt) {
+		return fmt.Errorf("%w: unpublishAt must be after publishAt", ErrInvalidStatus)
+	}
+	return nil
+}
+
+func scheduleTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	scheduled := t.UTC().Truncate(time.Millisecond)
+	return &scheduled
+}
+
+// parseStatuses reads the comma-separated statuses a listing shows.
+// Customers see published holidays only, which is the default.
+func parseStatuses(value string) ([]string, error) {
+	if value == "" {
+		return []string{Published}, nil
+	}
+
+	var statuses []string
+	for _, status := range strings.Split(value, ",") {
+		status = strings.ToLower(strings.TrimSpace(status))
+		if !slices.Contains(Statuses, status) {
+			return nil, fmt.Errorf("%w: status %q is not one of %s", ErrInvalidFilter, status, strings.Join(Statuses, ", "))
+		}
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// ApplySchedule carries out the scheduled publications and withdrawals that
+// are due at now and archives the holidays that have started.
+func (s *Service) ApplySchedule(ctx context.Context, now time.Time) (int64, error) {
+	changed, err := s.repo.ApplySchedule(ctx, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to apply holiday schedule", "error", err)
+		return 0, err
+	}
+
+	return changed, nil
+}
+
+// ApplyScheduleEvery runs ApplySchedule every interval until ctx is done, so
+// holidays change status at most one interval late.
+func ApplyScheduleEvery(ctx context.Context, service *Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			changed, err := service.ApplySchedule(ctx, now)
+			if err != nil {
+				continue
+			}
+			if changed > 0 {
+				slog.DebugContext(ctx, "Applied holiday schedule", "changes", changed)
+			}
+		}
+	}
+}
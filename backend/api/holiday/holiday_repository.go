@@ -0,0 +1,92 @@
+package holiday
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("holiday not found")
+	ErrInvalidFilter = errors.New("invalid holiday filter")
+
+	ErrInvalidItinerary = errors.New("invalid itinerary")
+	ErrUnknownLocation  = errors.New("itinerary stop location not found")
+	ErrUnknownTag       = errors.New("tag not found")
+
+	ErrInvalidContent     = errors.New("invalid holiday content")
+	ErrInvalidTranslation = errors.New("invalid translation")
+	ErrInvalidStatus      = errors.New("invalid holiday status")
+
+	ErrVersionMismatch = errors.New("holiday was modified by another request")
+)
+
+type HolidayFilter struct {
+	StartDate string
+	// StartFrom and StartBefore, when set, match holidays starting on or
+	// after StartFrom and before StartBefore.
+	StartFrom   string
+	StartBefore string
+	Duration    *int32
+	// CountryCode is the ISO 3166-1 alpha-2 code of a country the holiday
+	// visits, at its location or any itinerary stop.
+	CountryCode string
+	// Location, when set, matches holidays whose location or any itinerary
+	// stop matches it.
+	Location *LocationMatch
+	// Tags are slugs of tags the holiday must all have.
+	Tags []string
+	// Statuses, when set, are the statuses the holiday may have.
+	Statuses []string
+	// TemplateID, when set, matches the departures of that template.
+	TemplateID int64
+}
+
+// LocationMatch matches a location by any of its set fields: the ID, the
+// city compared ignoring case, or the ISO 3166-1 alpha-2 country code.
+type LocationMatch struct {
+	LocationID  int64
+	City        string
+	CountryCode string
+}
+
+// A non-zero Version on Update, or version on Delete, makes the write
+// conditional: it fails with ErrVersionMismatch unless the stored row still
+// has that version. Every successful write increments the version.
+type HolidayRepository interface {
+	Create(ctx context.Context, holiday Holiday) (Holiday, error)
+	Delete(ctx context.Context, holidayID int64, version int64) error
+	List(ctx context.Context, filter HolidayFilter) ([]Holiday, error)
+	Get(ctx context.Context, holidayID int64) (Holiday, error)
+	// GetMany returns those of the holidays that exist, by ID.
+	GetMany(ctx context.Context, holidayIDs []int64) (map[int64]Holiday, error)
+	Update(ctx context.Context, holiday Holiday) error
+	// GetItinerary returns the holiday's stops in order, or ErrNotFound.
+	GetItinerary(ctx context.Context, holidayID int64) ([]Stop, error)
+	// ReplaceItinerary replaces the holiday's stops. It is a write to the
+	// holiday: version makes it conditional and the holiday's version is
+	// incremented.
+	ReplaceItinerary(ctx context.Context, holidayID int64, stops []Stop, version int64) error
+	// ListItineraries returns the stops of each of the holidays that has
+	// any, by holiday ID.
+	ListItineraries(ctx context.Context, holidayIDs []int64) (map[int64][]Stop, error)
+	// ListTags returns the slugs of the tags of each of the holidays that
+	// has any, in order, by holiday ID.
+	ListTags(ctx context.Context, holidayIDs []int64) (map[int64][]string, error)
+	// ReplaceTags sets the holiday's tags to the tags with the given slugs,
+	// or fails with ErrUnknownTag. Like ReplaceItinerary it is a
+	// conditional write to the holiday.
+	ReplaceTags(ctx context.Context, holidayID int64, slugs []string, version int64) error
+	// ListTranslations returns the translations of each of the holidays
+	// that has any, ordered by language, by holiday ID.
+	ListTranslations(ctx context.Context, holidayIDs []int64) (map[int64][]Translation, error)
+	// ReplaceTranslations replaces all of the holiday's translations. Like
+	// ReplaceItinerary it is a conditional write to the holiday.
+	ReplaceTranslations(ctx context.Context, holidayID int64, translations []Translation, version int64) error
+	// ApplySchedule publishes the drafts whose PublishAt has come, takes
+	// back to draft the published holidays whose UnpublishAt has come and
+	// archives the holidays that start before now's date in UTC, clearing
+	// the schedules it has carried out. It returns the number of changes
+	// made; each one increments the holiday's version.
+	ApplySchedule(ctx context.Context, now time.Time) (int64, error)
+}
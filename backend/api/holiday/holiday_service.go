@@ -1,300 +1,604 @@
-package holiday
-
-import (
-	"database/sql"
-	"fmt"
-	"log"
-	"net/url"
-	"strconv"
-	"sync"
-
-	_ "github.com/lib/pq"
-	"github.com/nikolaypleshkov/uni-api/api/holiday/dto"
-	"github.com/nikolaypleshkov/uni-api/api/location"
-)
-
-type Service struct {
-	mu              sync.Mutex
-	db              *sql.DB
-	holidays        []Holiday
-	locationService *location.LocationServiceImpl
-}
-
-func NewService(db *sql.DB, locationService *location.LocationServiceImpl) *Service {
-	return &Service{
-		db:              db,
-		holidays:        make([]Holiday, 0),
-		locationService: locationService,
-	}
-}
-func (s *Service) ensureTableExists() error {
-	query := `
-        CREATE TABLE IF NOT EXISTS holidays (
-            id SERIAL PRIMARY KEY,
-            title VARCHAR(255),
-            start_date DATE,
-            duration INT,
-            free_slots INT,
-            price DECIMAL(10,2),
-            location_id INT,
-            FOREIGN KEY (location_id) REFERENCES locations(id)
-        );
-    `
-	_, err := s.db.Exec(query)
-	return err
-}
-
-func (s *Service) DropAndRecreateTable() error {
-	dropQuery := "DROP TABLE IF EXISTS holidays"
-	_, err := s.db.Exec(dropQuery)
-	if err != nil {
-		return fmt.Errorf("failed to drop holidays table: %v", err)
-	}
-
-	createQuery := `
-		CREATE TABLE IF NOT EXISTS holidays (
-			id SERIAL PRIMARY KEY,
-			title VARCHAR(255),
-			start_date DATE,
-			duration INT,
-			free_slots INT,
-			price DECIMAL(10,2),
-			location_id INT,
-			FOREIGN KEY (location_id) REFERENCES locations(id)
-		);
-	`
-	_, err = s.db.Exec(createQuery)
-	if err != nil {
-		return fmt.Errorf("failed to recreate holidays table: %v", err)
-	}
-
-	return nil
-}
-
-func (s *Service) CreateHoliday(holidayDTO dto.CreateHolidayDTO) (dto.ResponseHolidayDTO, error) {
-	if err := s.ensureTableExists(); err != nil {
-		return dto.ResponseHolidayDTO{}, err
-	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	var locationID sql.NullInt64
-	if holidayDTO.Location != -1 {
-		locationID = sql.NullInt64{Int64: holidayDTO.Location, Valid: true}
-	} else {
-		locationID = sql.NullInt64{Valid: false}
-	}
-
-	query := `
-        INSERT INTO holidays (title, start_date, duration, free_slots, price, location_id)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING id, title, start_date, duration, free_slots, price, location_id
-    `
-	row := s.db.QueryRow(
-		query,
-		holidayDTO.Title,
-		holidayDTO.StartDate,
-		holidayDTO.Duration,
-		holidayDTO.FreeSlots,
-		holidayDTO.Price,
-		locationID,
-	)
-
-	var createdHoliday Holiday
-	err := row.Scan(
-		&createdHoliday.ID,
-		&createdHoliday.Title,
-		&createdHoliday.StartDate,
-		&createdHoliday.Duration,
-		&createdHoliday.FreeSlots,
-		&createdHoliday.Price,
-		&createdHoliday.LocationID,
-	)
-
-	if err != nil {
-		return dto.ResponseHolidayDTO{}, err
-	}
-
-	responseDTO := dto.ResponseHolidayDTO{
-		ID:         createdHoliday.ID,
-		Title:      createdHoliday.Title,
-		StartDate:  createdHoliday.StartDate,
-		Duration:   createdHoliday.Duration,
-		FreeSlots:  createdHoliday.FreeSlots,
-		Price:      createdHoliday.Price,
-		LocationID: createdHoliday.LocationID,
-	}
-
-	return responseDTO, nil
-}
-
-func (s *Service) DeleteHoliday(holidayID int64) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := "DELETE FROM holidays WHERE id = $1"
-
-	_, err := s.db.Exec(query, holidayID)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *Service) GetHolidays(queryParams url.Values) ([]dto.ResponseHolidayDTO, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := "SELECT * FROM holidays WHERE 1 = 1"
-
-	if startDate, ok := queryParams["startDate"]; ok {
-		query += fmt.Sprintf(" AND start_date = '%s'", startDate)
-	}
-	if duration, ok := queryParams["duration"]; ok {
-		query += fmt.Sprintf(" AND duration = %s", duration)
-	}
-
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var resultDTOs []dto.ResponseHolidayDTO
-	for rows.Next() {
-		var holiday Holiday
-		err := rows.Scan(
-			&holiday.ID,
-			&holiday.Title,
-			&holiday.StartDate,
-			&holiday.Duration,
-			&holiday.FreeSlots,
-			&holiday.Price,
-			&holiday.LocationID,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		locationDTO, err := s.locationService.GetLocation(holiday.LocationID)
-		if err != nil {
-			return nil, err
-		}
-
-		resultDTO := dto.ResponseHolidayDTO{
-			ID:        holiday.ID,
-			Title:     holiday.Title,
-			StartDate: holiday.StartDate,
-			Duration:  holiday.Duration,
-			FreeSlots: holiday.FreeSlots,
-			Price:     holiday.Price,
-			Location:  locationDTO,
-		}
-
-		resultDTOs = append(resultDTOs, resultDTO)
-	}
-
-	log.Printf("Successfully retrieved %d holidays", len(resultDTOs))
-	return resultDTOs, nil
-}
-func (s *Service) GetHoliday(holidayID int64) (dto.ResponseHolidayDTO, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := "SELECT * FROM holidays WHERE id = $1"
-
-	row := s.db.QueryRow(query, holidayID)
-
-	var holiday Holiday
-	err := row.Scan(
-		&holiday.ID,
-		&holiday.Title,
-		&holiday.StartDate,
-		&holiday.Duration,
-		&holiday.FreeSlots,
-		&holiday.Price,
-		&holiday.LocationID,
-	)
-
-	if err != nil {
-		return dto.ResponseHolidayDTO{}, err
-	}
-
-	locationDTO, err := s.locationService.GetLocation(holiday.LocationID)
-	if err != nil {
-		return dto.ResponseHolidayDTO{}, err
-	}
-
-	responseDTO := dto.ResponseHolidayDTO{
-		ID:        holiday.ID,
-		Title:     holiday.Title,
-		StartDate: holiday.StartDate,
-		Duration:  holiday.Duration,
-		FreeSlots: holiday.FreeSlots,
-		Price:     holiday.Price,
-		Location:  locationDTO,
-	}
-
-	return responseDTO, nil
-}
-func (s *Service) UpdateHoliday(updateDTO dto.UpdateHolidayDTO) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := `
-        UPDATE holidays
-        SET title = $1, start_date = $2, duration = $3, free_slots = $4, price = $5,
-            location_id = $6
-        WHERE id = $7
-    `
-
-	priceString := strconv.FormatFloat(updateDTO.Price, 'f', -1, 64)
-
-	_, err := s.db.Exec(
-		query,
-		updateDTO.Title,
-		updateDTO.StartDate,
-		updateDTO.Duration,
-		updateDTO.FreeSlots,
-		priceString,
-		updateDTO.Location,
-		updateDTO.ID,
-	)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *Service) GetHolidayDTO(holidayID int64) (Holiday, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := "SELECT * FROM holidays WHERE id = $1"
-
-	row := s.db.QueryRow(query, holidayID)
-
-	var holiday Holiday
-	err := row.Scan(
-		&holiday.ID,
-		&holiday.Title,
-		&holiday.StartDate,
-		&holiday.Duration,
-		&holiday.FreeSlots,
-		&holiday.Price,
-		&holiday.LocationID,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return Holiday{}, fmt.Errorf("holiday with ID %d not found", holidayID)
-		}
-		return Holiday{}, err
-	}
-
-	return holiday, nil
-}
+package holiday
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	"github.com/nikolaypleshkov/uni-api/api/tag"
+	"github.com/nikolaypleshkov/uni-api/geo"
+	"github.com/nikolaypleshkov/uni-api/iso3166"
+	"github.com/nikolaypleshkov/uni-api/mergepatch"
+)
+
+type Service struct {
+	repo            HolidayRepository
+	locationService location.LocationService
+	tagService      *tag.Service
+}
+
+func NewService(repo HolidayRepository, locationService location.LocationService, tagService *tag.Service) *Service {
+	return &Service{
+		repo:            repo,
+		locationService: locationService,
+		tagService:      tagService,
+	}
+}
+
+func (s *Service) CreateHoliday(ctx context.Context, holidayDTO dto.CreateHolidayDTO) (dto.ResponseHolidayDTO, error) {
+	holiday := Holiday{
+		Title:         holidayDTO.Title,
+		Description:   holidayDTO.Description,
+		StartDate:     holidayDTO.StartDate,
+		Duration:      holidayDTO.Duration,
+		FreeSlots:     holidayDTO.FreeSlots,
+		Price:         holidayDTO.Price,
+		LocationID:    holidayDTO.Location,
+		Included:      holidayDTO.Included,
+		Excluded:      holidayDTO.Excluded,
+		Accommodation: Accommodation(holidayDTO.Accommodation),
+		Status:        holidayDTO.Status,
+		PublishAt:     holidayDTO.PublishAt,
+		UnpublishAt:   holidayDTO.UnpublishAt,
+	}
+	if err := NormalizeContent(&holiday); err != nil {
+		return dto.ResponseHolidayDTO{}, err
+	}
+	if err := normalizePublication(&holiday); err != nil {
+		return dto.ResponseHolidayDTO{}, err
+	}
+
+	createdHoliday, err := s.repo.Create(ctx, holiday)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create holiday", "error", err)
+		return dto.ResponseHolidayDTO{}, err
+	}
+
+	responseDTO := convertHolidayToResponseDTO(createdHoliday, nil)
+	responseDTO.LocationID = createdHoliday.LocationID
+
+	return responseDTO, nil
+}
+
+// convertHolidayToResponseDTO fills in everything but the location, which
+// callers embed or refer to by ID.
+func convertHolidayToResponseDTO(holiday Holiday, tags []string) dto.ResponseHolidayDTO {
+	if tags == nil {
+		tags = []string{}
+	}
+	return dto.ResponseHolidayDTO{
+		ID:            holiday.ID,
+		Title:         holiday.Title,
+		Description:   holiday.Description,
+		StartDate:     holiday.StartDate,
+		Duration:      holiday.Duration,
+		FreeSlots:     holiday.FreeSlots,
+		Price:         holiday.Price,
+		Included:      nonNil(holiday.Included),
+		Excluded:      nonNil(holiday.Excluded),
+		Accommodation: dto.AccommodationDTO(holiday.Accommodation),
+		Status:        holiday.Status,
+		PublishAt:     holiday.PublishAt,
+		UnpublishAt:   holiday.UnpublishAt,
+		Template:      templateID(holiday.TemplateID),
+		Version:       holiday.Version,
+		Tags:          tags,
+	}
+}
+
+func templateID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func (s *Service) DeleteHoliday(ctx context.Context, holidayID int64, version int64) error {
+	err := s.repo.Delete(ctx, holidayID, version)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+		slog.ErrorContext(ctx, "Failed to delete holiday", "holiday_id", holidayID, "error", err)
+	}
+
+	return err
+}
+
+func (s *Service) GetHolidays(ctx context.Context, queryParams url.Values) ([]dto.ResponseHolidayDTO, error) {
+	filter, err := parseHolidayFilter(queryParams)
+	if err != nil {
+		return nil, err
+	}
+	near, err := parseNearSearch(queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := s.repo.List(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query holidays", "error", err)
+		return nil, err
+	}
+	holidayIDs := make([]int64, len(holidays))
+	for i, holiday := range holidays {
+		holidayIDs[i] = holiday.ID
+	}
+	tags, err := s.repo.ListTags(ctx, holidayIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query holiday tags", "error", err)
+		return nil, err
+	}
+
+	locationIDs := make([]int64, len(holidays))
+	for i, holiday := range holidays {
+		locationIDs[i] = holiday.LocationID
+	}
+	locations, err := s.locationService.GetLocations(ctx, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var resultDTOs []dto.ResponseHolidayDTO
+	for _, holiday := range holidays {
+		locationDTO, ok := locations[holiday.LocationID]
+		if !ok {
+			return nil, fmt.Errorf("%w: ID %d", location.ErrNotFound, holiday.LocationID)
+		}
+
+		resultDTO := convertHolidayToResponseDTO(holiday, tags[holiday.ID])
+		resultDTO.Location = locationDTO
+
+		if near != nil {
+			distance, ok := near.distanceTo(locationDTO)
+			if !ok {
+				continue
+			}
+			resultDTO.DistanceKm = &distance
+		}
+
+		resultDTOs = append(resultDTOs, resultDTO)
+	}
+
+	if near != nil {
+		sort.SliceStable(resultDTOs, func(i, j int) bool {
+			return *resultDTOs[i].DistanceKm < *resultDTOs[j].DistanceKm
+		})
+	}
+
+	slog.DebugContext(ctx, "Retrieved holidays", "count", len(resultDTOs))
+	return resultDTOs, nil
+}
+
+func (s *Service) GetHoliday(ctx context.Context, holidayID int64) (dto.ResponseHolidayDTO, error) {
+	holiday, err := s.repo.Get(ctx, holidayID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get holiday", "holiday_id", holidayID, "error", err)
+		}
+		return dto.ResponseHolidayDTO{}, err
+	}
+
+	locationDTO, err := s.locationService.GetLocation(ctx, holiday.LocationID)
+	if err != nil {
+		return dto.ResponseHolidayDTO{}, err
+	}
+	tags, err := s.repo.ListTags(ctx, []int64{holidayID})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get holiday tags", "holiday_id", holidayID, "error", err)
+		return dto.ResponseHolidayDTO{}, err
+	}
+
+	responseDTO := convertHolidayToResponseDTO(holiday, tags[holidayID])
+	responseDTO.Location = locationDTO
+
+	return responseDTO, nil
+}
+
+func (s *Service) UpdateHoliday(ctx context.Context, updateDTO dto.UpdateHolidayDTO) error {
+	priceString := strconv.FormatFloat(updateDTO.Price, 'f', -1, 64)
+
+	stops, err := s.repo.GetItinerary(ctx, updateDTO.ID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get itinerary", "holiday_id", updateDTO.ID, "error", err)
+		}
+		return err
+	}
+	if err := validateItinerary(updateDTO.Duration, stops); err != nil {
+		return err
+	}
+
+	holiday := Holiday{
+		ID:            updateDTO.ID,
+		Title:         updateDTO.Title,
+		Description:   updateDTO.Description,
+		StartDate:     updateDTO.StartDate,
+		Duration:      updateDTO.Duration,
+		FreeSlots:     updateDTO.FreeSlots,
+		Price:         priceString,
+		LocationID:    updateDTO.Location,
+		Included:      updateDTO.Included,
+		Excluded:      updateDTO.Excluded,
+		Accommodation: Accommodation(updateDTO.Accommodation),
+		Status:        updateDTO.Status,
+		PublishAt:     updateDTO.PublishAt,
+		UnpublishAt:   updateDTO.UnpublishAt,
+		Version:       updateDTO.Version,
+	}
+	if err := NormalizeContent(&holiday); err != nil {
+		return err
+	}
+	if holiday.Status == "" {
+		current, err := s.repo.Get(ctx, updateDTO.ID)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				slog.ErrorContext(ctx, "Failed to get holiday", "holiday_id", updateDTO.ID, "error", err)
+			}
+			return err
+		}
+		holiday.Status, holiday.PublishAt, holiday.UnpublishAt = current.Status, current.PublishAt, current.UnpublishAt
+	}
+	if err := normalizePublication(&holiday); err != nil {
+		return err
+	}
+
+	err = s.repo.Update(ctx, holiday)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+		slog.ErrorContext(ctx, "Failed to update holiday", "holiday_id", updateDTO.ID, "error", err)
+	}
+
+	return err
+}
+
+// PatchHoliday applies a JSON merge patch to the holiday and saves the
+// result. The patch is applied to the UpdateHolidayDTO representation, so
+// it uses the same field names and types as PUT. A non-zero version makes
+// the save conditional, as for UpdateHoliday.
+func (s *Service) PatchHoliday(ctx context.Context, holidayID int64, patch []byte, version int64) (dto.ResponseHolidayDTO, error) {
+	current, err := s.repo.Get(ctx, holidayID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get holiday", "holiday_id", holidayID, "error", err)
+		}
+		return dto.ResponseHolidayDTO{}, err
+	}
+
+	price, err := strconv.ParseFloat(current.Price, 64)
+	if err != nil {
+		return dto.ResponseHolidayDTO{}, fmt.Errorf("stored price %q of holiday %d: %w", current.Price, holidayID, err)
+	}
+
+	var updateDTO dto.UpdateHolidayDTO
+	err = mergepatch.Apply(dto.UpdateHolidayDTO{
+		ID:            current.ID,
+		Title:         current.Title,
+		Description:   current.Description,
+		StartDate:     current.StartDate,
+		Duration:      current.Duration,
+		FreeSlots:     current.FreeSlots,
+		Price:         price,
+		Location:      current.LocationID,
+		Included:      current.Included,
+		Excluded:      current.Excluded,
+		Accommodation: dto.AccommodationDTO(current.Accommodation),
+		Status:        current.Status,
+		PublishAt:     current.PublishAt,
+		UnpublishAt:   current.UnpublishAt,
+	}, patch, &updateDTO)
+	if err != nil {
+		return dto.ResponseHolidayDTO{}, err
+	}
+	updateDTO.ID = holidayID
+	updateDTO.Version = version
+
+	if err := s.UpdateHoliday(ctx, updateDTO); err != nil {
+		return dto.ResponseHolidayDTO{}, err
+	}
+
+	return s.GetHoliday(ctx, holidayID)
+}
+
+func (s *Service) GetHolidayTags(ctx context.Context, holidayID int64) (dto.HolidayTagsDTO, error) {
+	holiday, err := s.GetHoliday(ctx, holidayID)
+	if err != nil {
+		return dto.HolidayTagsDTO{}, err
+	}
+
+	return dto.HolidayTagsDTO{Tags: holiday.Tags}, nil
+}
+
+// ReplaceHolidayTags sets the holiday's tags by slug. A non-zero version
+// makes the write conditional, as for UpdateHoliday.
+func (s *Service) ReplaceHolidayTags(ctx context.Context, holidayID int64, tagsDTO dto.HolidayTagsDTO, version int64) (dto.HolidayTagsDTO, error) {
+	slugs := make([]string, len(tagsDTO.Tags))
+	for i, slug := range tagsDTO.Tags {
+		slugs[i] = strings.ToLower(strings.TrimSpace(slug))
+	}
+
+	err := s.repo.ReplaceTags(ctx, holidayID, slugs, version)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) && !errors.Is(err, ErrUnknownTag) {
+			slog.ErrorContext(ctx, "Failed to replace holiday tags", "holiday_id", holidayID, "error", err)
+		}
+		return dto.HolidayTagsDTO{}, err
+	}
+
+	return s.GetHolidayTags(ctx, holidayID)
+}
+
+func (s *Service) GetItinerary(ctx context.Context, holidayID int64) (dto.ResponseItineraryDTO, error) {
+	holiday, err := s.repo.Get(ctx, holidayID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get holiday", "holiday_id", holidayID, "error", err)
+		}
+		return dto.ResponseItineraryDTO{}, err
+	}
+	stops, err := s.repo.GetItinerary(ctx, holidayID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get itinerary", "holiday_id", holidayID, "error", err)
+		}
+		return dto.ResponseItineraryDTO{}, err
+	}
+
+	locationIDs := make([]int64, len(stops))
+	for i, stop := range stops {
+		locationIDs[i] = stop.LocationID
+	}
+	locations, err := s.locationService.GetLocations(ctx, locationIDs)
+	if err != nil {
+		return dto.ResponseItineraryDTO{}, err
+	}
+
+	itineraryDTO := dto.ResponseItineraryDTO{
+		HolidayID: holiday.ID,
+		Duration:  holiday.Duration,
+		Stops:     []dto.ResponseStopDTO{},
+		Version:   holiday.Version,
+	}
+	for position, stop := range stops {
+		locationDTO, ok := locations[stop.LocationID]
+		if !ok {
+			return dto.ResponseItineraryDTO{}, fmt.Errorf("%w: ID %d", location.ErrNotFound, stop.LocationID)
+		}
+		itineraryDTO.Stops = append(itineraryDTO.Stops, dto.ResponseStopDTO{
+			Position:  int32(position),
+			DayOffset: stop.DayOffset,
+			Nights:    stop.Nights,
+			Location:  locationDTO,
+		})
+	}
+
+	return itineraryDTO, nil
+}
+
+// ReplaceItinerary replaces the holiday's stops after checking that they
+// fit within its duration. A non-zero version makes the write conditional,
+// as for UpdateHoliday.
+func (s *Service) ReplaceItinerary(ctx context.Context, holidayID int64, itineraryDTO dto.UpdateItineraryDTO, version int64) (dto.ResponseItineraryDTO, error) {
+	holiday, err := s.repo.Get(ctx, holidayID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get holiday", "holiday_id", holidayID, "error", err)
+		}
+		return dto.ResponseItineraryDTO{}, err
+	}
+
+	var stops []Stop
+	for _, stopDTO := range itineraryDTO.Stops {
+		stops = append(stops, Stop{
+			LocationID: stopDTO.Location,
+			DayOffset:  stopDTO.DayOffset,
+			Nights:     stopDTO.Nights,
+		})
+	}
+	if err := validateItinerary(holiday.Duration, stops); err != nil {
+		return dto.ResponseItineraryDTO{}, err
+	}
+
+	err = s.repo.ReplaceItinerary(ctx, holidayID, stops, version)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) && !errors.Is(err, ErrUnknownLocation) {
+			slog.ErrorContext(ctx, "Failed to replace itinerary", "holiday_id", holidayID, "error", err)
+		}
+		return dto.ResponseItineraryDTO{}, err
+	}
+
+	return s.GetItinerary(ctx, holidayID)
+}
+
+// validateItinerary checks that every stop lasts at least one night, starts
+// no earlier than the previous stop ends and that the last stop ends within
+// duration days.
+func validateItinerary(duration int32, stops []Stop) error {
+	var end int32
+	for i, stop := range stops {
+		if stop.Nights < 1 {
+			return fmt.Errorf("%w: stop %d must last at least one night", ErrInvalidItinerary, i)
+		}
+		if stop.DayOffset < end {
+			if i == 0 {
+				return fmt.Errorf("%w: stop %d starts before the holiday", ErrInvalidItinerary, i)
+			}
+			return fmt.Errorf("%w: stop %d starts on day %d, before stop %d ends on day %d", ErrInvalidItinerary, i, stop.DayOffset, i-1, end)
+		}
+		end = stop.DayOffset + stop.Nights
+		if end > duration {
+			return fmt.Errorf("%w: stop %d ends on day %d, after the %d-day holiday", ErrInvalidItinerary, i, end, duration)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) GetHolidayDTO(ctx context.Context, holidayID int64) (Holiday, error) {
+	holiday, err := s.repo.Get(ctx, holidayID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Holiday{}, fmt.Errorf("%w: ID %d", ErrNotFound, holidayID)
+		}
+		slog.ErrorContext(ctx, "Failed to get holiday", "holiday_id", holidayID, "error", err)
+		return Holiday{}, err
+	}
+
+	return holiday, nil
+}
+
+// GetHolidayDTOs loads several holidays at once, leaving out those that do
+// not exist.
+func (s *Service) GetHolidayDTOs(ctx context.Context, holidayIDs []int64) (map[int64]Holiday, error) {
+	holidays, err := s.repo.GetMany(ctx, holidayIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get holidays", "error", err)
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+// GetFreeSlots returns the free slots of the published holidays that have
+// not started yet, the ones still on sale, by holiday ID. Leaving out the
+// rest keeps the number of holidays reported from growing with every
+// holiday and template departure ever created.
+func (s *Service) GetFreeSlots(ctx context.Context) (map[int64]int32, error) {
+	holidays, err := s.repo.List(ctx, HolidayFilter{
+		StartFrom: time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly),
+		Statuses:  []string{Published},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query free slots", "error", err)
+		return nil, err
+	}
+
+	slots := make(map[int64]int32, len(holidays))
+	for _, holiday := range holidays {
+		slots[holiday.ID] = holiday.FreeSlots
+	}
+
+	return slots, nil
+}
+
+func parseHolidayFilter(queryParams url.Values) (HolidayFilter, error) {
+	var filter HolidayFilter
+
+	if startDate := queryParams.Get("startDate"); startDate != "" {
+		filter.StartDate = startDate
+	}
+	if duration := queryParams.Get("duration"); duration != "" {
+		value, err := strconv.ParseInt(duration, 10, 32)
+		if err != nil {
+			return HolidayFilter{}, fmt.Errorf("%w: duration %q is not a number", ErrInvalidFilter, duration)
+		}
+		d := int32(value)
+		filter.Duration = &d
+	}
+	if name := queryParams.Get("country"); name != "" {
+		country, ok := iso3166.Lookup(name)
+		if !ok {
+			return HolidayFilter{}, fmt.Errorf("%w: unknown country %q", ErrInvalidFilter, name)
+		}
+		filter.CountryCode = country.Alpha2
+	}
+	if value := strings.TrimSpace(queryParams.Get("location")); value != "" {
+		filter.Location = parseLocationMatch(value)
+	}
+	if value := queryParams.Get("tags"); value != "" {
+		for _, slug := range strings.Split(value, ",") {
+			slug = strings.ToLower(strings.TrimSpace(slug))
+			if !tag.ValidSlug(slug) {
+				return HolidayFilter{}, fmt.Errorf("%w: %q is not a tag slug", ErrInvalidFilter, slug)
+			}
+			filter.Tags = append(filter.Tags, slug)
+		}
+	}
+	if value := queryParams.Get("template"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return HolidayFilter{}, fmt.Errorf("%w: template %q is not a template ID", ErrInvalidFilter, value)
+		}
+		filter.TemplateID = id
+	}
+	statuses, err := parseStatuses(queryParams.Get("status"))
+	if err != nil {
+		return HolidayFilter{}, err
+	}
+	filter.Statuses = statuses
+
+	return filter, nil
+}
+
+// parseLocationMatch reads the location search: a location ID, or a city or
+// country name.
+func parseLocationMatch(value string) *LocationMatch {
+	if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return &LocationMatch{LocationID: id}
+	}
+
+	match := &LocationMatch{City: value}
+	if country, ok := iso3166.Lookup(value); ok {
+		match.CountryCode = country.Alpha2
+	}
+	return match
+}
+
+// nearSearch narrows a holiday listing to locations within RadiusKm of
+// Point. A zero radius keeps every location that has coordinates.
+type nearSearch struct {
+	Point    geo.Point
+	RadiusKm float64
+}
+
+func parseNearSearch(queryParams url.Values) (*nearSearch, error) {
+	near, radius := queryParams.Get("near"), queryParams.Get("radiusKm")
+	if near == "" {
+		if radius != "" {
+			return nil, fmt.Errorf("%w: radiusKm requires near", ErrInvalidFilter)
+		}
+		return nil, nil
+	}
+
+	point, err := geo.ParsePoint(near)
+	if err != nil {
+		return nil, fmt.Errorf("%w: near: %v", ErrInvalidFilter, err)
+	}
+
+	search := &nearSearch{Point: point}
+	if radius != "" {
+		search.RadiusKm, err = strconv.ParseFloat(radius, 64)
+		if err != nil || math.IsNaN(search.RadiusKm) || math.IsInf(search.RadiusKm, 0) || search.RadiusKm <= 0 {
+			return nil, fmt.Errorf("%w: radiusKm %q is not a positive number", ErrInvalidFilter, radius)
+		}
+	}
+
+	return search, nil
+}
+
+// distanceTo returns the distance to the location rounded to metres, and
+// false when the location has no coordinates or lies outside the radius.
+func (n *nearSearch) distanceTo(location locationdto.ResponseLocationDTO) (float64, bool) {
+	if location.Latitude == nil || location.Longitude == nil {
+		return 0, false
+	}
+
+	distance := geo.DistanceKm(n.Point, geo.Point{Lat: *location.Latitude, Lng: *location.Longitude})
+	if n.RadiusKm > 0 && distance > n.RadiusKm {
+		return 0, false
+	}
+	return math.Round(distance*1000) / 1000, true
+}
@@ -0,0 +1,16 @@
+package dto
+
+type ResponseImageDTO struct {
+	ID           int64  `json:"id"`
+	Position     int32  `json:"position"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	ContentType  string `json:"contentType"`
+	Width        int32  `json:"width"`
+	Height       int32  `json:"height"`
+	Size         int64  `json:"size"`
+}
+
+type ReorderImagesDTO struct {
+	ImageIDs []int64 `json:"imageIds"`
+}
@@ -0,0 +1,29 @@
+package image
+
+// OwnerKind names the kind of resource an image belongs to.
+type OwnerKind string
+
+const (
+	LocationOwner OwnerKind = "location"
+	HolidayOwner  OwnerKind = "holiday"
+)
+
+type Owner struct {
+	Kind OwnerKind
+	ID   int64
+}
+
+// Image is an uploaded picture of a location or holiday. Key and
+// ThumbnailKey address the original file and its thumbnail in the blob
+// store. Position orders the images of one owner, starting at 0.
+type Image struct {
+	ID           int64
+	Owner        Owner
+	Position     int32
+	Key          string
+	ThumbnailKey string
+	ContentType  string
+	Width        int32
+	Height       int32
+	Size         int64
+}
@@ -0,0 +1,218 @@
+package image
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nikolaypleshkov/uni-api/api/image/dto"
+	"github.com/nikolaypleshkov/uni-api/blob"
+	"github.com/nikolaypleshkov/uni-api/imaging"
+)
+
+// UploadField is the multipart form field that carries the image files.
+const UploadField = "images"
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(service *Service) *Controller {
+	return &Controller{service: service}
+}
+
+// AddImages takes one or more files in the images field of a
+// multipart/form-data request.
+func (c *Controller) AddImages(w http.ResponseWriter, r *http.Request) {
+	owner, err := ownerFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The limit leaves room for the multipart framing around the files.
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadFiles*c.service.MaxBytes()+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Content-Type must be multipart/form-data", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var images []dto.ResponseImageDTO
+	uploads, err := readUploads(reader, c.service.MaxBytes())
+	if err == nil {
+		images, err = c.service.AddImages(r.Context(), owner, uploads)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case err == nil:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(images)
+	case errors.Is(err, ErrOwnerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrTooLarge), errors.As(err, &maxBytesErr):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, imaging.ErrUnsupportedType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, imaging.ErrInvalidImage), errors.Is(err, ErrInvalidUpload):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// readUploads reads the files in the images field. Other fields are
+// ignored. A file larger than maxBytes is read only as far as needed to
+// tell, and AddImages rejects it.
+func readUploads(reader *multipart.Reader, maxBytes int64) ([]Upload, error) {
+	var uploads []Upload
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return uploads, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidUpload, err)
+		}
+		if part.FormName() != UploadField {
+			continue
+		}
+		if len(uploads) == MaxUploadFiles {
+			return nil, fmt.Errorf("%w: at most %d images can be uploaded at once", ErrInvalidUpload, MaxUploadFiles)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, maxBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidUpload, err)
+		}
+		uploads = append(uploads, Upload{Name: cmp.Or(part.FileName(), UploadField), Data: data})
+	}
+}
+
+func (c *Controller) GetImages(w http.ResponseWriter, r *http.Request) {
+	owner, err := ownerFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	images, err := c.service.GetImages(r.Context(), owner)
+	if errors.Is(err, ErrOwnerNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(images)
+}
+
+func (c *Controller) ReorderImages(w http.ResponseWriter, r *http.Request) {
+	owner, err := ownerFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var reorderDTO dto.ReorderImagesDTO
+	if err := json.NewDecoder(r.Body).Decode(&reorderDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	images, err := c.service.ReorderImages(r.Context(), owner, reorderDTO.ImageIDs)
+	if errors.Is(err, ErrOwnerNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrInvalidOrder) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(images)
+}
+
+func (c *Controller) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	owner, err := ownerFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	imageID, err := strconv.ParseInt(mux.Vars(r)["imageId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = c.service.DeleteImage(r.Context(), owner, imageID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFile serves an image or thumbnail file. File names are random and
+// never reused, so clients may cache the files for good.
+func (c *Controller) GetFile(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	file, err := c.service.OpenFile(r.Context(), key)
+	if errors.Is(err, blob.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, key, time.Time{}, file)
+}
+
+// ownerFromRequest reads the owner from the locationId or holidayId route
+// variable.
+func ownerFromRequest(r *http.Request) (Owner, error) {
+	vars := mux.Vars(r)
+	for name, kind := range map[string]OwnerKind{"locationId": LocationOwner, "holidayId": HolidayOwner} {
+		value, ok := vars[name]
+		if !ok {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Owner{}, fmt.Errorf("invalid %s %q", kind, value)
+		}
+		return Owner{Kind: kind, ID: id}, nil
+	}
+	return Owner{}, errors.New("route has no image owner")
+}
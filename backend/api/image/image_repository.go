@@ -0,0 +1,47 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrNotFound      = errors.New("image not found")
+	ErrOwnerNotFound = errors.New("image owner not found")
+	ErrInvalidOrder  = errors.New("invalid image order")
+)
+
+// ImageRepository keeps the images of locations and holidays in order.
+// Deleting an owner leaves its images behind; ListOrphans finds them so that
+// their files can be removed too.
+type ImageRepository interface {
+	// Create appends the image after the owner's last one. It fails with
+	// ErrOwnerNotFound when the owner does not exist.
+	Create(ctx context.Context, image Image) (Image, error)
+	// List returns the owner's images in order, or ErrOwnerNotFound.
+	List(ctx context.Context, owner Owner) ([]Image, error)
+	// Delete removes one of the owner's images and moves the ones after it
+	// up by one position.
+	Delete(ctx context.Context, owner Owner, imageID int64) (Image, error)
+	// Reorder gives the owner's images the positions of their IDs in
+	// imageIDs, which must list each of them exactly once.
+	Reorder(ctx context.Context, owner Owner, imageIDs []int64) ([]Image, error)
+	// ListOrphans returns the images whose owner no longer exists.
+	ListOrphans(ctx context.Context) ([]Image, error)
+}
+
+// CheckOrder fails with ErrInvalidOrder unless imageIDs lists each of
+// images exactly once.
+func CheckOrder(images []Image, imageIDs []int64) error {
+	if len(imageIDs) != len(images) {
+		return fmt.Errorf("%w: got %d image IDs for %d images", ErrInvalidOrder, len(imageIDs), len(images))
+	}
+	for _, image := range images {
+		if !slices.Contains(imageIDs, image.ID) {
+			return fmt.Errorf("%w: image %d is missing", ErrInvalidOrder, image.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/image/dto"
+	"github.com/nikolaypleshkov/uni-api/blob"
+	"github.com/nikolaypleshkov/uni-api/imaging"
+)
+
+// URLPrefix is the path the image files are served under.
+const URLPrefix = "/images/"
+
+// MaxUploadFiles bounds the number of images in one upload request.
+const MaxUploadFiles = 10
+
+var (
+	ErrTooLarge      = errors.New("image is too large")
+	ErrInvalidUpload = errors.New("invalid upload")
+)
+
+type Options struct {
+	// MaxBytes limits the size of one uploaded file.
+	MaxBytes int64
+	// ThumbnailSize is the longest side of generated thumbnails in pixels.
+	ThumbnailSize int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxBytes:      10 << 20,
+		ThumbnailSize: 320,
+	}
+}
+
+// Upload is one file of an upload request.
+type Upload struct {
+	Name string
+	Data []byte
+}
+
+type Service struct {
+	repo  ImageRepository
+	blobs blob.BlobStore
+	opts  Options
+}
+
+// NewService returns a service that records images in repo and keeps their
+// files in blobs.
+func NewService(repo ImageRepository, blobs blob.BlobStore, opts Options) *Service {
+	return &Service{repo: repo, blobs: blobs, opts: opts}
+}
+
+// MaxBytes returns the size limit of one uploaded file.
+func (s *Service) MaxBytes() int64 {
+	return s.opts.MaxBytes
+}
+
+func (s *Service) convertImageToDTO(image Image) dto.ResponseImageDTO {
+	return dto.ResponseImageDTO{
+		ID:           image.ID,
+		Position:     image.Position,
+		URL:          URLPrefix + image.Key,
+		ThumbnailURL: URLPrefix + image.ThumbnailKey,
+		ContentType:  image.ContentType,
+		Width:        image.Width,
+		Height:       image.Height,
+		Size:         image.Size,
+	}
+}
+
+func (s *Service) convertImagesToDTOs(images []Image) []dto.ResponseImageDTO {
+	imageDTOs := []dto.ResponseImageDTO{}
+	for _, image := range images {
+		imageDTOs = append(imageDTOs, s.convertImageToDTO(image))
+	}
+	return imageDTOs
+}
+
+// AddImages appends the uploaded images to the owner's images. Every upload
+// is checked before any is stored, so a request with one bad file adds
+// nothing.
+func (s *Service) AddImages(ctx context.Context, owner Owner, uploads []Upload) ([]dto.ResponseImageDTO, error) {
+	if len(uploads) == 0 {
+		return nil, fmt.Errorf("%w: no images were uploaded", ErrInvalidUpload)
+	}
+	if len(uploads) > MaxUploadFiles {
+		return nil, fmt.Errorf("%w: at most %d images can be uploaded at once", ErrInvalidUpload, MaxUploadFiles)
+	}
+	if _, err := s.GetImages(ctx, owner); err != nil {
+		return nil, err
+	}
+
+	infos := make([]imaging.Info, len(uploads))
+	for i, upload := range uploads {
+		if int64(len(upload.Data)) > s.opts.MaxBytes {
+			return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrTooLarge, upload.Name, s.opts.MaxBytes)
+		}
+		info, err := imaging.Inspect(upload.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", upload.Name, err)
+		}
+		infos[i] = info
+	}
+
+	var added []Image
+	for i, upload := range uploads {
+		image, err := s.store(ctx, owner, upload, infos[i])
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, image)
+	}
+
+	return s.convertImagesToDTOs(added), nil
+}
+
+// store writes the file and thumbnail of one checked upload and records
+// the image. The files are removed again when the image cannot be recorded.
+func (s *Service) store(ctx context.Context, owner Owner, upload Upload, info imaging.Info) (Image, error) {
+	thumbnail, thumbnailType, err := imaging.Thumbnail(upload.Data, s.opts.ThumbnailSize)
+	if err != nil {
+		return Image{}, fmt.Errorf("%s: %w", upload.Name, err)
+	}
+
+	name := newName()
+	image := Image{
+		Owner:        owner,
+		Key:          name + imaging.Extension(info.ContentType),
+		ThumbnailKey: name + "_thumb" + imaging.Extension(thumbnailType),
+		ContentType:  info.ContentType,
+		Width:        info.Width,
+		Height:       info.Height,
+		Size:         int64(len(upload.Data)),
+	}
+
+	if err := s.blobs.Put(ctx, image.Key, bytes.NewReader(upload.Data)); err != nil {
+		slog.ErrorContext(ctx, "Failed to store image", "key", image.Key, "error", err)
+		return Image{}, err
+	}
+	if err := s.blobs.Put(ctx, image.ThumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+		slog.ErrorContext(ctx, "Failed to store thumbnail", "key", image.ThumbnailKey, "error", err)
+		s.deleteFiles(ctx, image)
+		return Image{}, err
+	}
+
+	created, err := s.repo.Create(ctx, image)
+	if err != nil {
+		if !errors.Is(err, ErrOwnerNotFound) {
+			slog.ErrorContext(ctx, "Failed to create image", "owner", owner.Kind, "owner_id", owner.ID, "error", err)
+		}
+		s.deleteFiles(ctx, image)
+		return Image{}, err
+	}
+
+	return created, nil
+}
+
+func (s *Service) GetImages(ctx context.Context, owner Owner) ([]dto.ResponseImageDTO, error) {
+	images, err := s.repo.List(ctx, owner)
+	if err != nil {
+		if !errors.Is(err, ErrOwnerNotFound) {
+			slog.ErrorContext(ctx, "Failed to query images", "owner", owner.Kind, "owner_id", owner.ID, "error", err)
+		}
+		return nil, err
+	}
+
+	return s.convertImagesToDTOs(images), nil
+}
+
+func (s *Service) DeleteImage(ctx context.Context, owner Owner, imageID int64) error {
+	image, err := s.repo.Delete(ctx, owner, imageID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to delete image", "image_id", imageID, "error", err)
+		}
+		return err
+	}
+
+	s.deleteFiles(ctx, image)
+	return nil
+}
+
+func (s *Service) ReorderImages(ctx context.Context, owner Owner, imageIDs []int64) ([]dto.ResponseImageDTO, error) {
+	images, err := s.repo.Reorder(ctx, owner, imageIDs)
+	if err != nil {
+		if !errors.Is(err, ErrOwnerNotFound) && !errors.Is(err, ErrInvalidOrder) {
+			slog.ErrorContext(ctx, "Failed to reorder images", "owner", owner.Kind, "owner_id", owner.ID, "error", err)
+		}
+		return nil, err
+	}
+
+	return s.convertImagesToDTOs(images), nil
+}
+
+// OpenFile returns an image or thumbnail file by the key in its URL.
+func (s *Service) OpenFile(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	file, err := s.blobs.Open(ctx, key)
+	if err != nil && !errors.Is(err, blob.ErrNotFound) {
+		slog.ErrorContext(ctx, "Failed to open image file", "key", key, "error", err)
+	}
+	return file, err
+}
+
+// PurgeOrphans deletes the images left behind by deleted locations and
+// holidays, files included, and returns how many it deleted.
+func (s *Service) PurgeOrphans(ctx context.Context) (int, error) {
+	orphans, err := s.repo.ListOrphans(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, image := range orphans {
+		if _, err := s.repo.Delete(ctx, image.Owner, image.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return purged, err
+		}
+		s.deleteFiles(ctx, image)
+		purged++
+	}
+
+	return purged, nil
+}
+
+// deleteFiles removes the files of an image. A file that cannot be removed
+// is only logged: nothing refers to it any more.
+func (s *Service) deleteFiles(ctx context.Context, image Image) {
+	for _, key := range []string{image.Key, image.ThumbnailKey} {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "Failed to delete image file", "key", key, "error", err)
+		}
+	}
+}
+
+// PurgeOrphansEvery runs PurgeOrphans every interval until ctx is done.
+func PurgeOrphansEvery(ctx context.Context, service *Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := service.PurgeOrphans(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to purge orphaned images", "error", err)
+				continue
+			}
+			if purged > 0 {
+				slog.DebugContext(ctx, "Purged orphaned images", "count", purged)
+			}
+		}
+	}
+}
+
+// newName returns a random file name, so that image URLs cannot be guessed
+// and every upload gets files of its own.
+func newName() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
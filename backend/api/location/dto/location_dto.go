@@ -1,27 +1,48 @@
-package dto
-
-type CreateLocationDTO struct {
-	Number   string `json:"number"`
-	Country  string `json:"country"`
-	City     string `json:"city"`
-	Street   string `json:"street"`
-	ImageURL string `json:"imageUrl"`
-}
-
-type UpdateLocationDTO struct {
-	ID       int64  `json:"id"`
-	Number   string `json:"number"`
-	Country  string `json:"country"`
-	City     string `json:"city"`
-	Street   string `json:"street"`
-	ImageURL string `json:"imageUrl"`
-}
-
-type ResponseLocationDTO struct {
-	ID       int64  `json:"id"`
-	Number   string `json:"number"`
-	Country  string `json:"country"`
-	City     string `json:"city"`
-	Street   string `json:"street"`
-	ImageURL string `json:"imageUrl"`
-}
+package dto
+
+type CreateLocationDTO struct {
+	Number      string   `json:"number"`
+	Country     string   `json:"country"`
+	CountryCode string   `json:"countryCode"`
+	City        string   `json:"city"`
+	Street      string   `json:"street"`
+	ImageURL    string   `json:"imageUrl"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+}
+
+type UpdateLocationDTO struct {
+	ID          int64    `json:"id"`
+	Number      string   `json:"number"`
+	Country     string   `json:"country"`
+	CountryCode string   `json:"countryCode"`
+	City        string   `json:"city"`
+	Street      string   `json:"street"`
+	ImageURL    string   `json:"imageUrl"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	Version     int64    `json:"-"`
+}
+
+type ResponseLocationDTO struct {
+	ID          int64    `json:"id"`
+	Number      string   `json:"number"`
+	Country     string   `json:"country"`
+	CountryCode string   `json:"countryCode"`
+	City        string   `json:"city"`
+	Street      string   `json:"street"`
+	ImageURL    string   `json:"imageUrl"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	Version     int64    `json:"version"`
+}
+
+// LocationTranslationsDTO holds a location's translations by language tag.
+type LocationTranslationsDTO struct {
+	Translations map[string]LocationTranslationDTO `json:"translations"`
+}
+
+type LocationTranslationDTO struct {
+	City   string `json:"city"`
+	Street string `json:"street"`
+}
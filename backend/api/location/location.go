@@ -0,0 +1,28 @@
+package location
+
+// Location is stored in the default language; Translations override its
+// city and street in others. Latitude and Longitude are nil when the
+// location has not been placed on the map.
+type Location struct {
+	ID      int64  `json:"id"`
+	Number  string `json:"number"`
+	Country string `json:"country"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of Country, or empty when
+	// no country is set.
+	CountryCode string   `json:"countryCode"`
+	City        string   `json:"city"`
+	Street      string   `json:"street"`
+	ImageURL    string   `json:"imageUrl"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	Version     int64    `json:"version"`
+}
+
+// Translation holds a location's names in one language other than the
+// default, e.g. "Атина" for Athens. Empty fields fall back to the default
+// language.
+type Translation struct {
+	Language string
+	City     string
+	Street   string
+}
@@ -1,100 +1,318 @@
-package location
-
-import (
-	"encoding/json"
-	"net/http"
-	"strconv"
-
-	"github.com/gorilla/mux"
-	"github.com/nikolaypleshkov/uni-api/api/location/dto"
-)
-
-type LocationController struct {
-	service LocationService
-}
-
-func NewLocationController(service LocationService) *LocationController {
-	return &LocationController{service}
-}
-
-func (c *LocationController) CreateLocation(w http.ResponseWriter, r *http.Request) {
-	var createLocationDTO dto.CreateLocationDTO
-	err := json.NewDecoder(r.Body).Decode(&createLocationDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	createdLocation, err := c.service.CreateLocation(createLocationDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(createdLocation)
-}
-
-func (c *LocationController) DeleteLocation(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	locationID, err := strconv.ParseInt(params["locationId"], 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	err = c.service.DeleteLocation(locationID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-}
-
-func (c *LocationController) GetAllLocations(w http.ResponseWriter, r *http.Request) {
-	locations, err := c.service.GetAllLocations()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(locations)
-}
-
-func (c *LocationController) GetLocation(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	locationID, err := strconv.ParseInt(params["locationId"], 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	location, err := c.service.GetLocation(locationID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(location)
-}
-
-func (c *LocationController) UpdateLocation(w http.ResponseWriter, r *http.Request) {
-	var updateLocationDTO dto.UpdateLocationDTO
-	err := json.NewDecoder(r.Body).Decode(&updateLocationDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	updatedLocation, err := c.service.UpdateLocation(updateLocationDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedLocation)
-}
+package location
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nikolaypleshkov/uni-api/api/location/dto"
+	"github.com/nikolaypleshkov/uni-api/etag"
+	"github.com/nikolaypleshkov/uni-api/locale"
+	"github.com/nikolaypleshkov/uni-api/mergepatch"
+)
+
+type LocationController struct {
+	service LocationService
+}
+
+func NewLocationController(service LocationService) *LocationController {
+	return &LocationController{service}
+}
+
+func (c *LocationController) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	var createLocationDTO dto.CreateLocationDTO
+	err := json.NewDecoder(r.Body).Decode(&createLocationDTO)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdLocation, err := c.service.CreateLocation(r.Context(), createLocationDTO)
+	if errors.Is(err, ErrInvalidCoordinates) || errors.Is(err, ErrUnknownCountry) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(createdLocation)
+}
+
+func (c *LocationController) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID, err := strconv.ParseInt(params["locationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentLocation(w, r, locationID)
+	if !ok || !etag.CheckIfMatch(w, r, locationETag(current)) {
+		return
+	}
+
+	err = c.service.DeleteLocation(r.Context(), locationID, current.Version)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *LocationController) GetAllLocations(w http.ResponseWriter, r *http.Request) {
+	ranges := locale.Preferences(w, r)
+
+	locations, err := c.service.GetAllLocations(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	languages, err := c.service.Localize(r.Context(), locations, ranges)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	locale.SetContentLanguage(w, languages)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(locations)
+}
+
+func (c *LocationController) GetLocation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID, err := strconv.ParseInt(params["locationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ranges := locale.Preferences(w, r)
+
+	location, err := c.service.GetLocation(r.Context(), locationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	locations := []dto.ResponseLocationDTO{location}
+	languages, err := c.service.Localize(r.Context(), locations, ranges)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	location = locations[0]
+
+	locale.SetContentLanguage(w, languages)
+	if etag.NotModified(w, r, etag.Localized(locationETag(location), languages)) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(location)
+}
+
+func (c *LocationController) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID, err := strconv.ParseInt(params["locationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updateLocationDTO dto.UpdateLocationDTO
+	err = json.NewDecoder(r.Body).Decode(&updateLocationDTO)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if updateLocationDTO.ID != 0 && updateLocationDTO.ID != locationID {
+		http.Error(w, "Location ID in body does not match the URL", http.StatusBadRequest)
+		return
+	}
+	updateLocationDTO.ID = locationID
+
+	current, ok := c.currentLocation(w, r, locationID)
+	if !ok || !etag.CheckIfMatch(w, r, locationETag(current)) {
+		return
+	}
+	updateLocationDTO.Version = current.Version
+
+	updatedLocation, err := c.service.UpdateLocation(r.Context(), updateLocationDTO)
+	if errors.Is(err, ErrInvalidCoordinates) || errors.Is(err, ErrUnknownCountry) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", locationETag(updatedLocation))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedLocation)
+}
+
+func (c *LocationController) PatchLocation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID, err := strconv.ParseInt(params["locationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !mergepatch.AcceptsContentType(r.Header.Get("Content-Type")) {
+		http.Error(w, "Content-Type must be "+mergepatch.MediaType, http.StatusUnsupportedMediaType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentLocation(w, r, locationID)
+	if !ok || !etag.CheckIfMatch(w, r, locationETag(current)) {
+		return
+	}
+
+	patchedLocation, err := c.service.PatchLocation(r.Context(), locationID, patch, current.Version)
+	if errors.Is(err, mergepatch.ErrInvalidPatch) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrInvalidCoordinates) || errors.Is(err, ErrUnknownCountry) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", locationETag(patchedLocation))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(patchedLocation)
+}
+
+func (c *LocationController) GetTranslations(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID, err := strconv.ParseInt(params["locationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentLocation(w, r, locationID)
+	if !ok || etag.NotModified(w, r, locationETag(current)) {
+		return
+	}
+
+	translations, err := c.service.GetTranslations(r.Context(), locationID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(translations)
+}
+
+// ReplaceTranslations replaces the location's translations. They are part
+// of the location and share its ETag.
+func (c *LocationController) ReplaceTranslations(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	locationID, err := strconv.ParseInt(params["locationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var translationsDTO dto.LocationTranslationsDTO
+	if err := json.NewDecoder(r.Body).Decode(&translationsDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentLocation(w, r, locationID)
+	if !ok || !etag.CheckIfMatch(w, r, locationETag(current)) {
+		return
+	}
+
+	translations, err := c.service.ReplaceTranslations(r.Context(), locationID, translationsDTO, current.Version)
+	if errors.Is(err, ErrInvalidTranslation) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if updated, err := c.service.GetLocation(r.Context(), locationID); err == nil {
+		w.Header().Set("ETag", locationETag(updated))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(translations)
+}
+
+// currentLocation loads the location a conditional request refers to. It
+// writes the error response itself and returns false when that fails.
+func (c *LocationController) currentLocation(w http.ResponseWriter, r *http.Request, locationID int64) (dto.ResponseLocationDTO, bool) {
+	location, err := c.service.GetLocation(r.Context(), locationID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return dto.ResponseLocationDTO{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return dto.ResponseLocationDTO{}, false
+	}
+
+	return location, true
+}
+
+func locationETag(location dto.ResponseLocationDTO) string {
+	return etag.Format(location.Version)
+}
@@ -0,0 +1,35 @@
+package location
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNotFound        = errors.New("location not found")
+	ErrVersionMismatch = errors.New("location was modified by another request")
+
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+	ErrUnknownCountry     = errors.New("unknown country")
+	ErrInvalidTranslation = errors.New("invalid translation")
+)
+
+// A non-zero Version on Update, or version on Delete, makes the write
+// conditional: it fails with ErrVersionMismatch unless the stored row still
+// has that version. Every successful write increments the version.
+type LocationRepository interface {
+	Create(ctx context.Context, location Location) (Location, error)
+	Delete(ctx context.Context, locationID int64, version int64) error
+	List(ctx context.Context) ([]Location, error)
+	Get(ctx context.Context, locationID int64) (Location, error)
+	// GetMany returns those of the locations that exist, by ID.
+	GetMany(ctx context.Context, locationIDs []int64) (map[int64]Location, error)
+	Update(ctx context.Context, location Location) (Location, error)
+	// ListTranslations returns the translations of each of the locations
+	// that has any, ordered by language, by location ID.
+	ListTranslations(ctx context.Context, locationIDs []int64) (map[int64][]Translation, error)
+	// ReplaceTranslations replaces all of the location's translations. It
+	// is a write to the location: version makes it conditional and the
+	// location's version is incremented.
+	ReplaceTranslations(ctx context.Context, locationID int64, translations []Translation, version int64) error
+}
@@ -1,182 +1,275 @@
-package location
-
-import (
-	"database/sql"
-	"errors"
-
-	"github.com/nikolaypleshkov/uni-api/api/location/dto"
-)
-
-type LocationService interface {
-	CreateLocation(createLocationDTO dto.CreateLocationDTO) (dto.ResponseLocationDTO, error)
-	DeleteLocation(locationID int64) error
-	GetAllLocations() ([]dto.ResponseLocationDTO, error)
-	GetLocation(locationID int64) (dto.ResponseLocationDTO, error)
-	UpdateLocation(updateLocationDTO dto.UpdateLocationDTO) (dto.ResponseLocationDTO, error)
-}
-
-type LocationServiceImpl struct {
-	db *sql.DB
-}
-
-func NewLocationService(db *sql.DB) *LocationServiceImpl {
-	return &LocationServiceImpl{db}
-}
-
-func (s *LocationServiceImpl) ensureTableExists() error {
-	query := `
-        CREATE TABLE IF NOT EXISTS locations (
-            id SERIAL PRIMARY KEY,
-            number VARCHAR(255),
-            country VARCHAR(255),
-            city VARCHAR(255),
-            street VARCHAR(255),
-            image_url VARCHAR(255)
-        );
-    `
-	_, err := s.db.Exec(query)
-	return err
-}
-
-func (s *LocationServiceImpl) CreateLocation(createLocationDTO dto.CreateLocationDTO) (dto.ResponseLocationDTO, error) {
-	if err := s.ensureTableExists(); err != nil {
-		return dto.ResponseLocationDTO{}, err
-	}
-
-	query := `
-		INSERT INTO locations (number, country, city, street, image_url)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, number, country, city, street, image_url
-	`
-
-	row := s.db.QueryRow(
-		query,
-		createLocationDTO.Number,
-		createLocationDTO.Country,
-		createLocationDTO.City,
-		createLocationDTO.Street,
-		createLocationDTO.ImageURL,
-	)
-
-	var createdLocation dto.ResponseLocationDTO
-	err := row.Scan(
-		&createdLocation.ID,
-		&createdLocation.Number,
-		&createdLocation.Country,
-		&createdLocation.City,
-		&createdLocation.Street,
-		&createdLocation.ImageURL,
-	)
-
-	if err != nil {
-		return dto.ResponseLocationDTO{}, err
-	}
-
-	return createdLocation, nil
-}
-
-func (s *LocationServiceImpl) DeleteLocation(locationID int64) error {
-	query := "DELETE FROM locations WHERE id = $1"
-
-	result, err := s.db.Exec(query, locationID)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return errors.New("location not found")
-	}
-
-	return nil
-}
-
-func (s *LocationServiceImpl) GetAllLocations() ([]dto.ResponseLocationDTO, error) {
-	query := "SELECT * FROM locations"
-
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var locations []dto.ResponseLocationDTO
-	for rows.Next() {
-		var location dto.ResponseLocationDTO
-		err := rows.Scan(
-			&location.ID,
-			&location.Number,
-			&location.Country,
-			&location.City,
-			&location.Street,
-			&location.ImageURL,
-		)
-		if err != nil {
-			return nil, err
-		}
-		locations = append(locations, location)
-	}
-
-	return locations, nil
-}
-
-func (s *LocationServiceImpl) GetLocation(locationID int64) (dto.ResponseLocationDTO, error) {
-	query := "SELECT * FROM locations WHERE id = $1"
-
-	row := s.db.QueryRow(query, locationID)
-
-	var location dto.ResponseLocationDTO
-	err := row.Scan(
-		&location.ID,
-		&location.Number,
-		&location.Country,
-		&location.City,
-		&location.Street,
-		&location.ImageURL,
-	)
-
-	if err != nil {
-		return dto.ResponseLocationDTO{}, err
-	}
-
-	return location, nil
-}
-
-func (s *LocationServiceImpl) UpdateLocation(updateLocationDTO dto.UpdateLocationDTO) (dto.ResponseLocationDTO, error) {
-	query := `
-		UPDATE locations
-		SET number = $2, country = $3, city = $4, street = $5, image_url = $6
-		WHERE id = $1
-		RETURNING id, number, country, city, street, image_url
-	`
-
-	row := s.db.QueryRow(
-		query,
-		updateLocationDTO.ID,
-		updateLocationDTO.Number,
-		updateLocationDTO.Country,
-		updateLocationDTO.City,
-		updateLocationDTO.Street,
-		updateLocationDTO.ImageURL,
-	)
-
-	var updatedLocation dto.ResponseLocationDTO
-	err := row.Scan(
-		&updatedLocation.ID,
-		&updatedLocation.Number,
-		&updatedLocation.Country,
-		&updatedLocation.City,
-		&updatedLocation.Street,
-		&updatedLocation.ImageURL,
-	)
-
-	if err != nil {
-		return dto.ResponseLocationDTO{}, err
-	}
-
-	return updatedLocation, nil
-}
+package location
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/api/location/dto"
+	"github.com/nikolaypleshkov/uni-api/geo"
+	"github.com/nikolaypleshkov/uni-api/iso3166"
+	"github.com/nikolaypleshkov/uni-api/mergepatch"
+)
+
+type LocationService interface {
+	CreateLocation(ctx context.Context, createLocationDTO dto.CreateLocationDTO) (dto.ResponseLocationDTO, error)
+	DeleteLocation(ctx context.Context, locationID int64, version int64) error
+	GetAllLocations(ctx context.Context) ([]dto.ResponseLocationDTO, error)
+	GetLocation(ctx context.Context, locationID int64) (dto.ResponseLocationDTO, error)
+	GetLocations(ctx context.Context, locationIDs []int64) (map[int64]dto.ResponseLocationDTO, error)
+	UpdateLocation(ctx context.Context, updateLocationDTO dto.UpdateLocationDTO) (dto.ResponseLocationDTO, error)
+	PatchLocation(ctx context.Context, locationID int64, patch []byte, version int64) (dto.ResponseLocationDTO, error)
+	GetTranslations(ctx context.Context, locationID int64) (dto.LocationTranslationsDTO, error)
+	ReplaceTranslations(ctx context.Context, locationID int64, translationsDTO dto.LocationTranslationsDTO, version int64) (dto.LocationTranslationsDTO, error)
+	Localize(ctx context.Context, locations []dto.ResponseLocationDTO, ranges []string) ([]string, error)
+}
+
+type LocationServiceImpl struct {
+	repo     LocationRepository
+	geocoder geo.Geocoder
+}
+
+// NewLocationService returns a service that places new and edited locations
+// without coordinates on the map using geocoder. A nil geocoder leaves them
+// without coordinates.
+func NewLocationService(repo LocationRepository, geocoder geo.Geocoder) *LocationServiceImpl {
+	return &LocationServiceImpl{repo: repo, geocoder: geocoder}
+}
+
+func convertLocationToDTO(location Location) dto.ResponseLocationDTO {
+	return dto.ResponseLocationDTO{
+		ID:          location.ID,
+		Number:      location.Number,
+		Country:     location.Country,
+		CountryCode: location.CountryCode,
+		City:        location.City,
+		Street:      location.Street,
+		ImageURL:    location.ImageURL,
+		Latitude:    location.Latitude,
+		Longitude:   location.Longitude,
+		Version:     location.Version,
+	}
+}
+
+func (s *LocationServiceImpl) CreateLocation(ctx context.Context, createLocationDTO dto.CreateLocationDTO) (dto.ResponseLocationDTO, error) {
+	newLocation := Location{
+		Number:      createLocationDTO.Number,
+		Country:     createLocationDTO.Country,
+		CountryCode: createLocationDTO.CountryCode,
+		City:        createLocationDTO.City,
+		Street:      createLocationDTO.Street,
+		ImageURL:    createLocationDTO.ImageURL,
+		Latitude:    createLocationDTO.Latitude,
+		Longitude:   createLocationDTO.Longitude,
+	}
+	if err := normalizeAddress(&newLocation); err != nil {
+		return dto.ResponseLocationDTO{}, err
+	}
+	if err := s.locate(ctx, &newLocation); err != nil {
+		return dto.ResponseLocationDTO{}, err
+	}
+
+	createdLocation, err := s.repo.Create(ctx, newLocation)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create location", "error", err)
+		return dto.ResponseLocationDTO{}, err
+	}
+
+	return convertLocationToDTO(createdLocation), nil
+}
+
+func (s *LocationServiceImpl) DeleteLocation(ctx context.Context, locationID int64, version int64) error {
+	err := s.repo.Delete(ctx, locationID, version)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+		slog.ErrorContext(ctx, "Failed to delete location", "location_id", locationID, "error", err)
+	}
+
+	return err
+}
+
+func (s *LocationServiceImpl) GetAllLocations(ctx context.Context) ([]dto.ResponseLocationDTO, error) {
+	locations, err := s.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query locations", "error", err)
+		return nil, err
+	}
+
+	var locationDTOs []dto.ResponseLocationDTO
+	for _, location := range locations {
+		locationDTOs = append(locationDTOs, convertLocationToDTO(location))
+	}
+
+	return locationDTOs, nil
+}
+
+func (s *LocationServiceImpl) GetLocation(ctx context.Context, locationID int64) (dto.ResponseLocationDTO, error) {
+	location, err := s.repo.Get(ctx, locationID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get location", "location_id", locationID, "error", err)
+		}
+		return dto.ResponseLocationDTO{}, err
+	}
+
+	return convertLocationToDTO(location), nil
+}
+
+// GetLocations loads several locations at once, leaving out those that do
+// not exist.
+func (s *LocationServiceImpl) GetLocations(ctx context.Context, locationIDs []int64) (map[int64]dto.ResponseLocationDTO, error) {
+	locations, err := s.repo.GetMany(ctx, locationIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get locations", "error", err)
+		return nil, err
+	}
+
+	locationDTOs := make(map[int64]dto.ResponseLocationDTO, len(locations))
+	for id, location := range locations {
+		locationDTOs[id] = convertLocationToDTO(location)
+	}
+
+	return locationDTOs, nil
+}
+
+func (s *LocationServiceImpl) UpdateLocation(ctx context.Context, updateLocationDTO dto.UpdateLocationDTO) (dto.ResponseLocationDTO, error) {
+	location := Location{
+		ID:          updateLocationDTO.ID,
+		Number:      updateLocationDTO.Number,
+		Country:     updateLocationDTO.Country,
+		CountryCode: updateLocationDTO.CountryCode,
+		City:        updateLocationDTO.City,
+		Street:      updateLocationDTO.Street,
+		ImageURL:    updateLocationDTO.ImageURL,
+		Latitude:    updateLocationDTO.Latitude,
+		Longitude:   updateLocationDTO.Longitude,
+		Version:     updateLocationDTO.Version,
+	}
+	if err := normalizeAddress(&location); err != nil {
+		return dto.ResponseLocationDTO{}, err
+	}
+	if err := s.locate(ctx, &location); err != nil {
+		return dto.ResponseLocationDTO{}, err
+	}
+
+	updatedLocation, err := s.repo.Update(ctx, location)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+			slog.ErrorContext(ctx, "Failed to update location", "location_id", updateLocationDTO.ID, "error", err)
+		}
+		return dto.ResponseLocationDTO{}, err
+	}
+
+	return convertLocationToDTO(updatedLocation), nil
+}
+
+// PatchLocation applies a JSON merge patch to the location and saves the
+// result, so fields missing from the patch keep their current values. A
+// patch that moves the location to another city or country without giving
+// new coordinates has it geocoded again. A non-zero version makes the save
+// conditional, as for UpdateLocation.
+func (s *LocationServiceImpl) PatchLocation(ctx context.Context, locationID int64, patch []byte, version int64) (dto.ResponseLocationDTO, error) {
+	current, err := s.GetLocation(ctx, locationID)
+	if err != nil {
+		return dto.ResponseLocationDTO{}, err
+	}
+
+	var updateLocationDTO dto.UpdateLocationDTO
+	if err := mergepatch.Apply(dto.UpdateLocationDTO(current), patch, &updateLocationDTO); err != nil {
+		return dto.ResponseLocationDTO{}, err
+	}
+	updateLocationDTO.ID = locationID
+	updateLocationDTO.Version = version
+
+	// A patch usually changes only one of country and countryCode; the
+	// other one still names the old country and must not contradict it.
+	countryChanged := updateLocationDTO.Country != current.Country
+	codeChanged := updateLocationDTO.CountryCode != current.CountryCode
+	if countryChanged && !codeChanged {
+		updateLocationDTO.CountryCode = ""
+	} else if codeChanged && !countryChanged {
+		updateLocationDTO.Country = ""
+	}
+
+	moved := updateLocationDTO.City != current.City || countryChanged || codeChanged
+	if moved && sameCoordinate(updateLocationDTO.Latitude, current.Latitude) && sameCoordinate(updateLocationDTO.Longitude, current.Longitude) {
+		updateLocationDTO.Latitude, updateLocationDTO.Longitude = nil, nil
+	}
+
+	return s.UpdateLocation(ctx, updateLocationDTO)
+}
+
+// normalizeAddress stores the country under its ISO 3166 name and code,
+// whichever way the client wrote it, and collapses whitespace in the city.
+func normalizeAddress(location *Location) error {
+	location.City = strings.Join(strings.Fields(location.City), " ")
+
+	var byName, byCode iso3166.Country
+	if strings.TrimSpace(location.Country) != "" {
+		country, ok := iso3166.Lookup(location.Country)
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCountry, location.Country)
+		}
+		byName = country
+	}
+	if strings.TrimSpace(location.CountryCode) != "" {
+		country, ok := iso3166.Lookup(location.CountryCode)
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCountry, location.CountryCode)
+		}
+		byCode = country
+	}
+	if byName.Alpha2 != "" && byCode.Alpha2 != "" && byName.Alpha2 != byCode.Alpha2 {
+		return fmt.Errorf("%w: country %q does not match countryCode %q", ErrUnknownCountry, location.Country, location.CountryCode)
+	}
+
+	country := byName
+	if country.Alpha2 == "" {
+		country = byCode
+	}
+	location.Country, location.CountryCode = country.Name, country.Alpha2
+	return nil
+}
+
+// locate validates the coordinates of a location, or looks them up when
+// none were given. A location the geocoder cannot place is saved without
+// coordinates.
+func (s *LocationServiceImpl) locate(ctx context.Context, location *Location) error {
+	if location.Latitude != nil || location.Longitude != nil {
+		if location.Latitude == nil || location.Longitude == nil {
+			return fmt.Errorf("%w: latitude and longitude must be given together", ErrInvalidCoordinates)
+		}
+		if point := (geo.Point{Lat: *location.Latitude, Lng: *location.Longitude}); !point.Valid() {
+			return fmt.Errorf("%w: %g,%g is outside the valid ranges", ErrInvalidCoordinates, point.Lat, point.Lng)
+		}
+		return nil
+	}
+	if s.geocoder == nil {
+		return nil
+	}
+
+	point, err := s.geocoder.Geocode(ctx, geo.Address{
+		Number:  location.Number,
+		Street:  location.Street,
+		City:    location.City,
+		Country: cmp.Or(location.CountryCode, location.Country),
+	})
+	if err != nil {
+		if !errors.Is(err, geo.ErrNoMatch) {
+			slog.WarnContext(ctx, "Failed to geocode location", "city", location.City, "country", location.Country, "error", err)
+		}
+		return nil
+	}
+
+	location.Latitude, location.Longitude = &point.Lat, &point.Lng
+	return nil
+}
+
+func sameCoordinate(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,122 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/api/location/dto"
+	"github.com/nikolaypleshkov/uni-api/locale"
+)
+
+func (s *LocationServiceImpl) GetTranslations(ctx context.Context, locationID int64) (dto.LocationTranslationsDTO, error) {
+	if _, err := s.GetLocation(ctx, locationID); err != nil {
+		return dto.LocationTranslationsDTO{}, err
+	}
+
+	translations, err := s.repo.ListTranslations(ctx, []int64{locationID})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get location translations", "location_id", locationID, "error", err)
+		return dto.LocationTranslationsDTO{}, err
+	}
+
+	translationsDTO := dto.LocationTranslationsDTO{Translations: map[string]dto.LocationTranslationDTO{}}
+	for _, t := range translations[locationID] {
+		translationsDTO.Translations[t.Language] = dto.LocationTranslationDTO{City: t.City, Street: t.Street}
+	}
+
+	return translationsDTO, nil
+}
+
+// ReplaceTranslations replaces all of the location's translations.
+// Languages are BCP 47 tags other than the default language, which is the
+// location itself. A non-zero version makes the write conditional, as for
+// UpdateLocation.
+func (s *LocationServiceImpl) ReplaceTranslations(ctx context.Context, locationID int64, translationsDTO dto.LocationTranslationsDTO, version int64) (dto.LocationTranslationsDTO, error) {
+	given, err := locale.Translations(translationsDTO.Translations)
+	if err != nil {
+		return dto.LocationTranslationsDTO{}, fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+	translations := make([]Translation, len(given))
+	for i, g := range given {
+		translations[i] = Translation{
+			Language: g.Language,
+			City:     strings.Join(strings.Fields(g.Text.City), " "),
+			Street:   strings.TrimSpace(g.Text.Street),
+		}
+		if t := translations[i]; t.City == "" && t.Street == "" {
+			return dto.LocationTranslationsDTO{}, fmt.Errorf("%w: the %s translation is empty", ErrInvalidTranslation, t.Language)
+		}
+	}
+
+	err = s.repo.ReplaceTranslations(ctx, locationID, translations, version)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+			slog.ErrorContext(ctx, "Failed to replace location translations", "location_id", locationID, "error", err)
+		}
+		return dto.LocationTranslationsDTO{}, err
+	}
+
+	return s.GetTranslations(ctx, locationID)
+}
+
+// Localize translates each location into the language that best serves
+// ranges among those it is translated into. Names without a translation
+// stay in the default language. It returns the languages used.
+func (s *LocationServiceImpl) Localize(ctx context.Context, locations []dto.ResponseLocationDTO, ranges []string) ([]string, error) {
+	if len(locations) == 0 {
+		return nil, nil
+	}
+	if len(ranges) == 0 {
+		return []string{locale.Default}, nil
+	}
+
+	var locationIDs []int64
+	for _, location := range locations {
+		if location.ID != 0 && !slices.Contains(locationIDs, location.ID) {
+			locationIDs = append(locationIDs, location.ID)
+		}
+	}
+	translations, err := s.repo.ListTranslations(ctx, locationIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query location translations", "error", err)
+		return nil, err
+	}
+
+	var languages []string
+	for i := range locations {
+		language := translate(&locations[i], translations[locations[i].ID], ranges)
+		if !slices.Contains(languages, language) {
+			languages = append(languages, language)
+		}
+	}
+
+	return languages, nil
+}
+
+// translate applies the translation that best serves ranges to location
+// and returns its language.
+func translate(location *dto.ResponseLocationDTO, translations []Translation, ranges []string) string {
+	available := make([]string, len(translations))
+	for i, t := range translations {
+		available[i] = t.Language
+	}
+	language := locale.Match(ranges, available)
+
+	for _, t := range translations {
+		if t.Language != language {
+			continue
+		}
+		if t.City != "" {
+			location.City = t.City
+		}
+		if t.Street != "" {
+			location.Street = t.Street
+		}
+	}
+
+	return language
+}
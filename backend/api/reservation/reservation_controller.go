@@ -1,98 +1,240 @@
-package reservation
-
-import (
-	"encoding/json"
-	"net/http"
-	"strconv"
-
-	"github.com/gorilla/mux"
-	"github.com/nikolaypleshkov/uni-api/api/reservation/dto"
-)
-
-type ReservationController struct {
-	reservationService ReservationService
-}
-
-func NewReservationController(service ReservationService) *ReservationController {
-	return &ReservationController{service}
-}
-
-func (c *ReservationController) CreateReservation(w http.ResponseWriter, r *http.Request) {
-	var createReservationDTO dto.CreateReservationDTO
-	if err := json.NewDecoder(r.Body).Decode(&createReservationDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	createdReservation, err := c.reservationService.CreateReservation(createReservationDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(createdReservation)
-}
-
-func (c *ReservationController) DeleteReservation(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	reservationID, err := strconv.ParseInt(vars["reservationId"], 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	err = c.reservationService.DeleteReservation(reservationID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-}
-
-func (c *ReservationController) GetAllReservations(w http.ResponseWriter, r *http.Request) {
-	reservations, err := c.reservationService.GetAllReservations()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(reservations)
-}
-
-func (c *ReservationController) GetReservationByID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	reservationID, err := strconv.ParseInt(vars["reservationId"], 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	reservation, err := c.reservationService.GetReservationByID(reservationID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(reservation)
-}
-
-func (c *ReservationController) UpdateReservation(w http.ResponseWriter, r *http.Request) {
-	var updateReservationDTO dto.UpdateReservationDTO
-	if err := json.NewDecoder(r.Body).Decode(&updateReservationDTO); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	updatedReservation, err := c.reservationService.UpdateReservation(updateReservationDTO)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedReservation)
-}
+package reservation
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+	"github.com/nikolaypleshkov/uni-api/etag"
+	"github.com/nikolaypleshkov/uni-api/mergepatch"
+)
+
+type ReservationController struct {
+	reservationService ReservationService
+}
+
+func NewReservationController(service ReservationService) *ReservationController {
+	return &ReservationController{service}
+}
+
+func (c *ReservationController) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	var createReservationDTO dto.CreateReservationDTO
+	if err := json.NewDecoder(r.Body).Decode(&createReservationDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdReservation, err := c.reservationService.CreateReservation(r.Context(), createReservationDTO)
+	if errors.Is(err, holiday.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, ErrNoFreeSlots) || errors.Is(err, ErrNotBookable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(createdReservation)
+}
+
+func (c *ReservationController) DeleteReservation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reservationID, err := strconv.ParseInt(vars["reservationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentReservation(w, r, reservationID)
+	if !ok || !etag.CheckIfMatch(w, r, reservationETag(current)) {
+		return
+	}
+
+	err = c.reservationService.DeleteReservation(r.Context(), reservationID, current.Version)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *ReservationController) GetAllReservations(w http.ResponseWriter, r *http.Request) {
+	reservations, err := c.reservationService.GetAllReservations(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservations)
+}
+
+func (c *ReservationController) GetReservationByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	reservationID, err := strconv.ParseInt(vars["reservationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := c.reservationService.GetReservationByID(r.Context(), reservationID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if etag.NotModified(w, r, reservationETag(reservation)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+func (c *ReservationController) UpdateReservation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	reservationID, err := strconv.ParseInt(params["reservationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updateReservationDTO dto.UpdateReservationDTO
+	if err := json.NewDecoder(r.Body).Decode(&updateReservationDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if updateReservationDTO.ID != 0 && updateReservationDTO.ID != reservationID {
+		http.Error(w, "Reservation ID in body does not match the URL", http.StatusBadRequest)
+		return
+	}
+	updateReservationDTO.ID = reservationID
+
+	current, ok := c.currentReservation(w, r, reservationID)
+	if !ok || !etag.CheckIfMatch(w, r, reservationETag(current)) {
+		return
+	}
+	updateReservationDTO.Version = current.Version
+
+	updatedReservation, err := c.reservationService.UpdateReservation(r.Context(), updateReservationDTO)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if errors.Is(err, holiday.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, ErrNoFreeSlots) || errors.Is(err, ErrNotBookable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", reservationETag(updatedReservation))
+	json.NewEncoder(w).Encode(updatedReservation)
+}
+
+func (c *ReservationController) PatchReservation(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	reservationID, err := strconv.ParseInt(params["reservationId"], 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !mergepatch.AcceptsContentType(r.Header.Get("Content-Type")) {
+		http.Error(w, "Content-Type must be "+mergepatch.MediaType, http.StatusUnsupportedMediaType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentReservation(w, r, reservationID)
+	if !ok || !etag.CheckIfMatch(w, r, reservationETag(current)) {
+		return
+	}
+
+	patchedReservation, err := c.reservationService.PatchReservation(r.Context(), reservationID, patch, current.Version)
+	if errors.Is(err, mergepatch.ErrInvalidPatch) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if errors.Is(err, holiday.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, ErrNoFreeSlots) || errors.Is(err, ErrNotBookable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", reservationETag(patchedReservation))
+	json.NewEncoder(w).Encode(patchedReservation)
+}
+
+// currentReservation loads the reservation a conditional request refers
+// to. It writes the error response itself and returns false when that fails.
+func (c *ReservationController) currentReservation(w http.ResponseWriter, r *http.Request, reservationID int64) (dto.ResponseReservationDTO, bool) {
+	reservation, err := c.reservationService.GetReservationByID(r.Context(), reservationID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return dto.ResponseReservationDTO{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return dto.ResponseReservationDTO{}, false
+	}
+
+	return reservation, true
+}
+
+func reservationETag(reservation dto.ResponseReservationDTO) string {
+	return etag.Format(reservation.Version)
+}
@@ -0,0 +1,31 @@
+package reservation
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNotFound    = errors.New("reservation not found")
+	ErrNoFreeSlots = errors.New("no free slots left for this holiday")
+	ErrNotBookable = errors.New("holiday is not open for reservations")
+
+	ErrVersionMismatch = errors.New("reservation was modified by another request")
+)
+
+// Implementations of Create take one free slot from the reserved holiday,
+// which must be published, and Delete gives it back. Update moves the
+// reservation, and its slot, when given a different non-zero HolidayID.
+// Taking or releasing a slot is a write to the holiday and increments its
+// version.
+//
+// A non-zero Version on Update, or version on Delete, makes the write
+// conditional: it fails with ErrVersionMismatch unless the stored row still
+// has that version. Every successful write increments the version.
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation Reservation) (Reservation, error)
+	Delete(ctx context.Context, reservationID int64, version int64) error
+	List(ctx context.Context) ([]Reservation, error)
+	Get(ctx context.Context, reservationID int64) (Reservation, error)
+	Update(ctx context.Context, reservation Reservation) (Reservation, error)
+}
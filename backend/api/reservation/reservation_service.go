@@ -1,275 +1,267 @@
-package reservation
-
-import (
-	"database/sql"
-	"fmt"
-	"sync"
-
-	"github.com/nikolaypleshkov/uni-api/api/holiday"
-	"github.com/nikolaypleshkov/uni-api/api/reservation/dto"
-)
-
-type ReservationService interface {
-	GetAllReservations() ([]dto.ResponseReservationDTO, error)
-	CreateReservation(createDTO dto.CreateReservationDTO) (dto.ResponseReservationDTO, error)
-	UpdateReservation(updateDTO dto.UpdateReservationDTO) (dto.ResponseReservationDTO, error)
-	GetReservation(reservationID int64) (dto.ResponseReservationDTO, error)
-	DeleteReservation(reservationID int64) error
-	GetReservationByID(reservationID int64) (dto.ResponseReservationDTO, error)
-}
-
-type HolidayDTO struct {
-	ID int64 `json:"id"`
-}
-
-type GetHolidayDTO struct {
-	ID int64 `json:"id"`
-}
-
-type ReservationServiceImpl struct {
-	db             *sql.DB
-	HolidayService *holiday.Service
-	mu             sync.Mutex
-}
-
-func NewReservationService(db *sql.DB, holidayService *holiday.Service) *ReservationServiceImpl {
-	return &ReservationServiceImpl{
-		db:             db,
-		HolidayService: holidayService,
-	}
-}
-
-func (s *ReservationServiceImpl) ensureTableExists() error {
-	query := `
-        CREATE TABLE IF NOT EXISTS reservations (
-            id SERIAL PRIMARY KEY,
-            phone_number VARCHAR(255),
-            contact_name VARCHAR(255),
-            holiday_id INT
-        );
-    `
-	_, err := s.db.Exec(query)
-	return err
-}
-
-func (s *ReservationServiceImpl) GetAllReservations() ([]dto.ResponseReservationDTO, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := "SELECT * FROM reservations"
-
-	rows, err := s.db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var reservations []dto.ResponseReservationDTO
-	for rows.Next() {
-		var reservation Reservation
-		err := rows.Scan(
-			&reservation.ID,
-			&reservation.PhoneNumber,
-			&reservation.ContactName,
-			&reservation.HolidayID,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		holidayDTO, err := s.HolidayService.GetHolidayDTO(reservation.HolidayID)
-		if err != nil {
-			return nil, err
-		}
-
-		responseDTO := dto.ResponseReservationDTO{
-			ID:          reservation.ID,
-			PhoneNumber: reservation.PhoneNumber,
-			ContactName: reservation.ContactName,
-			Holiday:     holidayDTO,
-		}
-		reservations = append(reservations, responseDTO)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return reservations, nil
-}
-
-func (s *ReservationServiceImpl) CreateReservation(createDTO dto.CreateReservationDTO) (dto.ResponseReservationDTO, error) {
-	if err := s.ensureTableExists(); err != nil {
-		return dto.ResponseReservationDTO{}, err
-	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	_, err := s.HolidayService.GetHolidayDTO(createDTO.HolidayID)
-	if err != nil {
-		return dto.ResponseReservationDTO{}, fmt.Errorf("associated holiday not found: %v", err)
-	}
-
-	query := `
-        INSERT INTO reservations (phone_number, contact_name, holiday_id)
-        VALUES ($1, $2, $3)
-        RETURNING id, phone_number, contact_name, holiday_id
-    `
-
-	row := s.db.QueryRow(
-		query,
-		createDTO.PhoneNumber,
-		createDTO.ContactName,
-		createDTO.HolidayID,
-	)
-
-	var createdReservation Reservation
-	err = row.Scan(
-		&createdReservation.ID,
-		&createdReservation.PhoneNumber,
-		&createdReservation.ContactName,
-		&createdReservation.HolidayID,
-	)
-
-	if err != nil {
-		return dto.ResponseReservationDTO{}, err
-	}
-
-	holidayDTO, err := s.HolidayService.GetHolidayDTO(createdReservation.HolidayID)
-	if err != nil {
-		return dto.ResponseReservationDTO{}, err
-	}
-
-	responseDTO := dto.ResponseReservationDTO{
-		ID:          createdReservation.ID,
-		PhoneNumber: createdReservation.PhoneNumber,
-		ContactName: createdReservation.ContactName,
-		Holiday:     holidayDTO,
-	}
-
-	return responseDTO, nil
-}
-
-func (s *ReservationServiceImpl) UpdateReservation(updateDTO dto.UpdateReservationDTO) (dto.ResponseReservationDTO, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := `
-		UPDATE reservations
-		SET phone_number = $1, contact_name = $2
-		WHERE id = $3
-		RETURNING id, phone_number, contact_name, holiday_id
-	`
-
-	row := s.db.QueryRow(
-		query,
-		updateDTO.PhoneNumber,
-		updateDTO.ContactName,
-		updateDTO.ID,
-	)
-
-	var updatedReservation Reservation
-	err := row.Scan(
-		&updatedReservation.ID,
-		&updatedReservation.PhoneNumber,
-		&updatedReservation.ContactName,
-		&updatedReservation.HolidayID,
-	)
-
-	if err != nil {
-		return dto.ResponseReservationDTO{}, err
-	}
-
-	holidayDTO, err := s.HolidayService.GetHolidayDTO(updatedReservation.HolidayID)
-	if err != nil {
-		return dto.ResponseReservationDTO{}, err
-	}
-
-	responseDTO := dto.ResponseReservationDTO{
-		ID:          updatedReservation.ID,
-		PhoneNumber: updatedReservation.PhoneNumber,
-		ContactName: updatedReservation.ContactName,
-		Holiday:     holidayDTO,
-	}
-
-	return responseDTO, nil
-}
-
-func (s *ReservationServiceImpl) GetReservation(reservationID int64) (dto.ResponseReservationDTO, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := "SELECT * FROM reservations WHERE id = $1"
-
-	row := s.db.QueryRow(query, reservationID)
-
-	var reservation Reservation
-	err := row.Scan(
-		&reservation.ID,
-		&reservation.PhoneNumber,
-		&reservation.ContactName,
-		&reservation.HolidayID,
-	)
-
-	if err != nil {
-		return dto.ResponseReservationDTO{}, err
-	}
-
-	holidayDTO, err := s.HolidayService.GetHolidayDTO(reservation.HolidayID)
-	if err != nil {
-		return dto.ResponseReservationDTO{}, err
-	}
-
-	responseDTO := dto.ResponseReservationDTO{
-		ID:          reservation.ID,
-		PhoneNumber: reservation.PhoneNumber,
-		ContactName: reservation.ContactName,
-		Holiday:     holidayDTO,
-	}
-
-	return responseDTO, nil
-}
-
-func (s *ReservationServiceImpl) DeleteReservation(reservationID int64) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := "DELETE FROM reservations WHERE id = $1"
-
-	_, err := s.db.Exec(query, reservationID)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *ReservationServiceImpl) GetReservationByID(reservationID int64) (dto.ResponseReservationDTO, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	query := "SELECT * FROM reservations WHERE id = $1"
-
-	row := s.db.QueryRow(query, reservationID)
-
-	var reservation Reservation
-	err := row.Scan(
-		&reservation.ID,
-		&reservation.PhoneNumber,
-		&reservation.ContactName,
-		&reservation.HolidayID,
-	)
-
-	if err != nil {
-		return dto.ResponseReservationDTO{}, err
-	}
-
-	responseDTO := dto.ResponseReservationDTO{
-		ID:          reservation.ID,
-		PhoneNumber: reservation.PhoneNumber,
-		ContactName: reservation.ContactName,
-	}
-
-	return responseDTO, nil
-}
+package reservation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+	"github.com/nikolaypleshkov/uni-api/mergepatch"
+	"github.com/nikolaypleshkov/uni-api/metrics"
+)
+
+type ReservationService interface {
+	GetAllReservations(ctx context.Context) ([]dto.ResponseReservationDTO, error)
+	CreateReservation(ctx context.Context, createDTO dto.CreateReservationDTO) (dto.ResponseReservationDTO, error)
+	UpdateReservation(ctx context.Context, updateDTO dto.UpdateReservationDTO) (dto.ResponseReservationDTO, error)
+	PatchReservation(ctx context.Context, reservationID int64, patch []byte, version int64) (dto.ResponseReservationDTO, error)
+	GetReservation(ctx context.Context, reservationID int64) (dto.ResponseReservationDTO, error)
+	DeleteReservation(ctx context.Context, reservationID int64, version int64) error
+	GetReservationByID(ctx context.Context, reservationID int64) (dto.ResponseReservationDTO, error)
+}
+
+type HolidayService interface {
+	GetHolidayDTO(ctx context.Context, holidayID int64) (holiday.Holiday, error)
+	GetHolidayDTOs(ctx context.Context, holidayIDs []int64) (map[int64]holiday.Holiday, error)
+}
+
+type HolidayDTO struct {
+	ID int64 `json:"id"`
+}
+
+type GetHolidayDTO struct {
+	ID int64 `json:"id"`
+}
+
+type ReservationServiceImpl struct {
+	repo           ReservationRepository
+	HolidayService HolidayService
+}
+
+func NewReservationService(repo ReservationRepository, holidayService HolidayService) *ReservationServiceImpl {
+	return &ReservationServiceImpl{
+		repo:           repo,
+		HolidayService: holidayService,
+	}
+}
+
+func (s *ReservationServiceImpl) GetAllReservations(ctx context.Context) ([]dto.ResponseReservationDTO, error) {
+	reservations, err := s.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query reservations", "error", err)
+		return nil, err
+	}
+
+	holidayIDs := make([]int64, len(reservations))
+	for i, reservation := range reservations {
+		holidayIDs[i] = reservation.HolidayID
+	}
+	holidays, err := s.HolidayService.GetHolidayDTOs(ctx, holidayIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseDTOs []dto.ResponseReservationDTO
+	for _, reservation := range reservations {
+		holidayDTO, ok := holidays[reservation.HolidayID]
+		if !ok {
+			return nil, fmt.Errorf("%w: ID %d", holiday.ErrNotFound, reservation.HolidayID)
+		}
+
+		responseDTO := dto.ResponseReservationDTO{
+			ID:          reservation.ID,
+			PhoneNumber: reservation.PhoneNumber,
+			ContactName: reservation.ContactName,
+			Version:     reservation.Version,
+			Holiday:     holidayDTO,
+		}
+		responseDTOs = append(responseDTOs, responseDTO)
+	}
+
+	return responseDTOs, nil
+}
+
+func (s *ReservationServiceImpl) CreateReservation(ctx context.Context, createDTO dto.CreateReservationDTO) (dto.ResponseReservationDTO, error) {
+	_, err := s.HolidayService.GetHolidayDTO(ctx, createDTO.HolidayID)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, fmt.Errorf("associated holiday not found: %w", err)
+	}
+
+	createdReservation, err := s.repo.Create(ctx, Reservation{
+		PhoneNumber: createDTO.PhoneNumber,
+		ContactName: createDTO.ContactName,
+		HolidayID:   createDTO.HolidayID,
+	})
+	if err != nil {
+		if !errors.Is(err, ErrNoFreeSlots) && !errors.Is(err, ErrNotBookable) && !errors.Is(err, holiday.ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to create reservation", "holiday_id", createDTO.HolidayID, "error", err)
+		}
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	holidayDTO, err := s.HolidayService.GetHolidayDTO(ctx, createdReservation.HolidayID)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	metrics.ReservationsCreated.Inc()
+
+	responseDTO := dto.ResponseReservationDTO{
+		ID:          createdReservation.ID,
+		PhoneNumber: createdReservation.PhoneNumber,
+		ContactName: createdReservation.ContactName,
+		Version:     createdReservation.Version,
+		Holiday:     holidayDTO,
+	}
+
+	return responseDTO, nil
+}
+
+func (s *ReservationServiceImpl) UpdateReservation(ctx context.Context, updateDTO dto.UpdateReservationDTO) (dto.ResponseReservationDTO, error) {
+	current, err := s.getReservation(ctx, updateDTO.ID)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	updatedReservation, err := s.repo.Update(ctx, Reservation{
+		ID:          updateDTO.ID,
+		PhoneNumber: updateDTO.PhoneNumber,
+		ContactName: updateDTO.ContactName,
+		HolidayID:   updateDTO.HolidayID,
+		Version:     updateDTO.Version,
+	})
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) && !errors.Is(err, ErrNoFreeSlots) && !errors.Is(err, ErrNotBookable) && !errors.Is(err, holiday.ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to update reservation", "reservation_id", updateDTO.ID, "error", err)
+		}
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	holidayDTO, err := s.HolidayService.GetHolidayDTO(ctx, updatedReservation.HolidayID)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	responseDTO := dto.ResponseReservationDTO{
+		ID:          updatedReservation.ID,
+		PhoneNumber: updatedReservation.PhoneNumber,
+		ContactName: updatedReservation.ContactName,
+		Version:     updatedReservation.Version,
+		Holiday:     holidayDTO,
+	}
+
+	if updatedReservation.HolidayID != current.HolidayID {
+		slog.InfoContext(ctx, "Moved reservation", "reservation_id", updatedReservation.ID, "from_holiday_id", current.HolidayID, "to_holiday_id", updatedReservation.HolidayID)
+
+		previousHoliday, err := s.HolidayService.GetHolidayDTO(ctx, current.HolidayID)
+		if err == nil {
+			responseDTO.PriceDifference = priceDifference(previousHoliday.Price, holidayDTO.Price)
+		}
+	}
+
+	return responseDTO, nil
+}
+
+// PatchReservation applies a JSON merge patch to the reservation. Patching
+// holiday_id moves the reservation exactly like UpdateReservation does, and
+// a non-zero version makes the save conditional.
+func (s *ReservationServiceImpl) PatchReservation(ctx context.Context, reservationID int64, patch []byte, version int64) (dto.ResponseReservationDTO, error) {
+	current, err := s.getReservation(ctx, reservationID)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	var updateDTO dto.UpdateReservationDTO
+	err = mergepatch.Apply(dto.UpdateReservationDTO{
+		ID:          current.ID,
+		PhoneNumber: current.PhoneNumber,
+		ContactName: current.ContactName,
+		HolidayID:   current.HolidayID,
+	}, patch, &updateDTO)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, err
+	}
+	updateDTO.ID = reservationID
+	updateDTO.Version = version
+
+	return s.UpdateReservation(ctx, updateDTO)
+}
+
+// priceDifference returns to - from as a decimal string with two places, or
+// an empty string when either price cannot be parsed.
+func priceDifference(from, to string) string {
+	fromPrice, ok := new(big.Rat).SetString(from)
+	if !ok {
+		return ""
+	}
+	toPrice, ok := new(big.Rat).SetString(to)
+	if !ok {
+		return ""
+	}
+
+	return new(big.Rat).Sub(toPrice, fromPrice).FloatString(2)
+}
+
+func (s *ReservationServiceImpl) GetReservation(ctx context.Context, reservationID int64) (dto.ResponseReservationDTO, error) {
+	reservation, err := s.getReservation(ctx, reservationID)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	holidayDTO, err := s.HolidayService.GetHolidayDTO(ctx, reservation.HolidayID)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	responseDTO := dto.ResponseReservationDTO{
+		ID:          reservation.ID,
+		PhoneNumber: reservation.PhoneNumber,
+		ContactName: reservation.ContactName,
+		Version:     reservation.Version,
+		Holiday:     holidayDTO,
+	}
+
+	return responseDTO, nil
+}
+
+func (s *ReservationServiceImpl) DeleteReservation(ctx context.Context, reservationID int64, version int64) error {
+	err := s.repo.Delete(ctx, reservationID, version)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+			slog.ErrorContext(ctx, "Failed to delete reservation", "reservation_id", reservationID, "error", err)
+		}
+		return err
+	}
+
+	metrics.ReservationsCancelled.Inc()
+
+	return nil
+}
+
+func (s *ReservationServiceImpl) GetReservationByID(ctx context.Context, reservationID int64) (dto.ResponseReservationDTO, error) {
+	reservation, err := s.getReservation(ctx, reservationID)
+	if err != nil {
+		return dto.ResponseReservationDTO{}, err
+	}
+
+	responseDTO := dto.ResponseReservationDTO{
+		ID:          reservation.ID,
+		PhoneNumber: reservation.PhoneNumber,
+		ContactName: reservation.ContactName,
+		Version:     reservation.Version,
+	}
+
+	return responseDTO, nil
+}
+
+func (s *ReservationServiceImpl) getReservation(ctx context.Context, reservationID int64) (Reservation, error) {
+	reservation, err := s.repo.Get(ctx, reservationID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		slog.ErrorContext(ctx, "Failed to get reservation", "reservation_id", reservationID, "error", err)
+	}
+
+	return reservation, err
+}
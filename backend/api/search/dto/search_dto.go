@@ -0,0 +1,22 @@
+package dto
+
+import (
+	holiday "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	location "github.com/nikolaypleshkov/uni-api/api/location/dto"
+)
+
+type ResponseSearchDTO struct {
+	Query   string              `json:"query"`
+	Results []ResponseResultDTO `json:"results"`
+}
+
+// ResponseResultDTO is one search hit. Exactly one of Holiday and Location
+// is set, as given by Type.
+type ResponseResultDTO struct {
+	Type      string                        `json:"type"`
+	ID        int64                         `json:"id"`
+	Rank      float64                       `json:"rank"`
+	Highlight string                        `json:"highlight"`
+	Holiday   *holiday.ResponseHolidayDTO   `json:"holiday,omitempty"`
+	Location  *location.ResponseLocationDTO `json:"location,omitempty"`
+}
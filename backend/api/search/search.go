@@ -0,0 +1,154 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	"github.com/nikolaypleshkov/uni-api/fulltext"
+)
+
+// Kind says whether a search hit is a holiday or a location.
+type Kind string
+
+const (
+	HolidayKind  Kind = "holiday"
+	LocationKind Kind = "location"
+)
+
+// Query is a parsed search: every term must prefix a word of a document for
+// it to match.
+type Query struct {
+	Terms []string
+	Limit int
+}
+
+// Hit is a matching document. Rank orders hits within one search and is
+// not comparable across searches. Highlight is the document's text with
+// the matching words wrapped in <mark> tags.
+type Hit struct {
+	Kind      Kind
+	ID        int64
+	Rank      float64
+	Highlight string
+}
+
+// Document is the searchable text of a holiday or location, in fields
+// weighted by how well a match describes the result.
+type Document struct {
+	Kind   Kind
+	ID     int64
+	Fields []fulltext.Field
+	// Text is what the highlight of a hit on the document shows.
+	Text string
+}
+
+// HolidayDocument indexes a holiday by its title; by the city, country and
+// tag names it is sold under; by its street and the month and year it
+// starts in, so that "varna july" finds the July departures to Varna; and,
+// weakest, by its description. The PostgreSQL search builds the same
+// document in SQL.
+func HolidayDocument(h holiday.Holiday, l location.Location, tagNames []string) Document {
+	return Document{
+		Kind: HolidayKind,
+		ID:   h.ID,
+		Fields: []fulltext.Field{
+			{Text: h.Title, Weight: fulltext.A},
+			{Text: l.City + " " + l.Country + " " + strings.Join(tagNames, " "), Weight: fulltext.B},
+			{Text: l.Street + " " + startMonth(h.StartDate), Weight: fulltext.C},
+			{Text: h.Description, Weight: fulltext.D},
+		},
+		Text: joinNonEmpty(h.Title, l.City, l.Country),
+	}
+}
+
+// LocationDocument indexes a location by its city, country and street.
+func LocationDocument(l location.Location) Document {
+	return Document{
+		Kind: LocationKind,
+		ID:   l.ID,
+		Fields: []fulltext.Field{
+			{Text: l.City, Weight: fulltext.A},
+			{Text: l.Country, Weight: fulltext.B},
+			{Text: l.Street, Weight: fulltext.C},
+		},
+		Text: joinNonEmpty(l.City, l.Country, l.Street),
+	}
+}
+
+// startMonth names the month and year of a start date, e.g. "July 2026".
+// Dates are stored as YYYY-MM-DD but read back as RFC 3339 by some drivers.
+func startMonth(startDate string) string {
+	date, err := time.Parse(time.DateOnly, startDate[:min(len(startDate), len(time.DateOnly))])
+	if err != nil {
+		return ""
+	}
+	return date.Format("January 2006")
+}
+
+// joinNonEmpty joins the non-empty parts of a highlight with a middle dot.
+func joinNonEmpty(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " · ")
+}
+
+// Index answers searches from an inverted index held in memory, for the
+// backends without full-text search of their own. It is rebuilt from the
+// stored rows whenever their fingerprint changes, and is safe for
+// concurrent use.
+type Index struct {
+	mu          sync.Mutex
+	fingerprint string
+	documents   []Document
+	index       *fulltext.Index
+}
+
+// Fingerprint summarises a table for Index: ids are never reused and
+// every write increments a row's version, so any insert, update or delete
+// changes the row count, the version total or the highest id.
+func Fingerprint(rows, versions, maxID int64) string {
+	return fmt.Sprintf("%d/%d/%d", rows, versions, maxID)
+}
+
+// Search runs query over the documents returned by load. load is called
+// only when fingerprint differs from the one the index was built for, and
+// must return holidays before locations and each in id order, which is how
+// ties between equal ranks are broken.
+func (ix *Index) Search(query Query, fingerprint string, load func() ([]Document, error)) ([]Hit, error) {
+	ix.mu.Lock()
+	if ix.index == nil || ix.fingerprint != fingerprint {
+		documents, err := load()
+		if err != nil {
+			ix.mu.Unlock()
+			return nil, err
+		}
+
+		index := fulltext.NewIndex()
+		for _, document := range documents {
+			index.Add(fulltext.Document{Fields: document.Fields})
+		}
+		ix.fingerprint, ix.documents, ix.index = fingerprint, documents, index
+	}
+	documents, index := ix.documents, ix.index
+	ix.mu.Unlock()
+
+	var hits []Hit
+	for _, match := range index.Search(query.Terms, query.Limit) {
+		document := documents[match.Doc]
+		hits = append(hits, Hit{
+			Kind:      document.Kind,
+			ID:        document.ID,
+			Rank:      match.Score,
+			Highlight: fulltext.Highlight(document.Text, query.Terms),
+		})
+	}
+	return hits, nil
+}
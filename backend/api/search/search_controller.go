@@ -0,0 +1,30 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(service *Service) *Controller {
+	return &Controller{service: service}
+}
+
+func (c *Controller) Search(w http.ResponseWriter, r *http.Request) {
+	results, err := c.service.Search(r.Context(), r.URL.Query())
+	if errors.Is(err, ErrInvalidQuery) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
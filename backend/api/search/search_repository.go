@@ -0,0 +1,15 @@
+package search
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrInvalidQuery = errors.New("invalid search query")
+
+// SearchRepository finds the holidays and locations matching a query, best
+// matches first. Ties are broken by kind and then id, so that results are
+// stable between searches.
+type SearchRepository interface {
+	Search(ctx context.Context, query Query) ([]Hit, error)
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	"github.com/nikolaypleshkov/uni-api/api/search/dto"
+	"github.com/nikolaypleshkov/uni-api/fulltext"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type Service struct {
+	repo            SearchRepository
+	holidayService  *holiday.Service
+	locationService location.LocationService
+}
+
+func NewService(repo SearchRepository, holidayService *holiday.Service, locationService location.LocationService) *Service {
+	return &Service{
+		repo:            repo,
+		holidayService:  holidayService,
+		locationService: locationService,
+	}
+}
+
+// Search finds the holidays and locations matching the words of the q
+// parameter. Each word matches the words it starts, so results appear while
+// the customer is still typing.
+func (s *Service) Search(ctx context.Context, queryParams url.Values) (dto.ResponseSearchDTO, error) {
+	query, err := parseQuery(queryParams)
+	if err != nil {
+		return dto.ResponseSearchDTO{}, err
+	}
+
+	hits, err := s.repo.Search(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to search", "query", queryParams.Get("q"), "error", err)
+		return dto.ResponseSearchDTO{}, err
+	}
+
+	results := []dto.ResponseResultDTO{}
+	for _, hit := range hits {
+		result := dto.ResponseResultDTO{
+			Type:      string(hit.Kind),
+			ID:        hit.ID,
+			Rank:      hit.Rank,
+			Highlight: hit.Highlight,
+		}
+
+		// A hit deleted since the search is left out.
+		switch hit.Kind {
+		case HolidayKind:
+			holidayDTO, err := s.holidayService.GetHoliday(ctx, hit.ID)
+			if errors.Is(err, holiday.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return dto.ResponseSearchDTO{}, err
+			}
+			result.Holiday = &holidayDTO
+		case LocationKind:
+			locationDTO, err := s.locationService.GetLocation(ctx, hit.ID)
+			if errors.Is(err, location.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return dto.ResponseSearchDTO{}, err
+			}
+			result.Location = &locationDTO
+		}
+
+		results = append(results, result)
+	}
+
+	return dto.ResponseSearchDTO{Query: queryParams.Get("q"), Results: results}, nil
+}
+
+func parseQuery(queryParams url.Values) (Query, error) {
+	query := Query{
+		Terms: fulltext.Terms(queryParams.Get("q")),
+		Limit: defaultLimit,
+	}
+	if len(query.Terms) == 0 {
+		return Query{}, fmt.Errorf("%w: q must contain a word", ErrInvalidQuery)
+	}
+
+	if limit := queryParams.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxLimit {
+			return Query{}, fmt.Errorf("%w: limit must be a number from 1 to %d", ErrInvalidQuery, maxLimit)
+		}
+		query.Limit = value
+	}
+
+	return query, nil
+}
@@ -0,0 +1,20 @@
+package dto
+
+type CreateTagDTO struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type UpdateTagDTO struct {
+	ID      int64  `json:"id"`
+	Slug    string `json:"slug"`
+	Name    string `json:"name"`
+	Version int64  `json:"-"`
+}
+
+type ResponseTagDTO struct {
+	ID      int64  `json:"id"`
+	Slug    string `json:"slug"`
+	Name    string `json:"name"`
+	Version int64  `json:"version"`
+}
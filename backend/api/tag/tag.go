@@ -0,0 +1,10 @@
+package tag
+
+// Tag labels holidays for marketing, e.g. "beach", "ski" or "family".
+// Clients refer to tags by Slug; Name is what is shown to customers.
+type Tag struct {
+	ID      int64
+	Slug    string
+	Name    string
+	Version int64
+}
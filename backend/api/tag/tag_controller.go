@@ -0,0 +1,172 @@
+package tag
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nikolaypleshkov/uni-api/api/tag/dto"
+	"github.com/nikolaypleshkov/uni-api/etag"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(service *Service) *Controller {
+	return &Controller{service: service}
+}
+
+func (c *Controller) CreateTag(w http.ResponseWriter, r *http.Request) {
+	var createTagDTO dto.CreateTagDTO
+	if err := json.NewDecoder(r.Body).Decode(&createTagDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdTag, err := c.service.CreateTag(r.Context(), createTagDTO)
+	if errors.Is(err, ErrInvalidTag) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrDuplicateSlug) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(createdTag)
+}
+
+func (c *Controller) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := strconv.ParseInt(mux.Vars(r)["tagId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentTag(w, r, tagID)
+	if !ok || !etag.CheckIfMatch(w, r, tagETag(current)) {
+		return
+	}
+
+	err = c.service.DeleteTag(r.Context(), tagID, current.Version)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := c.service.GetTags(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+func (c *Controller) GetTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := strconv.ParseInt(mux.Vars(r)["tagId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	tag, ok := c.currentTag(w, r, tagID)
+	if !ok || etag.NotModified(w, r, tagETag(tag)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (c *Controller) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	tagID, err := strconv.ParseInt(mux.Vars(r)["tagId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return
+	}
+
+	var updateTagDTO dto.UpdateTagDTO
+	if err := json.NewDecoder(r.Body).Decode(&updateTagDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if updateTagDTO.ID != 0 && updateTagDTO.ID != tagID {
+		http.Error(w, "Tag ID in body does not match the URL", http.StatusBadRequest)
+		return
+	}
+	updateTagDTO.ID = tagID
+
+	current, ok := c.currentTag(w, r, tagID)
+	if !ok || !etag.CheckIfMatch(w, r, tagETag(current)) {
+		return
+	}
+	updateTagDTO.Version = current.Version
+
+	updatedTag, err := c.service.UpdateTag(r.Context(), updateTagDTO)
+	if errors.Is(err, ErrInvalidTag) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrDuplicateSlug) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", tagETag(updatedTag))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedTag)
+}
+
+// currentTag loads the tag a request refers to. It writes the error
+// response itself and returns false when that fails.
+func (c *Controller) currentTag(w http.ResponseWriter, r *http.Request, tagID int64) (dto.ResponseTagDTO, bool) {
+	tag, err := c.service.GetTag(r.Context(), tagID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return dto.ResponseTagDTO{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return dto.ResponseTagDTO{}, false
+	}
+
+	return tag, true
+}
+
+func tagETag(tag dto.ResponseTagDTO) string {
+	return etag.Format(tag.Version)
+}
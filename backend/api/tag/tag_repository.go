@@ -0,0 +1,26 @@
+package tag
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNotFound        = errors.New("tag not found")
+	ErrVersionMismatch = errors.New("tag was modified by another request")
+
+	ErrInvalidTag    = errors.New("invalid tag")
+	ErrDuplicateSlug = errors.New("a tag with this slug already exists")
+)
+
+// A non-zero Version on Update, or version on Delete, makes the write
+// conditional, as for the other repositories. A tag is part of every holiday
+// it is attached to, so Update and Delete also increment the version of
+// those holidays.
+type TagRepository interface {
+	Create(ctx context.Context, tag Tag) (Tag, error)
+	Delete(ctx context.Context, tagID int64, version int64) error
+	List(ctx context.Context) ([]Tag, error)
+	Get(ctx context.Context, tagID int64) (Tag, error)
+	Update(ctx context.Context, tag Tag) (Tag, error)
+}
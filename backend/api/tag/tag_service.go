@@ -0,0 +1,153 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/api/tag/dto"
+)
+
+// slugPattern is lower-case words of letters and digits joined by hyphens,
+// so slugs can be listed in a query string without escaping.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+const maxSlugLength = 64
+
+type Service struct {
+	repo TagRepository
+}
+
+func NewService(repo TagRepository) *Service {
+	return &Service{repo: repo}
+}
+
+func convertTagToDTO(tag Tag) dto.ResponseTagDTO {
+	return dto.ResponseTagDTO{
+		ID:      tag.ID,
+		Slug:    tag.Slug,
+		Name:    tag.Name,
+		Version: tag.Version,
+	}
+}
+
+func (s *Service) CreateTag(ctx context.Context, createTagDTO dto.CreateTagDTO) (dto.ResponseTagDTO, error) {
+	tag := Tag{Slug: createTagDTO.Slug, Name: createTagDTO.Name}
+	if err := normalizeTag(&tag); err != nil {
+		return dto.ResponseTagDTO{}, err
+	}
+
+	createdTag, err := s.repo.Create(ctx, tag)
+	if err != nil {
+		if !errors.Is(err, ErrDuplicateSlug) {
+			slog.ErrorContext(ctx, "Failed to create tag", "error", err)
+		}
+		return dto.ResponseTagDTO{}, err
+	}
+
+	return convertTagToDTO(createdTag), nil
+}
+
+func (s *Service) DeleteTag(ctx context.Context, tagID int64, version int64) error {
+	err := s.repo.Delete(ctx, tagID, version)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+		slog.ErrorContext(ctx, "Failed to delete tag", "tag_id", tagID, "error", err)
+	}
+
+	return err
+}
+
+func (s *Service) GetTags(ctx context.Context) ([]dto.ResponseTagDTO, error) {
+	tags, err := s.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query tags", "error", err)
+		return nil, err
+	}
+
+	tagDTOs := []dto.ResponseTagDTO{}
+	for _, tag := range tags {
+		tagDTOs = append(tagDTOs, convertTagToDTO(tag))
+	}
+
+	return tagDTOs, nil
+}
+
+func (s *Service) GetTag(ctx context.Context, tagID int64) (dto.ResponseTagDTO, error) {
+	tag, err := s.repo.Get(ctx, tagID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get tag", "tag_id", tagID, "error", err)
+		}
+		return dto.ResponseTagDTO{}, err
+	}
+
+	return convertTagToDTO(tag), nil
+}
+
+func (s *Service) UpdateTag(ctx context.Context, updateTagDTO dto.UpdateTagDTO) (dto.ResponseTagDTO, error) {
+	tag := Tag{
+		ID:      updateTagDTO.ID,
+		Slug:    updateTagDTO.Slug,
+		Name:    updateTagDTO.Name,
+		Version: updateTagDTO.Version,
+	}
+	if err := normalizeTag(&tag); err != nil {
+		return dto.ResponseTagDTO{}, err
+	}
+
+	updatedTag, err := s.repo.Update(ctx, tag)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) && !errors.Is(err, ErrDuplicateSlug) {
+			slog.ErrorContext(ctx, "Failed to update tag", "tag_id", updateTagDTO.ID, "error", err)
+		}
+		return dto.ResponseTagDTO{}, err
+	}
+
+	return convertTagToDTO(updatedTag), nil
+}
+
+// normalizeTag derives a missing slug from the name, e.g. "City break"
+// becomes "city-break", and checks that the tag has a valid slug and a
+// name.
+func normalizeTag(tag *Tag) error {
+	tag.Name = strings.Join(strings.Fields(tag.Name), " ")
+	tag.Slug = strings.ToLower(strings.TrimSpace(tag.Slug))
+	if tag.Slug == "" {
+		tag.Slug = Slugify(tag.Name)
+	}
+
+	if tag.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTag)
+	}
+	if !ValidSlug(tag.Slug) {
+		return fmt.Errorf("%w: slug %q must be lower-case letters and digits separated by single hyphens", ErrInvalidTag, tag.Slug)
+	}
+	return nil
+}
+
+// ValidSlug reports whether slug can name a tag.
+func ValidSlug(slug string) bool {
+	return len(slug) <= maxSlugLength && slugPattern.MatchString(slug)
+}
+
+// Slugify turns a name into a slug by lower-casing it and replacing every
+// run of other characters than ASCII letters and digits with a hyphen.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package dto
+
+import holiday "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+
+type CreateTemplateDTO struct {
+	Title         string                   `json:"title"`
+	Description   string                   `json:"description"`
+	Duration      int32                    `json:"duration"`
+	FreeSlots     int32                    `json:"freeSlots"`
+	Price         string                   `json:"price"`
+	Location      int64                    `json:"location"`
+	Included      []string                 `json:"included"`
+	Excluded      []string                 `json:"excluded"`
+	Accommodation holiday.AccommodationDTO `json:"accommodation"`
+	Status        string                   `json:"status"`
+	StartDate     string                   `json:"startDate"`
+	Recurrence    string                   `json:"recurrence"`
+	ExcludedDates []string                 `json:"excludedDates"`
+}
+
+type UpdateTemplateDTO struct {
+	ID            int64                    `json:"id"`
+	Title         string                   `json:"title"`
+	Description   string                   `json:"description"`
+	Duration      int32                    `json:"duration"`
+	FreeSlots     int32                    `json:"freeSlots"`
+	Price         string                   `json:"price"`
+	Location      int64                    `json:"location"`
+	Included      []string                 `json:"included"`
+	Excluded      []string                 `json:"excluded"`
+	Accommodation holiday.AccommodationDTO `json:"accommodation"`
+	Status        string                   `json:"status"`
+	StartDate     string                   `json:"startDate"`
+	Recurrence    string                   `json:"recurrence"`
+	ExcludedDates []string                 `json:"excludedDates"`
+	Version       int64                    `json:"-"`
+}
+
+type ResponseTemplateDTO struct {
+	ID            int64                    `json:"id"`
+	Title         string                   `json:"title"`
+	Description   string                   `json:"description"`
+	Duration      int32                    `json:"duration"`
+	FreeSlots     int32                    `json:"freeSlots"`
+	Price         string                   `json:"price"`
+	Location      int64                    `json:"location"`
+	Included      []string                 `json:"included"`
+	Excluded      []string                 `json:"excluded"`
+	Accommodation holiday.AccommodationDTO `json:"accommodation"`
+	Status        string                   `json:"status"`
+	StartDate     string                   `json:"startDate"`
+	Recurrence    string                   `json:"recurrence"`
+	ExcludedDates []string                 `json:"excludedDates"`
+	Version       int64                    `json:"version"`
+}
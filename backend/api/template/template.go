@@ -0,0 +1,48 @@
+package template
+
+import "github.com/nikolaypleshkov/uni-api/api/holiday"
+
+// Template schedules a holiday that is sold again and again, such as the
+// same week every Saturday of the summer. Each departure is a holiday of
+// its own, with its own free slots and reservations.
+type Template struct {
+	ID          int64
+	Title       string
+	Description string
+	Duration    int32
+	// FreeSlots is the number of slots each departure is put on sale with.
+	FreeSlots     int32
+	Price         string
+	LocationID    int64
+	Included      []string
+	Excluded      []string
+	Accommodation holiday.Accommodation
+	// Status is the status of the departures, Draft or Published.
+	Status string
+	// StartDate is the first day a departure can be on and Recurrence the
+	// RRULE that schedules the departures from it, such as
+	// "FREQ=WEEKLY;BYDAY=SA;UNTIL=20260926". No departure is scheduled on
+	// ExcludedDates. Dates are written as 2006-01-02.
+	StartDate     string
+	Recurrence    string
+	ExcludedDates []string
+	Version       int64
+}
+
+// Departure returns the holiday the template schedules on startDate.
+func (t Template) Departure(startDate string) holiday.Holiday {
+	return holiday.Holiday{
+		Title:         t.Title,
+		Description:   t.Description,
+		StartDate:     startDate,
+		Duration:      t.Duration,
+		FreeSlots:     t.FreeSlots,
+		Price:         t.Price,
+		LocationID:    t.LocationID,
+		Included:      t.Included,
+		Excluded:      t.Excluded,
+		Accommodation: t.Accommodation,
+		Status:        t.Status,
+		TemplateID:    t.ID,
+	}
+}
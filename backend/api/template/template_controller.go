@@ -0,0 +1,172 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nikolaypleshkov/uni-api/api/template/dto"
+	"github.com/nikolaypleshkov/uni-api/etag"
+)
+
+type Controller struct {
+	service *Service
+}
+
+func NewController(service *Service) *Controller {
+	return &Controller{service: service}
+}
+
+func (c *Controller) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var createTemplateDTO dto.CreateTemplateDTO
+	if err := json.NewDecoder(r.Body).Decode(&createTemplateDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdTemplate, err := c.service.CreateTemplate(r.Context(), createTemplateDTO)
+	if errors.Is(err, ErrInvalidTemplate) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrUnknownLocation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(createdTemplate)
+}
+
+func (c *Controller) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.ParseInt(mux.Vars(r)["templateId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	current, ok := c.currentTemplate(w, r, templateID)
+	if !ok || !etag.CheckIfMatch(w, r, templateETag(current)) {
+		return
+	}
+
+	err = c.service.DeleteTemplate(r.Context(), templateID, current.Version)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := c.service.GetTemplates(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+func (c *Controller) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.ParseInt(mux.Vars(r)["templateId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	template, ok := c.currentTemplate(w, r, templateID)
+	if !ok || etag.NotModified(w, r, templateETag(template)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+func (c *Controller) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.ParseInt(mux.Vars(r)["templateId"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	var updateTemplateDTO dto.UpdateTemplateDTO
+	if err := json.NewDecoder(r.Body).Decode(&updateTemplateDTO); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if updateTemplateDTO.ID != 0 && updateTemplateDTO.ID != templateID {
+		http.Error(w, "Template ID in body does not match the URL", http.StatusBadRequest)
+		return
+	}
+	updateTemplateDTO.ID = templateID
+
+	current, ok := c.currentTemplate(w, r, templateID)
+	if !ok || !etag.CheckIfMatch(w, r, templateETag(current)) {
+		return
+	}
+	updateTemplateDTO.Version = current.Version
+
+	updatedTemplate, err := c.service.UpdateTemplate(r.Context(), updateTemplateDTO)
+	if errors.Is(err, ErrInvalidTemplate) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, ErrUnknownLocation) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		etag.PreconditionFailed(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", templateETag(updatedTemplate))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedTemplate)
+}
+
+// currentTemplate loads the template a request refers to. It writes the
+// error response itself and returns false when that fails.
+func (c *Controller) currentTemplate(w http.ResponseWriter, r *http.Request, templateID int64) (dto.ResponseTemplateDTO, bool) {
+	template, err := c.service.GetTemplate(r.Context(), templateID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return dto.ResponseTemplateDTO{}, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return dto.ResponseTemplateDTO{}, false
+	}
+
+	return template, true
+}
+
+func templateETag(template dto.ResponseTemplateDTO) string {
+	return etag.Format(template.Version)
+}
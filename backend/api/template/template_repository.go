@@ -0,0 +1,36 @@
+package template
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNotFound        = errors.New("template not found")
+	ErrVersionMismatch = errors.New("template was modified by another request")
+
+	ErrInvalidTemplate = errors.New("invalid template")
+	ErrUnknownLocation = errors.New("template location not found")
+)
+
+// Create stores the template with a departure on each of dates. Update
+// changes the template and brings its departures after the date after in
+// line with it: they take on its content and status, their free slots move
+// by the change in FreeSlots, departures are added on new dates and those
+// on dates no longer scheduled are deleted unless they have reservations.
+// Published departures with reservations stay published, and a Duration
+// that one of the departures' itineraries does not fit in fails with
+// ErrInvalidTemplate.
+// Archived departures and those on or before after are left as they are.
+// Delete keeps the departures as holidays of their own.
+//
+// A non-zero Version on Update, or version on Delete, makes the write
+// conditional, as for the other repositories. Every write to a departure
+// increments its version.
+type TemplateRepository interface {
+	Create(ctx context.Context, template Template, dates []string) (Template, error)
+	Delete(ctx context.Context, templateID int64, version int64) error
+	List(ctx context.Context) ([]Template, error)
+	Get(ctx context.Context, templateID int64) (Template, error)
+	Update(ctx context.Context, template Template, dates []string, after string) (Template, error)
+}
@@ -0,0 +1,260 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	"github.com/nikolaypleshkov/uni-api/api/template/dto"
+	"github.com/nikolaypleshkov/uni-api/recurrence"
+)
+
+// maxDepartures bounds the departures a template schedules, about a year
+// of daily departures.
+const maxDepartures = 366
+
+type Service struct {
+	repo TemplateRepository
+	// now tells which departures are in the future.
+	now func() time.Time
+}
+
+func NewService(repo TemplateRepository) *Service {
+	return &Service{repo: repo, now: time.Now}
+}
+
+func convertTemplateToDTO(template Template) dto.ResponseTemplateDTO {
+	return dto.ResponseTemplateDTO{
+		ID:            template.ID,
+		Title:         template.Title,
+		Description:   template.Description,
+		Duration:      template.Duration,
+		FreeSlots:     template.FreeSlots,
+		Price:         template.Price,
+		Location:      template.LocationID,
+		Included:      nonNil(template.Included),
+		Excluded:      nonNil(template.Excluded),
+		Accommodation: holidaydto.AccommodationDTO(template.Accommodation),
+		Status:        template.Status,
+		StartDate:     template.StartDate,
+		Recurrence:    template.Recurrence,
+		ExcludedDates: nonNil(template.ExcludedDates),
+		Version:       template.Version,
+	}
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, createTemplateDTO dto.CreateTemplateDTO) (dto.ResponseTemplateDTO, error) {
+	template := Template{
+		Title:         createTemplateDTO.Title,
+		Description:   createTemplateDTO.Description,
+		Duration:      createTemplateDTO.Duration,
+		FreeSlots:     createTemplateDTO.FreeSlots,
+		Price:         createTemplateDTO.Price,
+		LocationID:    createTemplateDTO.Location,
+		Included:      createTemplateDTO.Included,
+		Excluded:      createTemplateDTO.Excluded,
+		Accommodation: holiday.Accommodation(createTemplateDTO.Accommodation),
+		Status:        createTemplateDTO.Status,
+		StartDate:     createTemplateDTO.StartDate,
+		Recurrence:    createTemplateDTO.Recurrence,
+		ExcludedDates: createTemplateDTO.ExcludedDates,
+	}
+	if err := normalizeTemplate(&template); err != nil {
+		return dto.ResponseTemplateDTO{}, err
+	}
+	today := s.today()
+	dates, err := departureDates(template, today)
+	if err != nil {
+		return dto.ResponseTemplateDTO{}, err
+	}
+
+	createdTemplate, err := s.repo.Create(ctx, template, dates)
+	if err != nil {
+		if !errors.Is(err, ErrUnknownLocation) {
+			slog.ErrorContext(ctx, "Failed to create template", "error", err)
+		}
+		return dto.ResponseTemplateDTO{}, err
+	}
+
+	return convertTemplateToDTO(createdTemplate), nil
+}
+
+func (s *Service) DeleteTemplate(ctx context.Context, templateID int64, version int64) error {
+	err := s.repo.Delete(ctx, templateID, version)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) {
+		slog.ErrorContext(ctx, "Failed to delete template", "template_id", templateID, "error", err)
+	}
+
+	return err
+}
+
+func (s *Service) GetTemplates(ctx context.Context) ([]dto.ResponseTemplateDTO, error) {
+	templates, err := s.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to query templates", "error", err)
+		return nil, err
+	}
+
+	templateDTOs := []dto.ResponseTemplateDTO{}
+	for _, template := range templates {
+		templateDTOs = append(templateDTOs, convertTemplateToDTO(template))
+	}
+
+	return templateDTOs, nil
+}
+
+func (s *Service) GetTemplate(ctx context.Context, templateID int64) (dto.ResponseTemplateDTO, error) {
+	template, err := s.repo.Get(ctx, templateID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			slog.ErrorContext(ctx, "Failed to get template", "template_id", templateID, "error", err)
+		}
+		return dto.ResponseTemplateDTO{}, err
+	}
+
+	return convertTemplateToDTO(template), nil
+}
+
+// UpdateTemplate changes the template and its future departures, those
+// that start after today.
+func (s *Service) UpdateTemplate(ctx context.Context, updateTemplateDTO dto.UpdateTemplateDTO) (dto.ResponseTemplateDTO, error) {
+	template := Template{
+		ID:            updateTemplateDTO.ID,
+		Title:         updateTemplateDTO.Title,
+		Description:   updateTemplateDTO.Description,
+		Duration:      updateTemplateDTO.Duration,
+		FreeSlots:     updateTemplateDTO.FreeSlots,
+		Price:         updateTemplateDTO.Price,
+		LocationID:    updateTemplateDTO.Location,
+		Included:      updateTemplateDTO.Included,
+		Excluded:      updateTemplateDTO.Excluded,
+		Accommodation: holiday.Accommodation(updateTemplateDTO.Accommodation),
+		Status:        updateTemplateDTO.Status,
+		StartDate:     updateTemplateDTO.StartDate,
+		Recurrence:    updateTemplateDTO.Recurrence,
+		ExcludedDates: updateTemplateDTO.ExcludedDates,
+		Version:       updateTemplateDTO.Version,
+	}
+	if err := normalizeTemplate(&template); err != nil {
+		return dto.ResponseTemplateDTO{}, err
+	}
+	today := s.today()
+	dates, err := departureDates(template, today)
+	if err != nil {
+		return dto.ResponseTemplateDTO{}, err
+	}
+
+	updatedTemplate, err := s.repo.Update(ctx, template, dates, today)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMismatch) && !errors.Is(err, ErrUnknownLocation) && !errors.Is(err, ErrInvalidTemplate) {
+			slog.ErrorContext(ctx, "Failed to update template", "template_id", updateTemplateDTO.ID, "error", err)
+		}
+		return dto.ResponseTemplateDTO{}, err
+	}
+
+	return convertTemplateToDTO(updatedTemplate), nil
+}
+
+func (s *Service) today() string {
+	return s.now().UTC().Format(time.DateOnly)
+}
+
+// normalizeTemplate checks the template and its departures' content the way
+// holidays are checked, and writes the recurrence rule and excluded dates
+// in a canonical form. A template without a status schedules drafts.
+func normalizeTemplate(template *Template) error {
+	template.Title = strings.TrimSpace(template.Title)
+	if template.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidTemplate)
+	}
+	if template.Duration < 1 {
+		return fmt.Errorf("%w: duration must be at least 1 day", ErrInvalidTemplate)
+	}
+	if template.FreeSlots < 0 {
+		return fmt.Errorf("%w: freeSlots cannot be negative", ErrInvalidTemplate)
+	}
+	template.Price = strings.TrimSpace(template.Price)
+	if price, err := strconv.ParseFloat(template.Price, 64); err != nil || price < 0 {
+		return fmt.Errorf("%w: price %q is not an amount", ErrInvalidTemplate, template.Price)
+	}
+
+	departure := template.Departure("")
+	if err := holiday.NormalizeContent(&departure); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+	template.Description = departure.Description
+	template.Included, template.Excluded = departure.Included, departure.Excluded
+	template.Accommodation = departure.Accommodation
+
+	template.Status = strings.ToLower(strings.TrimSpace(template.Status))
+	if template.Status == "" {
+		template.Status = holiday.Draft
+	}
+	if template.Status != holiday.Draft && template.Status != holiday.Published {
+		return fmt.Errorf("%w: status must be %s or %s", ErrInvalidTemplate, holiday.Draft, holiday.Published)
+	}
+
+	if _, err := time.Parse(time.DateOnly, template.StartDate); err != nil {
+		return fmt.Errorf("%w: startDate %q is not a date such as 2026-06-06", ErrInvalidTemplate, template.StartDate)
+	}
+	rule, err := recurrence.Parse(template.Recurrence)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+	template.Recurrence = rule.String()
+
+	excluded := []string{}
+	for _, date := range template.ExcludedDates {
+		date = strings.TrimSpace(date)
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("%w: excluded date %q is not a date such as 2026-08-15", ErrInvalidTemplate, date)
+		}
+		if !slices.Contains(excluded, date) {
+			excluded = append(excluded, date)
+		}
+	}
+	slices.Sort(excluded)
+	template.ExcludedDates = excluded
+
+	return nil
+}
+
+// departureDates returns the dates after `after` on which a normalized
+// template schedules departures, minus its excluded dates.
+func departureDates(template Template, after string) ([]string, error) {
+	rule, err := recurrence.Parse(template.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+	start, err := time.Parse(time.DateOnly, template.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+	occurrences, err := rule.Dates(start, maxDepartures)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	dates := []string{}
+	for _, occurrence := range occurrences {
+		date := occurrence.Format(time.DateOnly)
+		if date > after && !slices.Contains(template.ExcludedDates, date) {
+			dates = append(dates, date)
+		}
+	}
+	return dates, nil
+}
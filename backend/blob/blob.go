@@ -0,0 +1,140 @@
+// Package blob stores uploaded files, such as location and holiday images,
+// outside the database. Files are addressed by keys chosen by the caller
+// and never change once written.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore keeps files by key. Keys are slash-separated relative paths
+// without "." or ".." elements.
+type BlobStore interface {
+	// Put stores the contents of data under key, replacing any existing
+	// file. Readers never see a partially written file.
+	Put(ctx context.Context, key string, data io.Reader) error
+	// Open returns the file stored under key, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the file stored under key. Deleting a missing key is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStore keeps files in a directory on the local filesystem.
+type LocalStore struct {
+	root *os.Root
+	temp atomic.Uint64
+}
+
+// NewLocalStore returns a store that keeps files under dir, creating the
+// directory if needed.
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// Close releases the directory. The store must not be used afterwards.
+func (s *LocalStore) Close() error {
+	return s.root.Close()
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, data io.Reader) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	if dir := path.Dir(key); dir != "." {
+		if err := s.root.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	// The file is written under a temporary name and renamed into place,
+	// so Open sees either the old file or the complete new one.
+	temp := key + ".tmp-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatUint(s.temp.Add(1), 36)
+	file, err := s.root.OpenFile(temp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, contextReader{ctx: ctx, r: data})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.root.Rename(temp, key)
+	}
+	if err != nil {
+		s.root.Remove(temp)
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	if !validKey(key) {
+		return nil, ErrNotFound
+	}
+	file, err := s.root.Open(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = ErrNotFound
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	err := s.root.Remove(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func validKey(key string) bool {
+	return fs.ValidPath(key) && key != "."
+}
+
+// contextReader stops a copy once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
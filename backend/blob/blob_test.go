@@ -0,0 +1,78 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := t.Context()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	read := func(key string) string {
+		t.Helper()
+		file, err := store.Open(ctx, key)
+		if err != nil {
+			t.Fatalf("Open(%q): %v", key, err)
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	for _, key := range []string{"a.jpg", "nested/dir/b.png"} {
+		if err := store.Put(ctx, key, strings.NewReader("first")); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+		if got := read(key); got != "first" {
+			t.Errorf("%s = %q, want first", key, got)
+		}
+	}
+
+	if err := store.Put(ctx, "a.jpg", strings.NewReader("second")); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("a.jpg"); got != "second" {
+		t.Errorf("after overwrite a.jpg = %q", got)
+	}
+
+	if err := store.Delete(ctx, "a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Open(ctx, "a.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "a.jpg"); err != nil {
+		t.Errorf("deleting a missing key: %v", err)
+	}
+	if _, err := store.Open(ctx, "nested/dir"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open(directory): err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStoreRejectsKeysOutsideTheDirectory(t *testing.T) {
+	ctx := t.Context()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for _, key := range []string{"", ".", "../escape.jpg", "/etc/passwd", "a/../../b", "a//b"} {
+		if err := store.Put(ctx, key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): err = %v, want ErrInvalidKey", key, err)
+		}
+		if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q): err = %v, want ErrNotFound", key, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// RetryOptions control how Open waits for the database. A MaxAttempts below
+// 1 still pings once.
+type RetryOptions struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+type Options struct {
+	Pool  PoolOptions
+	Retry RetryOptions
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Pool: PoolOptions{
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Retry: RetryOptions{
+			MaxAttempts:    10,
+			InitialBackoff: 500 * time.Millisecond,
+			MaxBackoff:     15 * time.Second,
+		},
+	}
+}
+
+// Open opens the database, applies the pool settings and blocks until the
+// server answers a ping, retrying with exponential backoff. The driver is
+// wrapped so every query made with a traced context produces a span.
+func Open(ctx context.Context, dialect Dialect, dsn string, opts Options) (*sql.DB, error) {
+	db, err := otelsql.Open(
+		dialect.Driver,
+		dsn,
+		otelsql.WithAttributes(attribute.String("db.system.name", dialect.Name)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(opts.Pool.MaxOpenConns)
+	db.SetMaxIdleConns(opts.Pool.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.Pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.Pool.ConnMaxIdleTime)
+
+	if err := waitForDB(ctx, db, opts.Retry); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func waitForDB(ctx context.Context, db *sql.DB, opts RetryOptions) error {
+	backoff := opts.InitialBackoff
+	maxAttempts := max(opts.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		slog.WarnContext(ctx, "Database not ready, retrying",
+			"attempt", attempt,
+			"max_attempts", maxAttempts,
+			"backoff", backoff.String(),
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+
+	return fmt.Errorf("database not reachable after %d attempts: %v", maxAttempts, err)
+}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestOpenWithoutAttempts checks that a retry budget below one still pings
+// the database once instead of failing without trying.
+func TestOpenWithoutAttempts(t *testing.T) {
+	for _, attempts := range []int{0, -1} {
+		opts := DefaultOptions()
+		opts.Retry.MaxAttempts = attempts
+
+		db, err := Open(context.Background(), SQLite, sqliteDSN(filepath.Join(t.TempDir(), "travel.db")), opts)
+		if err != nil {
+			t.Fatalf("MaxAttempts %d: %v", attempts, err)
+		}
+		db.Close()
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// Dialect captures the differences between the SQL databases the API can
+// run on. Queries are written with "?" placeholders and PostgreSQL DDL and
+// are adapted with Rebind and DDL.
+type Dialect struct {
+	Name   string
+	Driver string
+}
+
+var (
+	Postgres = Dialect{Name: "postgres", Driver: "postgres"}
+	SQLite   = Dialect{Name: "sqlite", Driver: "sqlite"}
+)
+
+// ParseURL maps a database URL to its dialect and the DSN understood by the
+// driver. Supported forms are postgres://... (or postgresql://...) and
+// sqlite:///absolute/path.db or sqlite://relative/path.db.
+func ParseURL(rawURL string) (Dialect, string, error) {
+	switch {
+	case strings.HasPrefix(rawURL, "postgres://"), strings.HasPrefix(rawURL, "postgresql://"):
+		return Postgres, rawURL, nil
+	case strings.HasPrefix(rawURL, "sqlite://"):
+		path := strings.TrimPrefix(rawURL, "sqlite://")
+		if path == "" {
+			return Dialect{}, "", fmt.Errorf("sqlite URL %q has no file path", rawURL)
+		}
+		return SQLite, sqliteDSN(path), nil
+	default:
+		return Dialect{}, "", fmt.Errorf("unsupported database URL %q", rawURL)
+	}
+}
+
+func sqliteDSN(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Set("_txlock", "immediate")
+	return "file:" + path + "?" + params.Encode()
+}
+
+// Rebind rewrites "?" placeholders into the dialect's bind syntax.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// DDL adapts PostgreSQL schema statements to the dialect.
+func (d Dialect) DDL(statement string) string {
+	if d != SQLite {
+		return statement
+	}
+	return strings.ReplaceAll(statement, "SERIAL PRIMARY KEY", "INTEGER PRIMARY KEY AUTOINCREMENT")
+}
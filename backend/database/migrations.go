@@ -0,0 +1,417 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/iso3166"
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	// PostgresOnly migrations are recorded, but not run, on other dialects.
+	PostgresOnly bool
+}
+
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_locations",
+		Up: `
+			CREATE TABLE IF NOT EXISTS locations (
+				id SERIAL PRIMARY KEY,
+				number VARCHAR(255),
+				country VARCHAR(255),
+				city VARCHAR(255),
+				street VARCHAR(255),
+				image_url VARCHAR(255)
+			);
+		`,
+	},
+	{
+		Version: 2,
+		Name:    "create_holidays",
+		Up: `
+			CREATE TABLE IF NOT EXISTS holidays (
+				id SERIAL PRIMARY KEY,
+				title VARCHAR(255),
+				start_date DATE,
+				duration INT,
+				free_slots INT,
+				price DECIMAL(10,2),
+				location_id INT,
+				FOREIGN KEY (location_id) REFERENCES locations(id)
+			);
+		`,
+	},
+	{
+		Version: 3,
+		Name:    "create_reservations",
+		Up: `
+			CREATE TABLE IF NOT EXISTS reservations (
+				id SERIAL PRIMARY KEY,
+				phone_number VARCHAR(255),
+				contact_name VARCHAR(255),
+				holiday_id INT
+			);
+		`,
+	},
+	{
+		Version: 4,
+		Name:    "add_row_versions",
+		Up: `
+			ALTER TABLE locations ADD COLUMN version INT NOT NULL DEFAULT 1;
+			ALTER TABLE holidays ADD COLUMN version INT NOT NULL DEFAULT 1;
+			ALTER TABLE reservations ADD COLUMN version INT NOT NULL DEFAULT 1;
+		`,
+	},
+	{
+		Version: 5,
+		Name:    "create_idempotency_keys",
+		Up: `
+			CREATE TABLE IF NOT EXISTS idempotency_keys (
+				idempotency_key VARCHAR(255) PRIMARY KEY,
+				fingerprint VARCHAR(64) NOT NULL,
+				created_at BIGINT NOT NULL,
+				status INT NOT NULL DEFAULT 0,
+				content_type VARCHAR(255) NOT NULL DEFAULT '',
+				body TEXT NOT NULL DEFAULT ''
+			);
+			CREATE INDEX IF NOT EXISTS idempotency_keys_created_at ON idempotency_keys (created_at);
+		`,
+	},
+	{
+		Version: 6,
+		Name:    "add_location_coordinates",
+		Up: `
+			ALTER TABLE locations ADD COLUMN latitude DOUBLE PRECISION;
+			ALTER TABLE locations ADD COLUMN longitude DOUBLE PRECISION;
+		`,
+	},
+	{
+		Version: 7,
+		Name:    "add_location_country_codes",
+		Up: `
+			ALTER TABLE locations ADD COLUMN country_code VARCHAR(2) NOT NULL DEFAULT '';
+			CREATE INDEX IF NOT EXISTS locations_country_code ON locations (country_code);
+		` + countryCodeBackfill(),
+	},
+	{
+		Version: 8,
+		Name:    "create_images",
+		Up: `
+			CREATE TABLE IF NOT EXISTS images (
+				id SERIAL PRIMARY KEY,
+				owner_type VARCHAR(16) NOT NULL,
+				owner_id INT NOT NULL,
+				sort_order INT NOT NULL,
+				blob_key VARCHAR(255) NOT NULL,
+				thumbnail_key VARCHAR(255) NOT NULL,
+				content_type VARCHAR(255) NOT NULL,
+				width INT NOT NULL,
+				height INT NOT NULL,
+				byte_size BIGINT NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS images_owner ON images (owner_type, owner_id, sort_order);
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "create_holiday_stops",
+		Up: `
+			CREATE TABLE IF NOT EXISTS holiday_stops (
+				holiday_id INT NOT NULL REFERENCES holidays(id) ON DELETE CASCADE,
+				sort_order INT NOT NULL,
+				location_id INT NOT NULL REFERENCES locations(id),
+				day_offset INT NOT NULL,
+				nights INT NOT NULL,
+				PRIMARY KEY (holiday_id, sort_order)
+			);
+			CREATE INDEX IF NOT EXISTS holiday_stops_location_id ON holiday_stops (location_id);
+		`,
+	},
+	{
+		Version: 10,
+		Name:    "create_tags",
+		Up: `
+			CREATE TABLE IF NOT EXISTS tags (
+				id SERIAL PRIMARY KEY,
+				slug VARCHAR(64) NOT NULL UNIQUE,
+				name VARCHAR(255) NOT NULL,
+				version INT NOT NULL DEFAULT 1
+			);
+			CREATE TABLE IF NOT EXISTS holiday_tags (
+				holiday_id INT NOT NULL REFERENCES holidays(id) ON DELETE CASCADE,
+				tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+				PRIMARY KEY (holiday_id, tag_id)
+			);
+			CREATE INDEX IF NOT EXISTS holiday_tags_tag_id ON holiday_tags (tag_id);
+		`,
+	},
+	{
+		Version: 11,
+		Name:    "add_holiday_content_and_translations",
+		Up: `
+			ALTER TABLE holidays ADD COLUMN description TEXT NOT NULL DEFAULT '';
+			ALTER TABLE holidays ADD COLUMN included VARCHAR(255) NOT NULL DEFAULT '';
+			ALTER TABLE holidays ADD COLUMN excluded VARCHAR(255) NOT NULL DEFAULT '';
+			ALTER TABLE holidays ADD COLUMN accommodation_name VARCHAR(255) NOT NULL DEFAULT '';
+			ALTER TABLE holidays ADD COLUMN accommodation_type VARCHAR(32) NOT NULL DEFAULT '';
+			ALTER TABLE holidays ADD COLUMN accommodation_stars INT NOT NULL DEFAULT 0;
+			ALTER TABLE holidays ADD COLUMN accommodation_description TEXT NOT NULL DEFAULT '';
+			CREATE TABLE IF NOT EXISTS holiday_translations (
+				holiday_id INT NOT NULL REFERENCES holidays(id) ON DELETE CASCADE,
+				language VARCHAR(35) NOT NULL,
+				title VARCHAR(255) NOT NULL DEFAULT '',
+				description TEXT NOT NULL DEFAULT '',
+				accommodation_description TEXT NOT NULL DEFAULT '',
+				PRIMARY KEY (holiday_id, language)
+			);
+			CREATE TABLE IF NOT EXISTS location_translations (
+				location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+				language VARCHAR(35) NOT NULL,
+				city VARCHAR(255) NOT NULL DEFAULT '',
+				street VARCHAR(255) NOT NULL DEFAULT '',
+				PRIMARY KEY (location_id, language)
+			);
+		`,
+	},
+	{
+		// Existing holidays were already on sale, so they start out
+		// published, as do new ones created without a status. The
+		// schedule is stored as Unix milliseconds.
+		Version: 12,
+		Name:    "add_holiday_status",
+		Up: `
+			ALTER TABLE holidays ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published';
+			ALTER TABLE holidays ADD COLUMN publish_at BIGINT;
+			ALTER TABLE holidays ADD COLUMN unpublish_at BIGINT;
+			CREATE INDEX IF NOT EXISTS holidays_status ON holidays (status, start_date);
+		`,
+	},
+	{
+		// Templates keep their dates as written, since they are parsed
+		// rather than compared. Deleting a template keeps its departures.
+		Version: 13,
+		Name:    "create_holiday_templates",
+		Up: `
+			CREATE TABLE IF NOT EXISTS holiday_templates (
+				id SERIAL PRIMARY KEY,
+				title VARCHAR(255) NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				duration INT NOT NULL,
+				free_slots INT NOT NULL,
+				price DECIMAL(10,2) NOT NULL,
+				location_id INT REFERENCES locations(id),
+				included VARCHAR(255) NOT NULL DEFAULT '',
+				excluded VARCHAR(255) NOT NULL DEFAULT '',
+				accommodation_name VARCHAR(255) NOT NULL DEFAULT '',
+				accommodation_type VARCHAR(32) NOT NULL DEFAULT '',
+				accommodation_stars INT NOT NULL DEFAULT 0,
+				accommodation_description TEXT NOT NULL DEFAULT '',
+				status VARCHAR(16) NOT NULL,
+				start_date VARCHAR(10) NOT NULL,
+				recurrence VARCHAR(255) NOT NULL,
+				excluded_dates TEXT NOT NULL DEFAULT '',
+				version INT NOT NULL DEFAULT 1
+			);
+			ALTER TABLE holidays ADD COLUMN template_id INT REFERENCES holiday_templates(id) ON DELETE SET NULL;
+			CREATE INDEX IF NOT EXISTS holidays_template_id ON holidays (template_id, start_date);
+		`,
+	},
+	{
+		// The search documents of holidays and locations, weighted as in
+		// search.HolidayDocument and search.LocationDocument. A holiday's
+		// document includes its location and tags, so triggers on those
+		// tables refresh it by setting it to NULL, which recomputes it.
+		// SQLite searches an in-memory index instead.
+		Version:      14,
+		Name:         "add_search_documents",
+		PostgresOnly: true,
+		Up: `
+			ALTER TABLE locations ADD COLUMN search_document tsvector GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', COALESCE(city, '')), 'A') ||
+				setweight(to_tsvector('simple', COALESCE(country, '')), 'B') ||
+				setweight(to_tsvector('simple', COALESCE(street, '')), 'C')
+			) STORED;
+			ALTER TABLE holidays ADD COLUMN search_document tsvector;
+
+			CREATE OR REPLACE FUNCTION holiday_search_document() RETURNS trigger AS $$
+			BEGIN
+				SELECT
+					setweight(to_tsvector('simple', COALESCE(NEW.title, '')), 'A') ||
+					setweight(to_tsvector('simple', concat_ws(' ', l.city, l.country, (
+						SELECT string_agg(tg.name, ' ')
+						FROM holiday_tags ht
+						JOIN tags tg ON tg.id = ht.tag_id
+						WHERE ht.holiday_id = NEW.id
+					))), 'B') ||
+					setweight(to_tsvector('simple', concat_ws(' ', l.street, to_char(NEW.start_date, 'FMMonth YYYY'))), 'C') ||
+					setweight(to_tsvector('simple', COALESCE(NEW.description, '')), 'D')
+				INTO NEW.search_document
+				FROM (SELECT 1) AS one
+				LEFT JOIN locations l ON l.id = NEW.location_id;
+				RETURN NEW;
+			END
+			$$ LANGUAGE plpgsql;
+
+			CREATE OR REPLACE FUNCTION refresh_holiday_search_documents() RETURNS trigger AS $$
+			BEGIN
+				IF TG_TABLE_NAME = 'locations' THEN
+					UPDATE holidays SET search_document = NULL WHERE location_id = NEW.id;
+				ELSIF TG_TABLE_NAME = 'tags' THEN
+					UPDATE holidays SET search_document = NULL
+					WHERE id IN (SELECT holiday_id FROM holiday_tags WHERE tag_id = NEW.id);
+				ELSIF TG_OP = 'DELETE' THEN
+					UPDATE holidays SET search_document = NULL WHERE id = OLD.holiday_id;
+				ELSE
+					UPDATE holidays SET search_document = NULL WHERE id = NEW.holiday_id;
+				END IF;
+				RETURN NULL;
+			END
+			$$ LANGUAGE plpgsql;
+
+			CREATE TRIGGER holidays_search_document
+				BEFORE INSERT OR UPDATE OF title, description, start_date, location_id, search_document ON holidays
+				FOR EACH ROW EXECUTE FUNCTION holiday_search_document();
+			CREATE TRIGGER locations_search_document
+				AFTER UPDATE OF city, country, street ON locations
+				FOR EACH ROW EXECUTE FUNCTION refresh_holiday_search_documents();
+			CREATE TRIGGER tags_search_document
+				AFTER UPDATE OF name ON tags
+				FOR EACH ROW EXECUTE FUNCTION refresh_holiday_search_documents();
+			CREATE TRIGGER holiday_tags_search_document
+				AFTER INSERT OR DELETE ON holiday_tags
+				FOR EACH ROW EXECUTE FUNCTION refresh_holiday_search_documents();
+
+			UPDATE holidays SET search_document = NULL;
+			CREATE INDEX IF NOT EXISTS holidays_search_document ON holidays USING GIN (search_document);
+			CREATE INDEX IF NOT EXISTS locations_search_document ON locations USING GIN (search_document);
+		`,
+	},
+}
+
+// countryCodeBackfill sets the code of existing locations whose country is
+// written as a known code, name or alias, and renames the country to its
+// canonical name. Unrecognised countries are left as they are.
+func countryCodeBackfill() string {
+	var codes, names strings.Builder
+	for _, country := range iso3166.All() {
+		code := sqlString(country.Alpha2)
+		for _, key := range append([]string{country.Alpha2, country.Alpha3, country.Name}, country.Aliases...) {
+			fmt.Fprintf(&codes, " WHEN %s THEN %s", sqlString(strings.ToLower(key)), code)
+		}
+		fmt.Fprintf(&names, " WHEN %s THEN %s", code, sqlString(country.Name))
+	}
+
+	return fmt.Sprintf(`
+		UPDATE locations SET country_code = CASE LOWER(TRIM(country))%s ELSE '' END;
+		UPDATE locations SET country = CASE country_code%s END WHERE country_code <> '';
+	`, codes.String(), names.String())
+}
+
+func sqlString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(255),
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+	_, err := db.ExecContext(ctx, query)
+	return err
+}
+
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// PendingMigrations returns the migrations known to this build that have
+// not been applied to the database yet. It never writes, so it is safe to
+// call from probes; an unmigrated database yields an error.
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Migrate applies every pending migration, each in its own transaction.
+func Migrate(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return err
+	}
+
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range pending {
+		if err := apply(ctx, db, dialect, migration); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %v", migration.Version, migration.Name, err)
+		}
+		slog.InfoContext(ctx, "Applied migration", "version", migration.Version, "name", migration.Name)
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, dialect Dialect, migration Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !migration.PostgresOnly || dialect == Postgres {
+		if _, err := tx.ExecContext(ctx, dialect.DDL(migration.Up)); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		dialect.Rebind("INSERT INTO schema_migrations (version, name) VALUES (?, ?)"),
+		migration.Version,
+		migration.Name,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
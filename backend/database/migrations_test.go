@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestCountryCodeBackfill migrates a database holding free-text countries
+// and checks that known ones are normalised.
+func TestCountryCodeBackfill(t *testing.T) {
+	ctx := context.Background()
+	opts := DefaultOptions()
+	opts.Retry.MaxAttempts = 1
+
+	db, err := Open(ctx, SQLite, sqliteDSN(filepath.Join(t.TempDir(), "travel.db")), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	for _, migration := range migrations {
+		if migration.Name == "add_location_country_codes" {
+			break
+		}
+		if err := apply(ctx, db, SQLite, migration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	written := map[string]struct{ country, code string }{
+		"bulgaria":      {"Bulgaria", "BG"},
+		" BG ":          {"Bulgaria", "BG"},
+		"bgr":           {"Bulgaria", "BG"},
+		"Cote d'Ivoire": {"Côte d'Ivoire", "CI"},
+		"Narnia":        {"Narnia", ""},
+		"":              {"", ""},
+	}
+	for country := range written {
+		if _, err := db.ExecContext(ctx, "INSERT INTO locations (country, city) VALUES (?, ?)", country, country); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Migrate(ctx, db, SQLite); err != nil {
+		t.Fatal(err)
+	}
+
+	for original, want := range written {
+		var country, code string
+		err := db.QueryRowContext(ctx, "SELECT country, country_code FROM locations WHERE city = ?", original).Scan(&country, &code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if country != want.country || code != want.code {
+			t.Errorf("%q migrated to %q/%q, want %q/%q", original, country, code, want.country, want.code)
+		}
+	}
+}
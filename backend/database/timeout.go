@@ -0,0 +1,20 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+var queryTimeout = 5 * time.Second
+
+func SetQueryTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		queryTimeout = timeout
+	}
+}
+
+// WithQueryTimeout bounds a database call by the configured query timeout
+// while still honouring cancellation of the parent (request) context.
+func WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, queryTimeout)
+}
@@ -0,0 +1,118 @@
+// Package etag derives entity tags from row versions and evaluates the
+// If-Match and If-None-Match preconditions of RFC 9110.
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/locale"
+)
+
+// Format builds a strong entity tag from the versions of every row that
+// makes up a representation, e.g. a holiday and its embedded location.
+func Format(versions ...int64) string {
+	parts := make([]string, len(versions))
+	for i, version := range versions {
+		parts[i] = strconv.FormatInt(version, 10)
+	}
+	return `"` + strings.Join(parts, ".") + `"`
+}
+
+// Digest builds a strong entity tag from the bytes of a representation,
+// for responses computed from too many rows to list their versions.
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Localized adds the languages of a translated representation to tag, so
+// that each translation has its own. The untranslated representation, in
+// locale.Default only, keeps tag as it is.
+func Localized(tag string, languages []string) string {
+	if len(languages) == 0 || slices.Equal(languages, []string{locale.Default}) {
+		return tag
+	}
+	return strings.TrimSuffix(tag, `"`) + ";" + strings.Join(languages, ";") + `"`
+}
+
+// unlocalized removes the languages Localized added to tag.
+func unlocalized(tag string) string {
+	if i := strings.Index(tag, ";"); i >= 0 {
+		return tag[:i] + `"`
+	}
+	return tag
+}
+
+// MatchesStrong reports whether an If-Match header lists tag. Weak tags
+// never match, as RFC 9110 requires strong comparison for If-Match. The
+// languages of localized tags are ignored: they select a translation, not
+// a state of the resource, so a write may follow a read in any language.
+func MatchesStrong(header, tag string) bool {
+	return matches(header, tag, false)
+}
+
+// MatchesWeak reports whether an If-None-Match header lists tag, ignoring
+// the weakness indicator.
+func MatchesWeak(header, tag string) bool {
+	return matches(header, tag, true)
+}
+
+func matches(header, tag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		} else if !weak {
+			candidate, tag = unlocalized(candidate), unlocalized(tag)
+		}
+		if candidate == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModified sets the ETag header and, when the request's If-None-Match
+// lists tag, answers 304 and returns true.
+func NotModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+
+	if header := r.Header.Get("If-None-Match"); header != "" && MatchesWeak(header, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// CheckIfMatch enforces the If-Match precondition of a state-changing
+// request against the current tag. It answers 428 when the header is
+// missing and 412 when it does not match, and returns false in both cases.
+func CheckIfMatch(w http.ResponseWriter, r *http.Request, tag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return false
+	}
+	if !MatchesStrong(header, tag) {
+		PreconditionFailed(w)
+		return false
+	}
+	return true
+}
+
+// PreconditionFailed answers 412 for an If-Match that no longer holds,
+// including when a concurrent write wins the race after CheckIfMatch.
+func PreconditionFailed(w http.ResponseWriter) {
+	http.Error(w, "Resource was modified, fetch it again and retry", http.StatusPreconditionFailed)
+}
@@ -0,0 +1,56 @@
+package etag
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	if got := Format(3); got != `"3"` {
+		t.Errorf("Format(3) = %s", got)
+	}
+	if got := Format(3, 12); got != `"3.12"` {
+		t.Errorf("Format(3, 12) = %s", got)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	tag := Digest([]byte(`{"days":[]}`))
+	if len(tag) != 34 || tag[0] != '"' || tag[33] != '"' {
+		t.Errorf("Digest = %s, want 32 quoted hex digits", tag)
+	}
+	if Digest([]byte(`{"days":[]}`)) != tag || Digest([]byte(`{"days":null}`)) == tag {
+		t.Error("Digest does not follow the content")
+	}
+}
+
+func TestLocalized(t *testing.T) {
+	if got := Localized(`"3.1"`, []string{"en"}); got != `"3.1"` {
+		t.Errorf("Localized in the default language = %s", got)
+	}
+	if got := Localized(`"3.1"`, []string{"de-at", "bg"}); got != `"3.1;de-at;bg"` {
+		t.Errorf("Localized(de-at, bg) = %s", got)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		header      string
+		strong      bool
+		weak        bool
+		description string
+	}{
+		{`"3"`, true, true, "same tag"},
+		{`"4"`, false, false, "different tag"},
+		{`"1", "3"`, true, true, "tag in a list"},
+		{`*`, true, true, "wildcard"},
+		{`W/"3"`, false, true, "weak tag"},
+		{`"3.1"`, false, false, "tag with another location version"},
+		{`"3;bg"`, true, false, "tag of a translation"},
+	}
+	for _, tt := range tests {
+		if got := MatchesStrong(tt.header, `"3"`); got != tt.strong {
+			t.Errorf("%s: MatchesStrong(%s) = %v", tt.description, tt.header, got)
+		}
+		if got := MatchesWeak(tt.header, `"3"`); got != tt.weak {
+			t.Errorf("%s: MatchesWeak(%s) = %v", tt.description, tt.header, got)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package fulltext is a small inverted index for searching short documents
+// by word prefixes. It splits text into words and weighs them the way
+// PostgreSQL's "simple" text search configuration does, so that results
+// agree with the database when it is not available.
+package fulltext
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Weight ranks the field a word appears in, from A (most important) to D.
+type Weight int
+
+const (
+	D Weight = iota
+	C
+	B
+	A
+)
+
+// rankWeights are PostgreSQL's default ts_rank weights for D, C, B and A.
+var rankWeights = [...]float64{0.1, 0.2, 0.4, 1.0}
+
+type Field struct {
+	Text   string
+	Weight Weight
+}
+
+type Document struct {
+	Fields []Field
+}
+
+type posting struct {
+	doc   int
+	score float64
+}
+
+// Index finds documents by the words in their fields. Documents are
+// numbered in the order they are added. Once built, an Index may be searched
+// concurrently.
+type Index struct {
+	postings map[string][]posting
+	// words holds the keys of postings in order, for prefix lookups.
+	words []string
+	next  int
+}
+
+func NewIndex() *Index {
+	return &Index{postings: make(map[string][]posting)}
+}
+
+// Add indexes doc under the next document number.
+func (ix *Index) Add(doc Document) {
+	number := ix.next
+	ix.next++
+
+	scores := make(map[string]float64)
+	for _, field := range doc.Fields {
+		for _, word := range Tokenize(field.Text) {
+			scores[word] += rankWeights[field.Weight]
+		}
+	}
+	for word, score := range scores {
+		if _, ok := ix.postings[word]; !ok {
+			i := sort.SearchStrings(ix.words, word)
+			ix.words = append(ix.words, "")
+			copy(ix.words[i+1:], ix.words[i:])
+			ix.words[i] = word
+		}
+		ix.postings[word] = append(ix.postings[word], posting{doc: number, score: score})
+	}
+}
+
+// Match is a document that contains every term, with its score.
+type Match struct {
+	Doc   int
+	Score float64
+}
+
+// Search returns the documents that have a word starting with each of the
+// terms, best scores first and then in document order. A limit of zero or
+// less returns every match.
+func (ix *Index) Search(terms []string, limit int) []Match {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var scores map[int]float64
+	for _, term := range terms {
+		termScores := make(map[int]float64)
+		for _, word := range ix.prefixed(term) {
+			for _, p := range ix.postings[word] {
+				termScores[p.doc] += p.score
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for doc := range scores {
+			if termScore, ok := termScores[doc]; ok {
+				scores[doc] += termScore
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+
+	matches := make([]Match, 0, len(scores))
+	for doc, score := range scores {
+		matches = append(matches, Match{Doc: doc, Score: score})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Doc < matches[j].Doc
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// prefixed returns the indexed words that start with prefix.
+func (ix *Index) prefixed(prefix string) []string {
+	start := sort.SearchStrings(ix.words, prefix)
+	end := start
+	for end < len(ix.words) && strings.HasPrefix(ix.words[end], prefix) {
+		end++
+	}
+	return ix.words[start:end]
+}
+
+// Tokenize splits text into lower-case words: runs of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isSeparator)
+}
+
+// Terms returns the distinct words of a query in the order they first
+// appear.
+func Terms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range Tokenize(query) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// Highlight wraps the words of text that start with one of the terms in
+// <mark> tags, like ts_headline with HighlightAll. The rest of the text is
+// returned unchanged.
+func Highlight(text string, terms []string) string {
+	var b strings.Builder
+	for len(text) > 0 {
+		end := strings.IndexFunc(text, isSeparator)
+		if end < 0 {
+			end = len(text)
+		}
+		if end == 0 {
+			_, size := utf8.DecodeRuneInString(text)
+			b.WriteString(text[:size])
+			text = text[size:]
+			continue
+		}
+
+		word := text[:end]
+		if matchesAny(strings.ToLower(word), terms) {
+			b.WriteString("<mark>" + word + "</mark>")
+		} else {
+			b.WriteString(word)
+		}
+		text = text[end:]
+	}
+	return b.String()
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
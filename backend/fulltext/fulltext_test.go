@@ -0,0 +1,79 @@
+package fulltext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(Document{Fields: []Field{{"Sunny Beach Week", A}, {"Sunny Beach, Bulgaria", B}, {"July 2026", C}}})
+	ix.Add(Document{Fields: []Field{{"Varna City Break", A}, {"Varna, Bulgaria", B}, {"August 2026", C}}})
+	ix.Add(Document{Fields: []Field{{"Sunny Beach Bulgaria", A}, {"Bulgaria", B}, {"Flower Street", C}}})
+	ix.Add(Document{Fields: []Field{{"Athens", A}, {"Greece", B}}})
+
+	for query, want := range map[string][]int{
+		"sunny beach bulgaria july": {0},
+		"SUNNY beach":               {0, 2},
+		"bulg":                      {2, 0, 1},
+		"bulgaria varna":            {1},
+		"sun gre":                   nil,
+		"2026":                      {0, 1},
+		"":                          nil,
+		"!!!":                       nil,
+	} {
+		if got := docs(ix.Search(Terms(query), 0)); !slices.Equal(got, want) {
+			t.Errorf("Search(%q) = %v, want %v", query, got, want)
+		}
+	}
+
+	if got := docs(ix.Search(Terms("bulgaria"), 2)); !slices.Equal(got, []int{2, 0}) {
+		t.Errorf("Search with limit 2 = %v", got)
+	}
+}
+
+func TestSearchRanksByWeight(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(Document{Fields: []Field{{"Tour", A}, {"Rila", C}}})
+	ix.Add(Document{Fields: []Field{{"Rila Lakes", A}}})
+	ix.Add(Document{Fields: []Field{{"Tour", A}, {"Rila", B}}})
+
+	matches := ix.Search([]string{"rila"}, 0)
+	if got := docs(matches); !slices.Equal(got, []int{1, 2, 0}) {
+		t.Errorf("ranked %v, want [1 2 0]", got)
+	}
+	if matches[0].Score <= matches[1].Score {
+		t.Errorf("scores %v are not decreasing", matches)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	if got := Terms("  Sunny-Beach, sunny BEACH Varna 2026! Пловдив"); !slices.Equal(got, []string{"sunny", "beach", "varna", "2026", "пловдив"}) {
+		t.Errorf("Terms = %q", got)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	for _, test := range []struct {
+		text  string
+		terms []string
+		want  string
+	}{
+		{"Sunny Beach Week · Sunny Beach, Bulgaria", []string{"sun", "bulgaria"}, "<mark>Sunny</mark> Beach Week · <mark>Sunny</mark> Beach, <mark>Bulgaria</mark>"},
+		{"Varna", []string{"sofia"}, "Varna"},
+		{"Пловдив, Bulgaria", []string{"пло"}, "<mark>Пловдив</mark>, Bulgaria"},
+		{"", []string{"a"}, ""},
+	} {
+		if got := Highlight(test.text, test.terms); got != test.want {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", test.text, test.terms, got, test.want)
+		}
+	}
+}
+
+func docs(matches []Match) []int {
+	var numbers []int
+	for _, match := range matches {
+		numbers = append(numbers, match.Doc)
+	}
+	return numbers
+}
@@ -0,0 +1,81 @@
+package geo
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//go:embed gazetteer.csv
+var gazetteerCSV string
+
+// Gazetteer is an offline Geocoder backed by an embedded list of cities. It
+// resolves addresses to the centre of their city, which is precise enough to
+// rank holidays by distance without calling an external service.
+type Gazetteer struct {
+	// cities maps a normalised city name to every place with that name.
+	cities map[string][]place
+}
+
+type place struct {
+	countryCode string
+	country     string
+	point       Point
+}
+
+// NewGazetteer loads the embedded city list.
+func NewGazetteer() (*Gazetteer, error) {
+	records, err := csv.NewReader(strings.NewReader(gazetteerCSV)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading gazetteer: %w", err)
+	}
+
+	g := &Gazetteer{cities: make(map[string][]place)}
+	for i, record := range records[1:] {
+		if len(record) != 5 {
+			return nil, fmt.Errorf("gazetteer line %d: want 5 fields, got %d", i+2, len(record))
+		}
+		lat, latErr := strconv.ParseFloat(record[3], 64)
+		lng, lngErr := strconv.ParseFloat(record[4], 64)
+		point := Point{Lat: lat, Lng: lng}
+		if latErr != nil || lngErr != nil || !point.Valid() {
+			return nil, fmt.Errorf("gazetteer line %d: invalid coordinates %s,%s", i+2, record[3], record[4])
+		}
+
+		city := normalize(record[2])
+		g.cities[city] = append(g.cities[city], place{
+			countryCode: normalize(record[0]),
+			country:     normalize(record[1]),
+			point:       point,
+		})
+	}
+
+	return g, nil
+}
+
+// Geocode matches the address's city, and its country when one is given as
+// a name or an ISO 3166 alpha-2 code. A city name shared by several
+// countries needs the country to be resolved.
+func (g *Gazetteer) Geocode(ctx context.Context, address Address) (Point, error) {
+	places := g.cities[normalize(address.City)]
+	country := normalize(address.Country)
+
+	var matches []place
+	for _, p := range places {
+		if country == "" || country == p.countryCode || country == p.country {
+			matches = append(matches, p)
+		}
+	}
+	if len(matches) != 1 {
+		return Point{}, ErrNoMatch
+	}
+
+	return matches[0].point, nil
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
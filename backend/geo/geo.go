@@ -0,0 +1,77 @@
+// Package geo places locations on the map and measures distances between
+// them.
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrNoMatch = errors.New("address could not be geocoded")
+
+const earthRadiusKm = 6371.0088
+
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Valid reports whether the point lies within the latitude and longitude
+// ranges.
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// ParsePoint reads a "lat,lng" pair in decimal degrees.
+func ParsePoint(value string) (Point, error) {
+	latText, lngText, ok := strings.Cut(value, ",")
+	if !ok {
+		return Point{}, fmt.Errorf("%q is not a lat,lng pair", value)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("latitude %q is not a number", latText)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngText), 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("longitude %q is not a number", lngText)
+	}
+
+	point := Point{Lat: lat, Lng: lng}
+	if !point.Valid() {
+		return Point{}, fmt.Errorf("%q is outside the valid latitude and longitude ranges", value)
+	}
+	return point, nil
+}
+
+// DistanceKm returns the great-circle distance between a and b using the
+// haversine formula on a spherical Earth.
+func DistanceKm(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+type Address struct {
+	Number  string
+	Street  string
+	City    string
+	Country string
+}
+
+// Geocoder finds the coordinates of an address. It returns ErrNoMatch when
+// the address is unknown to it.
+type Geocoder interface {
+	Geocode(ctx context.Context, address Address) (Point, error)
+}
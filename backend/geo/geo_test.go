@@ -0,0 +1,67 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{"same point", Point{42.6977, 23.3219}, Point{42.6977, 23.3219}, 0},
+		{"London to Paris", Point{51.5074, -0.1278}, Point{48.8566, 2.3522}, 343.6},
+		{"Sofia to Varna", Point{42.6977, 23.3219}, Point{43.2141, 27.9147}, 378.1},
+		{"across the antimeridian", Point{0, 179.5}, Point{0, -179.5}, 111.2},
+		{"pole to pole", Point{90, 0}, Point{-90, 0}, 20015.1},
+	}
+	for _, tt := range tests {
+		if got := DistanceKm(tt.a, tt.b); math.Abs(got-tt.want) > 0.5 {
+			t.Errorf("%s: DistanceKm = %.1f, want %.1f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	point, err := ParsePoint("43.2141, 27.9147")
+	if err != nil || point != (Point{43.2141, 27.9147}) {
+		t.Errorf("ParsePoint = %+v, %v", point, err)
+	}
+
+	for _, value := range []string{"", "43.2", "north,east", "43.2,east", "91,0", "0,181"} {
+		if _, err := ParsePoint(value); err == nil {
+			t.Errorf("ParsePoint(%q) succeeded", value)
+		}
+	}
+}
+
+func TestGazetteer(t *testing.T) {
+	g, err := NewGazetteer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	varna := Point{43.2141, 27.9147}
+	for _, address := range []Address{
+		{City: "Varna"},
+		{City: "  varna ", Country: "BULGARIA"},
+		{City: "Varna", Country: "bg"},
+	} {
+		if point, err := g.Geocode(t.Context(), address); err != nil || point != varna {
+			t.Errorf("Geocode(%+v) = %+v, %v", address, point, err)
+		}
+	}
+
+	for _, address := range []Address{
+		{City: "Atlantis"},
+		{City: "Varna", Country: "Greece"},
+		{},
+	} {
+		if _, err := g.Geocode(t.Context(), address); !errors.Is(err, ErrNoMatch) {
+			t.Errorf("Geocode(%+v) error = %v, want ErrNoMatch", address, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+func DatabaseCheck(db *sql.DB) Check {
+	return Check{
+		Name:     "database",
+		Required: true,
+		Run: func(ctx context.Context) error {
+			return db.PingContext(ctx)
+		},
+	}
+}
+
+func MigrationsCheck(db *sql.DB) Check {
+	return Check{
+		Name:     "migrations",
+		Required: true,
+		Run: func(ctx context.Context) error {
+			pending, err := database.PendingMigrations(ctx, db)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%d pending migration(s), next is %d (%s)", len(pending), pending[0].Version, pending[0].Name)
+			}
+			return nil
+		},
+	}
+}
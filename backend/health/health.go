@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Check struct {
+	Name     string
+	Required bool
+	Run      func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+type Controller struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+func NewController(timeout time.Duration, checks ...Check) *Controller {
+	return &Controller{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Live reports whether the process is up. It deliberately checks no
+// dependencies so that a database outage does not get the pod restarted.
+func (c *Controller) Live(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// Ready runs every registered check and answers 503 when a required one
+// fails, so the orchestrator stops routing traffic to this instance.
+func (c *Controller) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+	defer cancel()
+
+	report := c.run(ctx)
+
+	status := http.StatusOK
+	if report.Status == StatusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(w, status, report)
+}
+
+func (c *Controller) run(ctx context.Context) Report {
+	results := make([]CheckResult, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check.Run(ctx)
+			result := CheckResult{
+				Status:    StatusOK,
+				Required:  check.Required,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusUnavailable
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(c.checks)),
+	}
+	for i, check := range c.checks {
+		result := results[i]
+		report.Checks[check.Name] = result
+
+		if result.Status == StatusOK {
+			continue
+		}
+		if check.Required {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
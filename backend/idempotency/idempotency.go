@@ -0,0 +1,208 @@
+// Package idempotency lets clients retry POST requests safely. A request
+// carrying an Idempotency-Key header is served once; repeats with the same
+// key and payload within the retention window get the stored response back
+// instead of creating the resource again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+
+	// pendingTimeout bounds how long a key stays locked by a request that
+	// never finished, e.g. because the server stopped while serving it.
+	pendingTimeout = time.Minute
+)
+
+// Record is what is kept for one key: the fingerprint of the request that
+// first used it and, once that request has finished, its response.
+type Record struct {
+	Key         string
+	Fingerprint string
+	CreatedAt   time.Time
+	// Status is zero while the first request is still being served.
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Store persists records, implemented by storage/memory and
+// storage/sqlstore.
+type Store interface {
+	// Reserve claims record.Key for a new request. Existing records created
+	// before expiredBefore, or still pending and created before
+	// abandonedBefore, no longer hold the key. When the key is held,
+	// Reserve returns the holding record and false.
+	Reserve(ctx context.Context, record Record, expiredBefore, abandonedBefore time.Time) (Record, bool, error)
+	// Complete stores the response of the request that reserved the key.
+	Complete(ctx context.Context, record Record) error
+	// Release forgets a key so the request can be retried.
+	Release(ctx context.Context, key string) error
+	// Purge deletes records created before the given time.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Middleware makes POST requests that carry an Idempotency-Key idempotent.
+// Responses are kept for retention, except server errors, which release the
+// key so the client can try again. The body is read into memory to
+// fingerprint it, so bodies over maxBodyBytes are refused with 413.
+// Multipart uploads are left for the handler to read and limit, and only
+// their target is fingerprinted.
+func Middleware(store Store, retention time.Duration, maxBodyBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				http.Error(w, Header+" must be at most "+strconv.Itoa(maxKeyLength)+" characters", http.StatusBadRequest)
+				return
+			}
+
+			var body []byte
+			if !multipart(r) {
+				var err error
+				body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					http.Error(w, "Request body with an "+Header+" must be at most "+strconv.FormatInt(maxBodyBytes, 10)+" bytes", http.StatusRequestEntityTooLarge)
+					return
+				}
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			ctx := r.Context()
+			now := time.Now()
+			record := Record{
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+				CreatedAt:   now,
+			}
+
+			existing, reserved, err := store.Reserve(ctx, record, now.Add(-retention), now.Add(-pendingTimeout))
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to reserve idempotency key", "error", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !reserved {
+				replay(w, existing, record.Fingerprint)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// The client may have gone away; the outcome must still be
+			// recorded so that its retry sees it.
+			ctx = context.WithoutCancel(ctx)
+			if recorder.status >= http.StatusInternalServerError {
+				if err := store.Release(ctx, key); err != nil {
+					slog.ErrorContext(ctx, "Failed to release idempotency key", "error", err)
+				}
+				return
+			}
+
+			record.Status = recorder.status
+			record.ContentType = recorder.Header().Get("Content-Type")
+			record.Body = recorder.body.Bytes()
+			if err := store.Complete(ctx, record); err != nil {
+				slog.ErrorContext(ctx, "Failed to store idempotent response", "error", err)
+			}
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, existing Record, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		http.Error(w, Header+" was already used for a different request", http.StatusUnprocessableEntity)
+	case existing.Status == 0:
+		http.Error(w, "A request with this "+Header+" is still being processed", http.StatusConflict)
+	default:
+		if existing.ContentType != "" {
+			w.Header().Set("Content-Type", existing.ContentType)
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(existing.Status)
+		w.Write(existing.Body)
+	}
+}
+
+func multipart(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "multipart/form-data"
+}
+
+// fingerprint identifies a request by its target and exact body, so a
+// retry matches and any change to the payload does not.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// PurgeExpired deletes expired records every interval until ctx is done.
+func PurgeExpired(ctx context.Context, store Store, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := store.Purge(ctx, time.Now().Add(-retention))
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to purge idempotency keys", "error", err)
+				continue
+			}
+			if purged > 0 {
+				slog.DebugContext(ctx, "Purged idempotency keys", "count", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package idempotency_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/idempotency"
+	"github.com/nikolaypleshkov/uni-api/storage/memory"
+)
+
+// counter answers every request with the number of requests it has served
+// and the status the test asks for.
+type counter struct {
+	calls  atomic.Int32
+	status atomic.Int32
+}
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	io.ReadAll(r.Body)
+	n := c.calls.Add(1)
+	if status := c.status.Load(); status != 0 {
+		w.WriteHeader(int(status))
+	}
+	io.WriteString(w, strings.Repeat("x", int(n)))
+}
+
+func post(t *testing.T, handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/things", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareReplaysWithinRetention(t *testing.T) {
+	next := &counter{}
+	handler := idempotency.Middleware(memory.New().IdempotencyKeys(), 50*time.Millisecond, 1<<20)(next)
+
+	post(t, handler, "k", "{}")
+	if rec := post(t, handler, "k", "{}"); rec.Body.String() != "x" || next.calls.Load() != 1 {
+		t.Fatalf("retry body %q after %d calls, want the stored response", rec.Body, next.calls.Load())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if rec := post(t, handler, "k", "{}"); rec.Body.String() != "xx" {
+		t.Errorf("body after retention = %q, want a fresh response", rec.Body)
+	}
+}
+
+func TestMiddlewareReleasesKeyOnServerError(t *testing.T) {
+	next := &counter{}
+	next.status.Store(http.StatusServiceUnavailable)
+	handler := idempotency.Middleware(memory.New().IdempotencyKeys(), time.Hour, 1<<20)(next)
+
+	post(t, handler, "k", "{}")
+	next.status.Store(0)
+	if rec := post(t, handler, "k", "{}"); rec.Code != http.StatusOK || rec.Header().Get(idempotency.ReplayedHeader) != "" {
+		t.Errorf("retry after a server error = %d %q, want it served again", rec.Code, rec.Body)
+	}
+}
+
+func TestMiddlewareIgnoresUnkeyedRequests(t *testing.T) {
+	next := &counter{}
+	handler := idempotency.Middleware(memory.New().IdempotencyKeys(), time.Hour, 1<<20)(next)
+
+	post(t, handler, "", "{}")
+	post(t, handler, "", "{}")
+	if calls := next.calls.Load(); calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	store := memory.New().IdempotencyKeys()
+	handler := idempotency.Middleware(store, time.Hour, 1<<20)(&counter{})
+	post(t, handler, "k", "{}")
+
+	purged, err := store.Purge(t.Context(), time.Now().Add(time.Second))
+	if err != nil || purged != 1 {
+		t.Errorf("Purge = %d, %v, want 1 record", purged, err)
+	}
+}
+
+func TestMiddlewareRefusesLargeBodies(t *testing.T) {
+	next := &counter{}
+	handler := idempotency.Middleware(memory.New().IdempotencyKeys(), time.Hour, 16)(next)
+
+	if rec := post(t, handler, "k", strings.Repeat("x", 17)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body = %d, want 413", rec.Code)
+	}
+	if rec := post(t, handler, "k", strings.Repeat("x", 16)); rec.Code != http.StatusOK || next.calls.Load() != 1 {
+		t.Errorf("body at the limit = %d after %d calls, want it served", rec.Code, next.calls.Load())
+	}
+	if rec := post(t, handler, "", strings.Repeat("x", 17)); rec.Code != http.StatusOK {
+		t.Errorf("oversized body without a key = %d, want it left to the handler", rec.Code)
+	}
+}
+
+func TestMiddlewareLeavesMultipartBodies(t *testing.T) {
+	next := &counter{}
+	handler := idempotency.Middleware(memory.New().IdempotencyKeys(), time.Hour, 16)(next)
+
+	for _, boundary := range []string{"a", "b"} {
+		req := httptest.NewRequest("POST", "/things", strings.NewReader(strings.Repeat("x", 64)))
+		req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+		req.Header.Set(idempotency.Header, "k")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != "x" {
+			t.Errorf("multipart upload %s = %d %q, want the first response", boundary, rec.Code, rec.Body)
+		}
+	}
+}
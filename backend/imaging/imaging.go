@@ -0,0 +1,143 @@
+// Package imaging checks uploaded images and makes thumbnails of them. It
+// supports the formats the standard library decodes: JPEG, PNG and GIF.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxPixels bounds the size of a decoded image, so that a small file cannot
+// claim dimensions that would exhaust memory when decoded.
+const MaxPixels = 50_000_000
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+// extensions maps the supported content types to their file extensions.
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Info describes an image that passed Inspect.
+type Info struct {
+	ContentType string
+	Width       int32
+	Height      int32
+}
+
+// Inspect identifies the image in data by its content, not by any name or
+// declared type, and reads its dimensions.
+func Inspect(data []byte) (Info, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := extensions[contentType]; !ok {
+		return Info{}, fmt.Errorf("%w %s, want JPEG, PNG or GIF", ErrUnsupportedType, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return Info{}, fmt.Errorf("%w: %dx%d pixels is outside the supported size", ErrInvalidImage, config.Width, config.Height)
+	}
+
+	return Info{ContentType: contentType, Width: int32(config.Width), Height: int32(config.Height)}, nil
+}
+
+// Extension returns the file extension for a content type accepted by
+// Inspect.
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
+
+// Thumbnail scales the image in data down so that neither side exceeds
+// maxSize, keeping its aspect ratio; smaller images keep their size. JPEGs
+// stay JPEGs and everything else becomes a PNG, which keeps transparency.
+// It returns the encoded thumbnail and its content type.
+func Thumbnail(data []byte, maxSize int) ([]byte, string, error) {
+	info, err := Inspect(data)
+	if err != nil {
+		return nil, "", err
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	thumb := resize(src, fit(src.Bounds().Dx(), src.Bounds().Dy(), maxSize))
+
+	var out bytes.Buffer
+	if info.ContentType == "image/jpeg" {
+		err = jpeg.Encode(&out, thumb, &jpeg.Options{Quality: 85})
+		return out.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(&out, thumb)
+	return out.Bytes(), "image/png", err
+}
+
+// fit returns the size of a width x height image scaled to fit in a
+// maxSize square.
+func fit(width, height, maxSize int) image.Point {
+	if width <= maxSize && height <= maxSize {
+		return image.Pt(width, height)
+	}
+	if width >= height {
+		return image.Pt(maxSize, max(1, (height*maxSize+width/2)/width))
+	}
+	return image.Pt(max(1, (width*maxSize+height/2)/height), maxSize)
+}
+
+// resize scales src to size by averaging the source pixels that fall into
+// each target pixel, which keeps downscaled photos free of aliasing.
+func resize(src image.Image, size image.Point) *image.NRGBA {
+	bounds := src.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	if size == rgba.Bounds().Size() {
+		return rgba
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size.X, size.Y))
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	for y := range size.Y {
+		y0, y1 := y*srcH/size.Y, max((y+1)*srcH/size.Y, y*srcH/size.Y+1)
+		for x := range size.X {
+			x0, x1 := x*srcW/size.X, max((x+1)*srcW/size.X, x*srcW/size.X+1)
+
+			// Colour channels are weighted by alpha so that transparent
+			// pixels do not darken the edges of what remains visible.
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					alpha := uint64(p[3])
+					r += uint64(p[0]) * alpha
+					g += uint64(p[1]) * alpha
+					b += uint64(p[2]) * alpha
+					a += alpha
+					n++
+				}
+			}
+
+			p := dst.Pix[y*dst.Stride+x*4:]
+			if a > 0 {
+				p[0], p[1], p[2] = uint8(r/a), uint8(g/a), uint8(b/a)
+			}
+			p[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
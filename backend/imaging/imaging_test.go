@@ -0,0 +1,98 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encode(t *testing.T, format string, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspect(t *testing.T) {
+	for format, contentType := range map[string]string{"png": "image/png", "jpeg": "image/jpeg", "gif": "image/gif"} {
+		info, err := Inspect(encode(t, format, 40, 30))
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if info != (Info{ContentType: contentType, Width: 40, Height: 30}) {
+			t.Errorf("%s: info = %+v", format, info)
+		}
+	}
+
+	if _, err := Inspect([]byte("<svg xmlns='http://www.w3.org/2000/svg'/>")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("SVG: err = %v, want ErrUnsupportedType", err)
+	}
+	truncated := encode(t, "png", 40, 30)[:20]
+	if _, err := Inspect(truncated); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("truncated PNG: err = %v, want ErrInvalidImage", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		format        string
+		width, height int
+		want          image.Point
+		contentType   string
+	}{
+		{"jpeg", 1200, 800, image.Pt(320, 213), "image/jpeg"},
+		{"png", 500, 1000, image.Pt(160, 320), "image/png"},
+		{"gif", 640, 640, image.Pt(320, 320), "image/png"},
+		{"png", 100, 50, image.Pt(100, 50), "image/png"},
+	}
+	for _, tt := range tests {
+		thumb, contentType, err := Thumbnail(encode(t, tt.format, tt.width, tt.height), 320)
+		if err != nil {
+			t.Errorf("%s %dx%d: %v", tt.format, tt.width, tt.height, err)
+			continue
+		}
+		info, err := Inspect(thumb)
+		if err != nil {
+			t.Fatalf("%s %dx%d: thumbnail does not decode: %v", tt.format, tt.width, tt.height, err)
+		}
+		if got := image.Pt(int(info.Width), int(info.Height)); got != tt.want || contentType != tt.contentType || info.ContentType != contentType {
+			t.Errorf("%s %dx%d: thumbnail is a %dx%d %s (%s), want %v %s", tt.format, tt.width, tt.height, info.Width, info.Height, info.ContentType, contentType, tt.want, tt.contentType)
+		}
+	}
+}
+
+func TestResizeAveragesPixels(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	src.Set(1, 0, color.NRGBA{B: 255, A: 0})
+
+	got := resize(src, image.Pt(1, 1)).NRGBAAt(0, 0)
+	if want := (color.NRGBA{R: 255, A: 127}); got != want {
+		t.Errorf("resized pixel = %v, want %v: transparent pixels must not tint the colour", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+// Package iso3166 is an embedded copy of the ISO 3166-1 country list, used
+// to store countries under one canonical code whichever way they are
+// written.
+package iso3166
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+//go:embed countries.csv
+var countriesCSV string
+
+type Country struct {
+	Alpha2 string
+	Alpha3 string
+	// Name is the common English short name.
+	Name string
+	// Aliases are other names the country is known by, e.g. spellings
+	// without diacritics.
+	Aliases []string
+}
+
+var countries, lookup = mustLoad()
+
+// All returns every country ordered by alpha-2 code.
+func All() []Country {
+	return append([]Country(nil), countries...)
+}
+
+// Lookup finds a country by alpha-2 code, alpha-3 code, name or alias,
+// ignoring case and surrounding whitespace.
+func Lookup(value string) (Country, bool) {
+	i, ok := lookup[normalize(value)]
+	if !ok {
+		return Country{}, false
+	}
+	return countries[i], true
+}
+
+func mustLoad() ([]Country, map[string]int) {
+	records, err := csv.NewReader(strings.NewReader(countriesCSV)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("iso3166: reading countries.csv: %v", err))
+	}
+
+	var all []Country
+	index := make(map[string]int)
+	for line, record := range records[1:] {
+		country := Country{Alpha2: record[0], Alpha3: record[1], Name: record[2]}
+		if record[3] != "" {
+			country.Aliases = strings.Split(record[3], "|")
+		}
+
+		keys := append([]string{country.Alpha2, country.Alpha3, country.Name}, country.Aliases...)
+		for _, key := range keys {
+			key = normalize(key)
+			if _, taken := index[key]; taken {
+				panic(fmt.Sprintf("iso3166: countries.csv line %d: %q names two countries", line+2, key))
+			}
+			index[key] = len(all)
+		}
+		all = append(all, country)
+	}
+
+	return all, index
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
@@ -0,0 +1,47 @@
+package iso3166
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	for _, value := range []string{"BG", "bg", "BGR", "Bulgaria", "  bulgaria "} {
+		country, ok := Lookup(value)
+		if !ok || country.Alpha2 != "BG" || country.Name != "Bulgaria" {
+			t.Errorf("Lookup(%q) = %+v, %v", value, country, ok)
+		}
+	}
+
+	for value, want := range map[string]string{
+		"Turkey":         "TR",
+		"Türkiye":        "TR",
+		"UK":             "GB",
+		"Czech Republic": "CZ",
+		"Ivory Coast":    "CI",
+	} {
+		if country, ok := Lookup(value); !ok || country.Alpha2 != want {
+			t.Errorf("Lookup(%q) = %+v, %v, want %s", value, country, ok, want)
+		}
+	}
+
+	for _, value := range []string{"", "XX", "Atlantis"} {
+		if _, ok := Lookup(value); ok {
+			t.Errorf("Lookup(%q) succeeded", value)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	all := All()
+	if len(all) != 249 {
+		t.Errorf("All returned %d countries, want the 249 assigned codes", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i-1].Alpha2 >= all[i].Alpha2 {
+			t.Errorf("countries out of order at %s, %s", all[i-1].Alpha2, all[i].Alpha2)
+		}
+	}
+	for _, country := range all {
+		if len(country.Alpha2) != 2 || len(country.Alpha3) != 3 || country.Name == "" {
+			t.Errorf("malformed country %+v", country)
+		}
+	}
+}
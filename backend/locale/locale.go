@@ -0,0 +1,151 @@
+// Package locale picks the language of a response from the Accept-Language
+// header (RFC 9110, section 12.5.4) among the languages content has been
+// translated into.
+package locale
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the language of the untranslated content. Every resource is
+// available in it.
+const Default = "en"
+
+// tagPattern accepts BCP 47 language tags in the simple form clients send:
+// a primary language subtag followed by region, script or variant subtags.
+var tagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{1,8})*$`)
+
+// Normalize lower-cases a language tag and reports whether it is valid.
+// Underscores are accepted as separators, as in "pt_BR".
+func Normalize(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "_", "-")
+	return tag, tagPattern.MatchString(tag)
+}
+
+// Translation is the text of a resource in Language.
+type Translation[T any] struct {
+	Language string
+	Text     T
+}
+
+// Translations checks the translations of a resource, keyed by language
+// tag as clients send them, and returns them sorted by language. Tags are
+// normalised, so "bg" and "BG" count as the same language given twice. The
+// default language is rejected, since it is the resource's own text.
+func Translations[T any](byTag map[string]T) ([]Translation[T], error) {
+	translations := make([]Translation[T], 0, len(byTag))
+	for tag, text := range byTag {
+		language, ok := Normalize(tag)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a language tag", tag)
+		}
+		if language == Default {
+			return nil, fmt.Errorf("%s is the default language; update the resource itself instead", language)
+		}
+		if slices.ContainsFunc(translations, func(other Translation[T]) bool { return other.Language == language }) {
+			return nil, fmt.Errorf("%s is given twice", language)
+		}
+		translations = append(translations, Translation[T]{language, text})
+	}
+	sort.Slice(translations, func(i, j int) bool { return translations[i].Language < translations[j].Language })
+	return translations, nil
+}
+
+// ParseAcceptLanguage returns the language ranges of an Accept-Language
+// header, most preferred first. Ranges with a weight of zero and malformed
+// entries are left out, so a header that cannot be parsed counts as absent.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag    string
+		weight float64
+	}
+
+	var ranges []weighted
+	for _, entry := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(entry, ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			value, err := strconv.ParseFloat(q, 64)
+			if err != nil || value < 0 || value > 1 {
+				continue
+			}
+			weight = value
+		}
+		if weight == 0 {
+			continue
+		}
+
+		tag = strings.TrimSpace(tag)
+		if tag != "*" {
+			var ok bool
+			if tag, ok = Normalize(tag); !ok {
+				continue
+			}
+		}
+		ranges = append(ranges, weighted{tag, weight})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].weight > ranges[j].weight })
+	tags := make([]string, len(ranges))
+	for i, r := range ranges {
+		tags[i] = r.tag
+	}
+	return tags
+}
+
+// Match returns the language of available, or Default, that best serves
+// the ranges. Each range is tried in turn, first as given and then with
+// subtags removed from the end, so "de-at" falls back to "de". At each step
+// a more specific language is accepted too, so "pt" and "pt-pt" both take
+// "pt-br" when that is all there is. Default is returned when nothing
+// matches.
+func Match(ranges []string, available []string) string {
+	for _, r := range ranges {
+		if r == "*" {
+			return Default
+		}
+		for prefix := r; prefix != ""; prefix = parent(prefix) {
+			if prefix == Default || strings.HasPrefix(Default, prefix+"-") {
+				return Default
+			}
+			if i := slices.Index(available, prefix); i >= 0 {
+				return available[i]
+			}
+			for _, language := range available {
+				if strings.HasPrefix(language, prefix+"-") {
+					return language
+				}
+			}
+		}
+	}
+	return Default
+}
+
+// parent removes the last subtag of a language tag.
+func parent(tag string) string {
+	i := strings.LastIndex(tag, "-")
+	if i < 0 {
+		return ""
+	}
+	return tag[:i]
+}
+
+// Preferences returns the language ranges the request accepts and marks the
+// response as depending on them, for caches.
+func Preferences(w http.ResponseWriter, r *http.Request) []string {
+	w.Header().Add("Vary", "Accept-Language")
+	return ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// SetContentLanguage names the languages of the response body.
+func SetContentLanguage(w http.ResponseWriter, languages []string) {
+	if len(languages) > 0 {
+		w.Header().Set("Content-Language", strings.Join(languages, ", "))
+	}
+}
@@ -0,0 +1,72 @@
+package locale
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	for header, want := range map[string][]string{
+		"":                                   {},
+		"de":                                 {"de"},
+		"fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5": {"fr-ch", "fr", "en", "*"},
+		"en;q=0.2, bg":                       {"bg", "en"},
+		"pt_BR;q=0.7, de;q=0":                {"pt-br"},
+		"de;q=high, x, bg, 12345":            {"bg"},
+	} {
+		if got := ParseAcceptLanguage(header); !slices.Equal(got, want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	available := []string{"bg", "de", "pt-br"}
+	for _, test := range []struct {
+		ranges []string
+		want   string
+	}{
+		{nil, Default},
+		{[]string{"de"}, "de"},
+		{[]string{"de-at"}, "de"},
+		{[]string{"fr", "bg"}, "bg"},
+		{[]string{"pt"}, "pt-br"},
+		{[]string{"pt-pt"}, "pt-br"},
+		{[]string{"en-gb", "de"}, Default},
+		{[]string{"*", "de"}, Default},
+		{[]string{"fr"}, Default},
+	} {
+		if got := Match(test.ranges, available); got != test.want {
+			t.Errorf("Match(%q) = %q, want %q", test.ranges, got, test.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for tag, want := range map[string]string{"DE": "de", " pt_BR ": "pt-br", "zh-Hant-TW": "zh-hant-tw"} {
+		if got, ok := Normalize(tag); !ok || got != want {
+			t.Errorf("Normalize(%q) = %q, %v, want %q", tag, got, ok, want)
+		}
+	}
+	for _, tag := range []string{"", "*", "english", "d", "de--at", "de-"} {
+		if _, ok := Normalize(tag); ok {
+			t.Errorf("Normalize(%q) succeeded", tag)
+		}
+	}
+}
+
+func TestTranslations(t *testing.T) {
+	translations, err := Translations(map[string]string{"pt_BR": "Olá", "BG": "Здравей"})
+	if err != nil || len(translations) != 2 || translations[0] != (Translation[string]{"bg", "Здравей"}) || translations[1].Language != "pt-br" {
+		t.Errorf("Translations = %v, %v", translations, err)
+	}
+	for _, invalid := range []map[string]string{
+		{"english": "Hello"},
+		{"EN": "Hello"},
+		{"bg": "Здравей", "BG": "Здравей"},
+	} {
+		if _, err := Translations(invalid); err == nil {
+			t.Errorf("Translations(%v) succeeded", invalid)
+		}
+	}
+}
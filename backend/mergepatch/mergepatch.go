@@ -0,0 +1,78 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+)
+
+// MediaType is the content type of an RFC 7396 merge patch document.
+const MediaType = "application/merge-patch+json"
+
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// AcceptsContentType reports whether a PATCH body with this Content-Type
+// header is treated as a merge patch. Plain application/json is accepted
+// too, for clients that cannot set the dedicated media type.
+func AcceptsContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == MediaType || mediaType == "application/json"
+}
+
+// Apply applies patch to target, which is first marshalled to JSON, and
+// decodes the result into out. Members set to null in the patch are removed
+// and therefore decode to their zero value.
+func Apply(target any, patch []byte, out any) error {
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if _, ok := patchValue.(map[string]any); !ok {
+		return fmt.Errorf("%w: patch must be a JSON object", ErrInvalidPatch)
+	}
+
+	original, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	var targetValue any
+	if err := json.Unmarshal(original, &targetValue); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(merge(targetValue, patchValue))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(merged, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return nil
+}
+
+// merge implements the MergePatch function from RFC 7396, section 2.
+func merge(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+
+	return targetObject
+}
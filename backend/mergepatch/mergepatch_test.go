@@ -0,0 +1,79 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// The test cases from RFC 7396, appendix A.
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		var target, patch, want any
+		json.Unmarshal([]byte(tt.target), &target)
+		json.Unmarshal([]byte(tt.patch), &patch)
+		json.Unmarshal([]byte(tt.want), &want)
+
+		if got := merge(target, patch); !reflect.DeepEqual(got, want) {
+			t.Errorf("merge(%s, %s) = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	type holiday struct {
+		ID       int64  `json:"id"`
+		Title    string `json:"title"`
+		Duration int32  `json:"duration"`
+	}
+	current := holiday{ID: 1, Title: "Summer", Duration: 7}
+
+	var patched holiday
+	if err := Apply(current, []byte(`{"duration":14,"title":null}`), &patched); err != nil {
+		t.Fatal(err)
+	}
+	if want := (holiday{ID: 1, Duration: 14}); patched != want {
+		t.Errorf("patched = %+v, want %+v", patched, want)
+	}
+
+	for _, patch := range []string{`not json`, `["title"]`, `{"duration":"long"}`} {
+		if err := Apply(current, []byte(patch), &patched); !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("Apply(%s) error = %v, want ErrInvalidPatch", patch, err)
+		}
+	}
+}
+
+func TestAcceptsContentType(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		"application/merge-patch+json":                true,
+		"application/merge-patch+json; charset=utf-8": true,
+		"application/json":                            true,
+		"application/json-patch+json":                 false,
+		"text/plain":                                  false,
+		"":                                            false,
+	} {
+		if got := AcceptsContentType(contentType); got != want {
+			t.Errorf("AcceptsContentType(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+func RegisterDBStats(db *sql.DB, dbName string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+const collectTimeout = 5 * time.Second
+
+type FreeSlotsSource func(ctx context.Context) (map[int64]int32, error)
+
+type freeSlotsCollector struct {
+	source FreeSlotsSource
+	desc   *prometheus.Desc
+}
+
+// RegisterFreeSlots exposes the remaining free slots of the holidays source
+// returns, which should be those still on sale so that the series do not
+// pile up. The values are read from source on each scrape instead of being
+// tracked in process, so they stay correct across replicas.
+func RegisterFreeSlots(source FreeSlotsSource) {
+	prometheus.MustRegister(&freeSlotsCollector{
+		source: source,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "holiday_free_slots"),
+			"Remaining free slots per published holiday that has not started yet.",
+			[]string{"holiday_id"},
+			nil,
+		),
+	})
+}
+
+func (c *freeSlotsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *freeSlotsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	slots, err := c.source(ctx)
+	if err != nil {
+		slog.Error("Failed to collect free slots", "error", err)
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for holidayID, free := range slots {
+		ch <- prometheus.MustNewConstMetric(
+			c.desc,
+			prometheus.GaugeValue,
+			float64(free),
+			strconv.FormatInt(holidayID, 10),
+		)
+	}
+}
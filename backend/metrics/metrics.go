@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "travel_agency"
+
+var (
+	httpRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route template, method and status code.",
+		},
+		[]string{"route", "method", "status"},
+	)
+
+	httpDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"route", "method", "status"},
+	)
+
+	ReservationsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservations_created_total",
+		Help:      "Number of reservations created.",
+	})
+
+	ReservationsCancelled = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservations_cancelled_total",
+		Help:      "Number of reservations cancelled.",
+	})
+)
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+)
+
+// Middleware records request counts and latency labelled with the mux route
+// template (e.g. /travel-agency/holidays/{holidayId}) rather than the raw
+// path, which keeps label cardinality bounded.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		m := httpsnoop.CaptureMetrics(next, w, r)
+
+		status := strconv.Itoa(m.Code)
+		httpRequests.WithLabelValues(route, r.Method, status).Inc()
+		httpDuration.WithLabelValues(route, r.Method, status).Observe(m.Duration.Seconds())
+	})
+}
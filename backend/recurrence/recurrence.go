@@ -0,0 +1,336 @@
+// Package recurrence expands the subset of iCalendar recurrence rules
+// (RFC 5545, section 3.3.10) that holiday templates are scheduled with:
+// weekly and monthly rules on whole days, ending at a date or after a number
+// of occurrences.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRule  = errors.New("invalid recurrence rule")
+	ErrTooManyDates = errors.New("recurrence rule yields too many dates")
+)
+
+type Frequency string
+
+const (
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// horizon bounds how far past its start a rule is expanded, so that a rule
+// whose days never occur, such as the 31st of every twelfth month starting
+// in February, still ends.
+const horizon = 10
+
+// Weekday is one BYDAY entry. In monthly rules a non-zero Ordinal picks one
+// occurrence of the day in the month: 1 for the first, -1 for the last.
+type Weekday struct {
+	Ordinal int
+	Day     time.Weekday
+}
+
+// Rule is a parsed RRULE. Exactly one of Until and Count is set.
+type Rule struct {
+	Frequency Frequency
+	// Interval is the number of weeks or months between occurrences.
+	Interval int
+	// Weekdays and MonthDays pick the days within each period. Without
+	// either, a rule repeats on the weekday or day of the month it starts
+	// on. MonthDays count back from the end of the month when negative.
+	Weekdays  []Weekday
+	MonthDays []int
+	// Until is the last date the rule may fall on.
+	Until time.Time
+	// Count is the number of occurrences.
+	Count int
+}
+
+var dayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Parse reads a rule such as "FREQ=WEEKLY;BYDAY=SA;UNTIL=20260926". The
+// supported parts are FREQ (WEEKLY or MONTHLY), INTERVAL, BYDAY,
+// BYMONTHDAY, UNTIL and COUNT; UNTIL is a date, and a date-time is cut to
+// its date.
+func Parse(value string) (Rule, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.TrimPrefix(value, "RRULE:")
+
+	rule := Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, partValue, ok := strings.Cut(part, "=")
+		if !ok || partValue == "" {
+			return Rule{}, fmt.Errorf("%w: %q is not a NAME=VALUE part", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return Rule{}, fmt.Errorf("%w: %s is given more than once", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Frequency = Frequency(partValue)
+			if rule.Frequency != Weekly && rule.Frequency != Monthly {
+				err = fmt.Errorf("FREQ must be WEEKLY or MONTHLY")
+			}
+		case "INTERVAL":
+			rule.Interval, err = positive(name, partValue)
+		case "COUNT":
+			rule.Count, err = positive(name, partValue)
+		case "UNTIL":
+			rule.Until, err = parseUntil(partValue)
+		case "BYDAY":
+			rule.Weekdays, err = parseWeekdays(partValue)
+		case "BYMONTHDAY":
+			rule.MonthDays, err = parseMonthDays(partValue)
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	return rule, nil
+}
+
+func (r Rule) validate() error {
+	if r.Frequency == "" {
+		return errors.New("FREQ is required")
+	}
+	if r.Until.IsZero() == (r.Count == 0) {
+		return errors.New("the rule must end with either UNTIL or COUNT")
+	}
+	if r.Frequency == Weekly {
+		if len(r.MonthDays) > 0 {
+			return errors.New("BYMONTHDAY is only supported in MONTHLY rules")
+		}
+		for _, weekday := range r.Weekdays {
+			if weekday.Ordinal != 0 {
+				return errors.New("BYDAY ordinals are only supported in MONTHLY rules")
+			}
+		}
+	}
+	return nil
+}
+
+func positive(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 1000 {
+		return 0, fmt.Errorf("%s must be a number from 1 to 1000", name)
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if len(value) > 8 && value[8] == 'T' {
+		value = value[:8]
+	}
+	until, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("UNTIL %q is not a date such as 20260926", value)
+	}
+	return until, nil
+}
+
+func parseWeekdays(value string) ([]Weekday, error) {
+	var weekdays []Weekday
+	for _, entry := range strings.Split(value, ",") {
+		if len(entry) < 2 {
+			return nil, fmt.Errorf("BYDAY %q is not a day such as SA or 1SA", entry)
+		}
+		day := slices.Index(dayNames, entry[len(entry)-2:])
+		if day < 0 {
+			return nil, fmt.Errorf("BYDAY %q is not a day such as SA or 1SA", entry)
+		}
+
+		weekday := Weekday{Day: time.Weekday(day)}
+		if ordinal := entry[:len(entry)-2]; ordinal != "" {
+			n, err := strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("BYDAY %q must have an ordinal from 1 to 5 or -1 to -5", entry)
+			}
+			weekday.Ordinal = n
+		}
+		if !slices.Contains(weekdays, weekday) {
+			weekdays = append(weekdays, weekday)
+		}
+	}
+	return weekdays, nil
+}
+
+func parseMonthDays(value string) ([]int, error) {
+	var days []int
+	for _, entry := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(entry)
+		if err != nil || n == 0 || n < -31 || n > 31 {
+			return nil, fmt.Errorf("BYMONTHDAY %q must be from 1 to 31 or -1 to -31", entry)
+		}
+		if !slices.Contains(days, n) {
+			days = append(days, n)
+		}
+	}
+	return days, nil
+}
+
+// String formats the rule the way Parse reads it, leaving out the default
+// interval of 1.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Frequency)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.Weekdays) > 0 {
+		days := make([]string, len(r.Weekdays))
+		for i, weekday := range r.Weekdays {
+			days[i] = dayNames[weekday.Day]
+			if weekday.Ordinal != 0 {
+				days[i] = strconv.Itoa(weekday.Ordinal) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.MonthDays) > 0 {
+		days := make([]string, len(r.MonthDays))
+		for i, day := range r.MonthDays {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	} else {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Dates returns the dates the rule falls on from start, which is the first
+// day of the first period, in order. It fails with ErrTooManyDates rather
+// than return more than limit dates. As in RFC 5545, weeks start on Monday.
+func (r Rule) Dates(start time.Time, limit int) ([]time.Time, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(horizon, 0, 0)
+	if !r.Until.IsZero() && r.Until.Before(end) {
+		end = r.Until
+	}
+	interval := max(r.Interval, 1)
+
+	var dates []time.Time
+	for period := 0; ; period += interval {
+		var periodStart time.Time
+		var days []time.Time
+		if r.Frequency == Weekly {
+			periodStart = start.AddDate(0, 0, 7*period-weekOffset(start.Weekday()))
+			days = r.weekDates(periodStart, start.Weekday())
+		} else {
+			periodStart = time.Date(start.Year(), start.Month()+time.Month(period), 1, 0, 0, 0, 0, time.UTC)
+			days = r.monthDates(periodStart, start.Day())
+		}
+		if periodStart.After(end) {
+			return dates, nil
+		}
+
+		for _, day := range days {
+			if day.Before(start) {
+				continue
+			}
+			if day.After(end) || (r.Count > 0 && len(dates) == r.Count) {
+				return dates, nil
+			}
+			if len(dates) == limit {
+				return nil, fmt.Errorf("%w: more than %d", ErrTooManyDates, limit)
+			}
+			dates = append(dates, day)
+		}
+	}
+}
+
+// weekOffset counts the days since Monday.
+func weekOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func (r Rule) weekDates(monday time.Time, startDay time.Weekday) []time.Time {
+	offsets := []int{weekOffset(startDay)}
+	if len(r.Weekdays) > 0 {
+		offsets = offsets[:0]
+		for _, weekday := range r.Weekdays {
+			offsets = append(offsets, weekOffset(weekday.Day))
+		}
+		slices.Sort(offsets)
+	}
+
+	dates := make([]time.Time, len(offsets))
+	for i, offset := range offsets {
+		dates[i] = monday.AddDate(0, 0, offset)
+	}
+	return dates
+}
+
+// monthDates returns the days of the month that starts at first. When both
+// BYDAY and BYMONTHDAY are given, a day must match both.
+func (r Rule) monthDates(first time.Time, startDay int) []time.Time {
+	length := first.AddDate(0, 1, -1).Day()
+
+	var days []int
+	switch {
+	case len(r.MonthDays) > 0:
+		for _, day := range r.MonthDays {
+			if day < 0 {
+				day += length + 1
+			}
+			if day >= 1 && day <= length && (len(r.Weekdays) == 0 || r.onWeekday(first, day, length)) {
+				days = append(days, day)
+			}
+		}
+	case len(r.Weekdays) > 0:
+		for day := 1; day <= length; day++ {
+			if r.onWeekday(first, day, length) {
+				days = append(days, day)
+			}
+		}
+	case startDay <= length:
+		days = append(days, startDay)
+	}
+	slices.Sort(days)
+	days = slices.Compact(days)
+
+	dates := make([]time.Time, len(days))
+	for i, day := range days {
+		dates[i] = first.AddDate(0, 0, day-1)
+	}
+	return dates
+}
+
+// onWeekday reports whether day of the month matches one of the rule's
+// weekdays, counting ordinals from the start or the end of the month.
+func (r Rule) onWeekday(first time.Time, day, length int) bool {
+	weekday := first.AddDate(0, 0, day-1).Weekday()
+	for _, w := range r.Weekdays {
+		if w.Day != weekday {
+			continue
+		}
+		switch {
+		case w.Ordinal == 0:
+			return true
+		case w.Ordinal > 0 && (day-1)/7+1 == w.Ordinal:
+			return true
+		case w.Ordinal < 0 && (length-day)/7+1 == -w.Ordinal:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	valid := map[string]string{
+		"FREQ=WEEKLY;BYDAY=SA;UNTIL=20260926":         "FREQ=WEEKLY;BYDAY=SA;UNTIL=20260926",
+		"rrule:freq=weekly;interval=1;count=4":        "FREQ=WEEKLY;COUNT=4",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,WE;COUNT=10": "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,WE;COUNT=10",
+		"FREQ=MONTHLY;BYDAY=1SA,-1SU;COUNT=6":         "FREQ=MONTHLY;BYDAY=1SA,-1SU;COUNT=6",
+		"FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20261231": "FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20261231",
+		"FREQ=MONTHLY;UNTIL=20261231T235959Z":         "FREQ=MONTHLY;UNTIL=20261231",
+	}
+	for value, want := range valid {
+		rule, err := Parse(value)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", value, err)
+			continue
+		}
+		if got := rule.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", value, got, want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"FREQ=DAILY;COUNT=3",
+		"FREQ=WEEKLY",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20260926",
+		"FREQ=WEEKLY;COUNT=0",
+		"FREQ=WEEKLY;COUNT=3;COUNT=4",
+		"FREQ=WEEKLY;BYDAY=1SA;COUNT=3",
+		"FREQ=WEEKLY;BYMONTHDAY=1;COUNT=3",
+		"FREQ=WEEKLY;BYDAY=XX;COUNT=3",
+		"FREQ=MONTHLY;BYMONTHDAY=32;COUNT=3",
+		"FREQ=MONTHLY;UNTIL=2026-09-26",
+		"FREQ=WEEKLY;BYHOUR=9;COUNT=3",
+	}
+	for _, value := range invalid {
+		if _, err := Parse(value); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalidRule", value, err)
+		}
+	}
+}
+
+func TestDates(t *testing.T) {
+	tests := []struct {
+		rule  string
+		start string
+		want  []string
+	}{
+		{
+			"FREQ=WEEKLY;UNTIL=20260627",
+			"2026-06-06",
+			[]string{"2026-06-06", "2026-06-13", "2026-06-20", "2026-06-27"},
+		},
+		{
+			"FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,WE;COUNT=5",
+			"2026-06-06",
+			[]string{"2026-06-06", "2026-06-17", "2026-06-20", "2026-07-01", "2026-07-04"},
+		},
+		{
+			"FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			"2026-01-15",
+			[]string{"2026-01-31", "2026-02-28", "2026-03-31"},
+		},
+		{
+			"FREQ=MONTHLY;BYDAY=1SA;UNTIL=20260930",
+			"2026-06-01",
+			[]string{"2026-06-06", "2026-07-04", "2026-08-01", "2026-09-05"},
+		},
+		{
+			"FREQ=MONTHLY;COUNT=3",
+			"2026-01-31",
+			[]string{"2026-01-31", "2026-03-31", "2026-05-31"},
+		},
+		{
+			"FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13;UNTIL=20261231",
+			"2026-01-01",
+			[]string{"2026-02-13", "2026-03-13", "2026-11-13"},
+		},
+		{
+			"FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30;COUNT=2",
+			"2026-02-01",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start, _ := time.Parse(time.DateOnly, tt.start)
+
+		dates, err := rule.Dates(start, 100)
+		if err != nil {
+			t.Errorf("%s from %s: %v", tt.rule, tt.start, err)
+			continue
+		}
+		var got []string
+		for _, date := range dates {
+			got = append(got, date.Format(time.DateOnly))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s from %s = %v, want %v", tt.rule, tt.start, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s from %s = %v, want %v", tt.rule, tt.start, got, tt.want)
+				break
+			}
+		}
+	}
+
+	rule, _ := Parse("FREQ=WEEKLY;COUNT=53")
+	if _, err := rule.Dates(time.Now(), 52); !errors.Is(err, ErrTooManyDates) {
+		t.Errorf("a year of weeks with a limit of 52 = %v, want ErrTooManyDates", err)
+	}
+}
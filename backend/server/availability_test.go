@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	reservationdto "github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+)
+
+func TestAvailabilityCalendar(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Sozopol")
+		other := api.createLocation("Burgas")
+
+		week := api.createHoliday(location.ID, "2030-07-04", 7, 10)
+		weekend := api.createHoliday(location.ID, "2030-07-04", 3, 4)
+		api.patch(fmt.Sprintf("/travel-agency/holidays/%d", weekend.ID), `{"price": 399}`, http.StatusOK, &weekend)
+		soldOut := api.createHoliday(location.ID, "2030-07-11", 7, 0)
+		draft := api.createHoliday(location.ID, "2030-07-18", 7, 6)
+		api.patch(fmt.Sprintf("/travel-agency/holidays/%d", draft.ID), `{"status": "draft"}`, http.StatusOK, nil)
+		api.createHoliday(location.ID, "2030-08-01", 7, 6)
+		api.createHoliday(other.ID, "2030-07-04", 7, 6)
+
+		path := fmt.Sprintf("/travel-agency/availability?location=%d&month=2030-07", location.ID)
+		var calendar holidaydto.ResponseAvailabilityDTO
+		api.expect("GET", path, nil, http.StatusOK, &calendar)
+		if calendar.Month != "2030-07" || len(calendar.Days) != 2 {
+			t.Fatalf("calendar = %+v", calendar)
+		}
+
+		first := calendar.Days[0]
+		if first.Date != "2030-07-04" || first.FreeSlots != 14 || first.LowestPrice == nil || *first.LowestPrice != weekend.Price {
+			t.Errorf("2030-07-04 = %+v", first)
+		}
+		if len(first.Holidays) != 2 || first.Holidays[0].ID != weekend.ID || first.Holidays[1].ID != week.ID {
+			t.Errorf("2030-07-04 holidays = %+v, want %d then %d", first.Holidays, weekend.ID, week.ID)
+		}
+		second := calendar.Days[1]
+		if second.Date != "2030-07-11" || second.FreeSlots != 0 || second.LowestPrice != nil {
+			t.Errorf("sold out 2030-07-11 = %+v", second)
+		}
+		if len(second.Holidays) != 1 || second.Holidays[0].ID != soldOut.ID {
+			t.Errorf("2030-07-11 holidays = %+v", second.Holidays)
+		}
+
+		var byCity holidaydto.ResponseAvailabilityDTO
+		api.expect("GET", "/travel-agency/availability?location=sozopol&month=2030-07", nil, http.StatusOK, &byCity)
+		if len(byCity.Days) != 2 {
+			t.Errorf("calendar by city = %+v", byCity)
+		}
+
+		var empty holidaydto.ResponseAvailabilityDTO
+		api.expect("GET", fmt.Sprintf("/travel-agency/availability?location=%d&month=2030-09", location.ID), nil, http.StatusOK, &empty)
+		if empty.Days == nil || len(empty.Days) != 0 {
+			t.Errorf("empty month = %+v", empty)
+		}
+
+		for _, query := range []string{"month=2030-07", fmt.Sprintf("location=%d", location.ID), fmt.Sprintf("location=%d&month=2030-13", location.ID)} {
+			api.expect("GET", "/travel-agency/availability?"+query, nil, http.StatusBadRequest, nil)
+		}
+	})
+}
+
+func TestAvailabilityCaching(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Sozopol")
+		holiday := api.createHoliday(location.ID, "2030-07-04", 7, 10)
+		path := fmt.Sprintf("/travel-agency/availability?location=%d&month=2030-07", location.ID)
+
+		resp := api.do("GET", path, nil)
+		tag := resp.Header.Get("ETag")
+		if tag == "" || resp.Header.Get("Cache-Control") != "public, max-age=60" {
+			t.Fatalf("ETag = %q, Cache-Control = %q", tag, resp.Header.Get("Cache-Control"))
+		}
+
+		resp = api.doWithHeader("GET", path, nil, http.Header{"If-None-Match": {tag}})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("If-None-Match current: status = %d, want 304", resp.StatusCode)
+		}
+
+		// A booking changes the free slots and so the tag.
+		reserve := reservationdto.CreateReservationDTO{ContactName: "Maria Ivanova", HolidayID: holiday.ID}
+		api.expect("POST", "/travel-agency/reservations", reserve, http.StatusOK, nil)
+		resp = api.doWithHeader("GET", path, nil, http.Header{"If-None-Match": {tag}})
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("If-None-Match after booking: status = %d, want 200", resp.StatusCode)
+		}
+	})
+}
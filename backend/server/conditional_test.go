@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	reservationdto "github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+)
+
+func TestETagAndIfNoneMatch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		path := fmt.Sprintf("/travel-agency/locations/%d", location.ID)
+
+		tag := api.etag(path)
+		if tag != `"1"` {
+			t.Fatalf("ETag = %s, want \"1\"", tag)
+		}
+
+		resp := api.doWithHeader("GET", path, nil, http.Header{"If-None-Match": {tag}})
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("If-None-Match current: status = %d, want 304", resp.StatusCode)
+		}
+		if got := resp.Header.Get("ETag"); got != tag {
+			t.Errorf("304 ETag = %s, want %s", got, tag)
+		}
+
+		resp = api.doWithHeader("GET", path, nil, http.Header{"If-None-Match": {`"0"`}})
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("If-None-Match stale: status = %d, want 200", resp.StatusCode)
+		}
+	})
+}
+
+func TestIfMatchOnWrites(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		path := fmt.Sprintf("/travel-agency/locations/%d", location.ID)
+		update := locationdto.UpdateLocationDTO{City: "Sozopol"}
+		stale := http.Header{"If-Match": {`"0"`}}
+
+		if resp := api.do("PUT", path, update); resp.StatusCode != http.StatusPreconditionRequired {
+			t.Errorf("PUT without If-Match: status = %d, want 428", resp.StatusCode)
+		}
+		if resp := api.doWithHeader("PUT", path, update, stale); resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("PUT with stale If-Match: status = %d, want 412", resp.StatusCode)
+		}
+		if resp := api.send("PATCH", path, "application/merge-patch+json", strings.NewReader(`{"city":"Nessebar"}`), stale); resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("PATCH with stale If-Match: status = %d, want 412", resp.StatusCode)
+		}
+		if resp := api.doWithHeader("DELETE", path, nil, stale); resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("DELETE with stale If-Match: status = %d, want 412", resp.StatusCode)
+		}
+
+		// Two agents read the same version; the second write must not
+		// silently overwrite the first.
+		read := http.Header{"If-Match": {api.etag(path)}}
+		first := api.doWithHeader("PUT", path, locationdto.UpdateLocationDTO{City: "Sozopol"}, read)
+		if first.StatusCode != http.StatusOK {
+			t.Fatalf("first write: status = %d", first.StatusCode)
+		}
+		second := api.doWithHeader("PUT", path, locationdto.UpdateLocationDTO{City: "Pomorie"}, read)
+		if second.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("second write: status = %d, want 412", second.StatusCode)
+		}
+
+		var fetched locationdto.ResponseLocationDTO
+		api.expect("GET", path, nil, http.StatusOK, &fetched)
+		if fetched.City != "Sozopol" || fetched.Version != 2 {
+			t.Errorf("after the race: %+v, want Sozopol at version 2", fetched)
+		}
+		if got := first.Header.Get("ETag"); got != api.etag(path) {
+			t.Errorf("PUT returned ETag %s, GET returns %s", got, api.etag(path))
+		}
+
+		if resp := api.do("DELETE", path, nil); resp.StatusCode != http.StatusPreconditionRequired {
+			t.Errorf("DELETE without If-Match: status = %d, want 428", resp.StatusCode)
+		}
+		api.write("DELETE", path, nil, http.StatusOK, nil)
+	})
+}
+
+// TestHolidayETagTracksDependents checks that the holiday's ETag changes
+// when its embedded location is edited and when a booking takes a slot.
+func TestHolidayETagTracksDependents(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		holiday := api.createHoliday(location.ID, "2026-07-01", 7, 5)
+		path := fmt.Sprintf("/travel-agency/holidays/%d", holiday.ID)
+
+		initial := api.etag(path)
+		api.patch(fmt.Sprintf("/travel-agency/locations/%d", location.ID), `{"city": "Golden Sands"}`, http.StatusOK, nil)
+		afterLocationEdit := api.etag(path)
+		if afterLocationEdit == initial {
+			t.Errorf("ETag %s did not change when the location was edited", initial)
+		}
+
+		api.expect("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+			ContactName: "Ivan Petrov",
+			HolidayID:   holiday.ID,
+		}, http.StatusOK, nil)
+		if api.etag(path) == afterLocationEdit {
+			t.Error("ETag did not change when a reservation took a slot")
+		}
+
+		stale := http.Header{"If-Match": {afterLocationEdit}}
+		resp := api.send("PATCH", path, "application/merge-patch+json", strings.NewReader(`{"freeSlots": 5}`), stale)
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("PATCH over a booking: status = %d, want 412", resp.StatusCode)
+		}
+		if slots := api.freeSlots(holiday.ID); slots != 4 {
+			t.Errorf("freeSlots = %d, want 4", slots)
+		}
+	})
+}
+
+func TestConcurrentConditionalWrites(t *testing.T) {
+	const writers = 10
+
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		holiday := api.createHoliday(location.ID, "2026-07-01", 7, 5)
+		path := fmt.Sprintf("/travel-agency/holidays/%d", holiday.ID)
+		header := http.Header{"If-Match": {api.etag(path)}}
+
+		var wg sync.WaitGroup
+		statuses := make(chan int, writers)
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				patch := fmt.Sprintf(`{"title": "Edit %d"}`, i)
+				statuses <- api.send("PATCH", path, "application/merge-patch+json", strings.NewReader(patch), header).StatusCode
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		counts := make(map[int]int)
+		for status := range statuses {
+			counts[status]++
+		}
+		if counts[http.StatusOK] != 1 || counts[http.StatusPreconditionFailed] != writers-1 {
+			t.Errorf("statuses = %v, want 1 OK and %d Precondition Failed", counts, writers-1)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	countrydto "github.com/nikolaypleshkov/uni-api/api/country/dto"
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+)
+
+func TestCountryNormalization(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		for _, written := range []locationdto.CreateLocationDTO{
+			{City: "Varna", Country: "bulgaria"},
+			{City: "Varna", Country: "BG"},
+			{City: "Varna", Country: " BGR "},
+			{City: "Varna", CountryCode: "bg"},
+			{City: "Varna", Country: "Bulgaria", CountryCode: "BG"},
+		} {
+			var created locationdto.ResponseLocationDTO
+			api.expect("POST", "/travel-agency/locations", written, http.StatusOK, &created)
+			if created.Country != "Bulgaria" || created.CountryCode != "BG" {
+				t.Errorf("%+v stored as %q/%q", written, created.Country, created.CountryCode)
+			}
+		}
+
+		for _, invalid := range []locationdto.CreateLocationDTO{
+			{City: "Narnia", Country: "Narnia"},
+			{City: "Varna", Country: "Bulgaria", CountryCode: "GR"},
+			{City: "Varna", CountryCode: "XX"},
+		} {
+			api.expect("POST", "/travel-agency/locations", invalid, http.StatusBadRequest, nil)
+		}
+
+		var nowhere locationdto.ResponseLocationDTO
+		api.expect("POST", "/travel-agency/locations", locationdto.CreateLocationDTO{City: "Somewhere"}, http.StatusOK, &nowhere)
+		if nowhere.Country != "" || nowhere.CountryCode != "" {
+			t.Errorf("location without a country stored as %q/%q", nowhere.Country, nowhere.CountryCode)
+		}
+
+		created := api.createLocation("Athens")
+		path := fmt.Sprintf("/travel-agency/locations/%d", created.ID)
+		var patched locationdto.ResponseLocationDTO
+		api.patch(path, `{"countryCode": "gr"}`, http.StatusOK, &patched)
+		if patched.Country != "Greece" || patched.CountryCode != "GR" {
+			t.Errorf("patching countryCode stored %q/%q", patched.Country, patched.CountryCode)
+		}
+		api.patch(path, `{"country": "Turkey"}`, http.StatusOK, &patched)
+		if patched.Country != "Türkiye" || patched.CountryCode != "TR" {
+			t.Errorf("patching country stored %q/%q", patched.Country, patched.CountryCode)
+		}
+	})
+}
+
+func TestCountriesAndCities(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		varna := api.createLocation("Varna")
+		api.createHoliday(varna.ID, "2026-07-01", 7, 3)
+		api.createHoliday(varna.ID, "2026-08-01", 7, 3)
+		api.expect("POST", "/travel-agency/locations", locationdto.CreateLocationDTO{City: "varna", Country: "BG"}, http.StatusOK, nil)
+		sofia := api.createLocation("Sofia")
+		api.createHoliday(sofia.ID, "2026-07-01", 7, 3)
+
+		var athens locationdto.ResponseLocationDTO
+		api.expect("POST", "/travel-agency/locations", locationdto.CreateLocationDTO{City: "Athens", Country: "Greece"}, http.StatusOK, &athens)
+		api.createHoliday(athens.ID, "2026-07-01", 7, 3)
+
+		var countries []countrydto.ResponseCountryDTO
+		api.expect("GET", "/travel-agency/countries", nil, http.StatusOK, &countries)
+		want := []countrydto.ResponseCountryDTO{
+			{Code: "BG", Alpha3: "BGR", Name: "Bulgaria", CityCount: 2, LocationCount: 3, HolidayCount: 3},
+			{Code: "GR", Alpha3: "GRC", Name: "Greece", CityCount: 1, LocationCount: 1, HolidayCount: 1},
+		}
+		if fmt.Sprint(countries) != fmt.Sprint(want) {
+			t.Errorf("countries = %+v, want %+v", countries, want)
+		}
+
+		var all []countrydto.ResponseCountryDTO
+		api.expect("GET", "/travel-agency/countries?all=true", nil, http.StatusOK, &all)
+		if len(all) != 249 {
+			t.Errorf("listed %d countries with all=true, want 249", len(all))
+		}
+		api.expect("GET", "/travel-agency/countries?all=maybe", nil, http.StatusBadRequest, nil)
+
+		var cities []countrydto.ResponseCityDTO
+		api.expect("GET", "/travel-agency/countries/bg/cities", nil, http.StatusOK, &cities)
+		wantCities := []countrydto.ResponseCityDTO{
+			{Name: "Sofia", CountryCode: "BG", LocationCount: 1, HolidayCount: 1},
+			{Name: "Varna", CountryCode: "BG", LocationCount: 2, HolidayCount: 2},
+		}
+		if fmt.Sprint(cities) != fmt.Sprint(wantCities) {
+			t.Errorf("cities = %+v, want %+v", cities, wantCities)
+		}
+
+		api.expect("GET", "/travel-agency/countries/FR/cities", nil, http.StatusOK, &cities)
+		if len(cities) != 0 {
+			t.Errorf("France has cities %+v", cities)
+		}
+		api.expect("GET", "/travel-agency/countries/XX/cities", nil, http.StatusNotFound, nil)
+	})
+}
+
+func TestHolidaysByCountry(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		varna := api.createHoliday(api.createLocation("Varna").ID, "2026-07-01", 7, 3)
+		var athens locationdto.ResponseLocationDTO
+		api.expect("POST", "/travel-agency/locations", locationdto.CreateLocationDTO{City: "Athens", Country: "GR"}, http.StatusOK, &athens)
+		greek := api.createHoliday(athens.ID, "2026-07-01", 7, 3)
+
+		for query, want := range map[string][]int64{
+			"country=Bulgaria":                 {varna.ID},
+			"country=gr":                       {greek.ID},
+			"country=GRC&startDate=2026-07-01": {greek.ID},
+			"country=FR":                       nil,
+			"country=bg&near=43.2141,27.9147":  {varna.ID},
+			"country=Greece&duration=10":       nil,
+		} {
+			var holidays []holidaydto.ResponseHolidayDTO
+			api.expect("GET", "/travel-agency/holidays?"+query, nil, http.StatusOK, &holidays)
+			if ids := holidayIDs(holidays); fmt.Sprint(ids) != fmt.Sprint(want) {
+				t.Errorf("%s: holidays %v, want %v", query, ids, want)
+			}
+		}
+
+		api.expect("GET", "/travel-agency/holidays?country=Narnia", nil, http.StatusBadRequest, nil)
+	})
+}
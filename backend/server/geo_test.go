@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestLocationCoordinates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		geocoded := api.createLocation("Varna")
+		if geocoded.Latitude == nil || *geocoded.Latitude != 43.2141 || *geocoded.Longitude != 27.9147 {
+			t.Errorf("Varna was placed at %v,%v", geocoded.Latitude, geocoded.Longitude)
+		}
+
+		unknown := api.createLocation("Atlantis")
+		if unknown.Latitude != nil || unknown.Longitude != nil {
+			t.Errorf("unknown city was placed at %v,%v", *unknown.Latitude, *unknown.Longitude)
+		}
+
+		var explicit locationdto.ResponseLocationDTO
+		api.expect("POST", "/travel-agency/locations", locationdto.CreateLocationDTO{
+			City:      "Varna",
+			Street:    "Sea Garden",
+			Latitude:  ptr(43.2050),
+			Longitude: ptr(27.9250),
+		}, http.StatusOK, &explicit)
+		if *explicit.Latitude != 43.2050 || *explicit.Longitude != 27.9250 {
+			t.Errorf("explicit coordinates replaced with %v,%v", *explicit.Latitude, *explicit.Longitude)
+		}
+
+		path := fmt.Sprintf("/travel-agency/locations/%d", explicit.ID)
+		var renamed locationdto.ResponseLocationDTO
+		api.patch(path, `{"street": "Primorski"}`, http.StatusOK, &renamed)
+		if *renamed.Latitude != 43.2050 {
+			t.Errorf("patch without a move changed latitude to %v", *renamed.Latitude)
+		}
+
+		for _, invalid := range []locationdto.CreateLocationDTO{
+			{City: "Varna", Latitude: ptr(43.2)},
+			{City: "Varna", Latitude: ptr(95.0), Longitude: ptr(27.9)},
+			{City: "Varna", Latitude: ptr(43.2), Longitude: ptr(-181.0)},
+		} {
+			api.expect("POST", "/travel-agency/locations", invalid, http.StatusBadRequest, nil)
+		}
+		api.patch(path, `{"latitude": null}`, http.StatusBadRequest, nil)
+	})
+}
+
+func TestHolidaysNear(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		varna := api.createHoliday(api.createLocation("Varna").ID, "2026-07-01", 7, 3)
+		sofia := api.createHoliday(api.createLocation("Sofia").ID, "2026-07-01", 7, 3)
+		balchik := api.createHoliday(api.createLocation("Balchik").ID, "2026-07-01", 7, 3)
+		api.createHoliday(api.createLocation("Atlantis").ID, "2026-07-01", 7, 3)
+
+		// A point on the coast north of Varna, closer to Balchik.
+		const near = "43.35,28.05"
+
+		var nearby []holidaydto.ResponseHolidayDTO
+		api.expect("GET", "/travel-agency/holidays?near="+near+"&radiusKm=50", nil, http.StatusOK, &nearby)
+		if ids := holidayIDs(nearby); fmt.Sprint(ids) != fmt.Sprint([]int64{balchik.ID, varna.ID}) {
+			t.Fatalf("holidays within 50 km = %v, want Balchik then Varna", ids)
+		}
+		if d := *nearby[0].DistanceKm; d < 10 || d > 12 {
+			t.Errorf("distance to Balchik = %v km, want about 11", d)
+		}
+
+		var ranked []holidaydto.ResponseHolidayDTO
+		api.expect("GET", "/travel-agency/holidays?near="+near, nil, http.StatusOK, &ranked)
+		if ids := holidayIDs(ranked); fmt.Sprint(ids) != fmt.Sprint([]int64{balchik.ID, varna.ID, sofia.ID}) {
+			t.Errorf("holidays by distance = %v, want Balchik, Varna, Sofia", ids)
+		}
+
+		var all []holidaydto.ResponseHolidayDTO
+		api.expect("GET", "/travel-agency/holidays", nil, http.StatusOK, &all)
+		if len(all) != 4 || all[0].DistanceKm != nil {
+			t.Errorf("listing without near = %d holidays, first distance %v", len(all), all[0].DistanceKm)
+		}
+
+		for _, query := range []string{"near=north", "near=95,0", "near=" + near + "&radiusKm=-5", "near=" + near + "&radiusKm=far", "radiusKm=10"} {
+			api.expect("GET", "/travel-agency/holidays?"+query, nil, http.StatusBadRequest, nil)
+		}
+	})
+}
+
+func holidayIDs(holidays []holidaydto.ResponseHolidayDTO) []int64 {
+	var ids []int64
+	for _, holiday := range holidays {
+		ids = append(ids, holiday.ID)
+	}
+	return ids
+}
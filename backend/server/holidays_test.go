@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+)
+
+func TestHolidaysCRUD(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+
+		created := api.createHoliday(location.ID, "2026-07-01", 7, 10)
+		if created.ID == 0 || created.LocationID != location.ID || created.FreeSlots != 10 {
+			t.Fatalf("created holiday = %+v", created)
+		}
+		path := fmt.Sprintf("/travel-agency/holidays/%d", created.ID)
+
+		var fetched holidaydto.ResponseHolidayDTO
+		api.expect("GET", path, nil, http.StatusOK, &fetched)
+		if fetched.Title != created.Title || fetched.Duration != 7 || fetched.Price != "499.90" {
+			t.Errorf("fetched holiday = %+v", fetched)
+		}
+		if fetched.StartDate != "2026-07-01T00:00:00Z" {
+			t.Errorf("startDate = %q", fetched.StartDate)
+		}
+		if fetched.Location.ID != location.ID || fetched.Location.City != "Varna" {
+			t.Errorf("embedded location = %+v", fetched.Location)
+		}
+
+		update := holidaydto.UpdateHolidayDTO{
+			Title:     "Autumn break",
+			StartDate: "2026-10-01",
+			Duration:  3,
+			FreeSlots: 4,
+			Price:     250.5,
+			Location:  location.ID,
+		}
+		resp := api.doWithHeader("PUT", path, update, api.ifMatch(path))
+		var replaced holidaydto.ResponseHolidayDTO
+		api.check(resp, http.StatusOK, &replaced)
+
+		api.expect("GET", path, nil, http.StatusOK, &fetched)
+		if fetched.Title != "Autumn break" || fetched.Duration != 3 || fetched.FreeSlots != 4 {
+			t.Errorf("updated holiday = %+v", fetched)
+		}
+		if replaced.Version != fetched.Version || replaced.Status != fetched.Status || replaced.Location.City != "Varna" {
+			t.Errorf("PUT response = %+v, want the stored holiday %+v", replaced, fetched)
+		}
+		if tag := resp.Header.Get("ETag"); tag != api.etag(path) {
+			t.Errorf("PUT ETag = %s, want the stored holiday's", tag)
+		}
+
+		if price, err := strconv.ParseFloat(fetched.Price, 64); err != nil || price != 250.5 {
+			t.Errorf("updated price = %q, want 250.5", fetched.Price)
+		}
+
+		api.write("DELETE", path, nil, http.StatusNoContent, nil)
+		api.expect("GET", path, nil, http.StatusNotFound, nil)
+		api.write("DELETE", path, nil, http.StatusNotFound, nil)
+	})
+}
+
+func TestHolidayFilters(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		api.createHoliday(location.ID, "2026-07-01", 7, 10)
+		api.createHoliday(location.ID, "2026-07-01", 14, 10)
+		api.createHoliday(location.ID, "2026-08-01", 7, 10)
+
+		tests := []struct {
+			query string
+			want  int
+		}{
+			{"", 3},
+			{"?duration=7", 2},
+			{"?startDate=2026-07-01", 2},
+			{"?startDate=2026-07-01&duration=14", 1},
+			{"?duration=30", 0},
+		}
+		for _, tt := range tests {
+			var holidays []holidaydto.ResponseHolidayDTO
+			api.expect("GET", "/travel-agency/holidays"+tt.query, nil, http.StatusOK, &holidays)
+			if len(holidays) != tt.want {
+				t.Errorf("GET holidays%s: %d results, want %d", tt.query, len(holidays), tt.want)
+			}
+		}
+
+		api.expect("GET", "/travel-agency/holidays?duration=week", nil, http.StatusBadRequest, nil)
+	})
+}
+
+func TestHolidayErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		api.expect("GET", "/travel-agency/holidays/999", nil, http.StatusNotFound, nil)
+		api.expect("GET", "/travel-agency/holidays/abc", nil, http.StatusBadRequest, nil)
+		api.write("PUT", "/travel-agency/holidays/999", holidaydto.UpdateHolidayDTO{Title: "Gone"}, http.StatusNotFound, nil)
+
+		resp := api.do("POST", "/travel-agency/holidays", nil)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("empty body: status = %d, want 400", resp.StatusCode)
+		}
+	})
+}
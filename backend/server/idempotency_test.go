@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	reservationdto "github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+)
+
+func TestIdempotentReservation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		holiday := api.createHoliday(location.ID, "2026-07-01", 7, 3)
+
+		booking := reservationdto.CreateReservationDTO{
+			PhoneNumber: "+359888123456",
+			ContactName: "Ivan Petrov",
+			HolidayID:   holiday.ID,
+		}
+		header := http.Header{"Idempotency-Key": {"booking-1"}}
+
+		first := api.doWithHeader("POST", "/travel-agency/reservations", booking, header)
+		var created reservationdto.ResponseReservationDTO
+		api.check(first, http.StatusOK, &created)
+		if first.Header.Get("Idempotent-Replayed") != "" {
+			t.Error("first request marked as replayed")
+		}
+
+		retry := api.doWithHeader("POST", "/travel-agency/reservations", booking, header)
+		var replayed reservationdto.ResponseReservationDTO
+		api.check(retry, http.StatusOK, &replayed)
+		if retry.Header.Get("Idempotent-Replayed") != "true" {
+			t.Error("retry not marked as replayed")
+		}
+		if !reflect.DeepEqual(replayed, created) {
+			t.Errorf("retry returned %+v, want the original %+v", replayed, created)
+		}
+
+		if slots := api.freeSlots(holiday.ID); slots != 2 {
+			t.Errorf("freeSlots after a retried booking = %d, want 2", slots)
+		}
+
+		changed := booking
+		changed.ContactName = "Maria Petrova"
+		api.check(api.doWithHeader("POST", "/travel-agency/reservations", changed, header), http.StatusUnprocessableEntity, nil)
+		api.check(api.doWithHeader("POST", "/travel-agency/locations", booking, header), http.StatusUnprocessableEntity, nil)
+
+		api.check(api.doWithHeader("POST", "/travel-agency/reservations", changed, http.Header{"Idempotency-Key": {"booking-2"}}), http.StatusOK, nil)
+		api.expect("POST", "/travel-agency/reservations", booking, http.StatusOK, nil)
+
+		var reservations []reservationdto.ResponseReservationDTO
+		api.expect("GET", "/travel-agency/reservations", nil, http.StatusOK, &reservations)
+		if len(reservations) != 3 {
+			t.Errorf("stored %d reservations, want 3", len(reservations))
+		}
+	})
+}
+
+func TestIdempotentErrorsAreReplayed(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		full := api.createHoliday(location.ID, "2026-07-01", 7, 0)
+
+		header := http.Header{"Idempotency-Key": {"full-holiday"}}
+		booking := reservationdto.CreateReservationDTO{ContactName: "Too late", HolidayID: full.ID}
+
+		first := api.doWithHeader("POST", "/travel-agency/reservations", booking, header)
+		firstBody, _ := io.ReadAll(first.Body)
+		retry := api.doWithHeader("POST", "/travel-agency/reservations", booking, header)
+		retryBody, _ := io.ReadAll(retry.Body)
+
+		if first.StatusCode != http.StatusConflict || retry.StatusCode != http.StatusConflict {
+			t.Fatalf("statuses = %d, %d, want 409 twice", first.StatusCode, retry.StatusCode)
+		}
+		if string(retryBody) != string(firstBody) {
+			t.Errorf("replayed body %q, want %q", retryBody, firstBody)
+		}
+	})
+}
+
+// TestConcurrentIdempotentRequests sends the same keyed booking many times
+// at once and checks that only one reservation is made.
+func TestConcurrentIdempotentRequests(t *testing.T) {
+	const attempts = 10
+
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		holiday := api.createHoliday(location.ID, "2026-07-01", 7, attempts)
+
+		booking := reservationdto.CreateReservationDTO{ContactName: "Double tap", HolidayID: holiday.ID}
+		header := http.Header{"Idempotency-Key": {"double-tap"}}
+
+		var wg sync.WaitGroup
+		statuses := make(chan int, attempts)
+		for range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				statuses <- api.doWithHeader("POST", "/travel-agency/reservations", booking, header).StatusCode
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		counts := make(map[int]int)
+		for status := range statuses {
+			counts[status]++
+		}
+		if counts[http.StatusOK]+counts[http.StatusConflict] != attempts || counts[http.StatusOK] == 0 {
+			t.Errorf("statuses = %v, want only 200 and 409", counts)
+		}
+
+		if slots := api.freeSlots(holiday.ID); slots != attempts-1 {
+			t.Errorf("freeSlots = %d, want %d", slots, attempts-1)
+		}
+		var reservations []reservationdto.ResponseReservationDTO
+		api.expect("GET", "/travel-agency/reservations", nil, http.StatusOK, &reservations)
+		if len(reservations) != 1 {
+			t.Errorf("stored %d reservations, want 1", len(reservations))
+		}
+	})
+}
+
+func TestIdempotencyKeyValidation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		header := http.Header{"Idempotency-Key": {fmt.Sprintf("%0256d", 0)}}
+		api.check(api.doWithHeader("POST", "/travel-agency/locations", nil, header), http.StatusBadRequest, nil)
+	})
+}
+
+// TestIdempotencyBodyLimit checks that a keyed request is refused before
+// its oversized body is buffered, and nothing is created.
+func TestIdempotencyBodyLimit(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		oversized := map[string]string{"city": "Varna", "street": strings.Repeat("x", 1<<20)}
+		header := http.Header{"Idempotency-Key": {"big-1"}}
+		api.check(api.doWithHeader("POST", "/travel-agency/locations", oversized, header), http.StatusRequestEntityTooLarge, nil)
+
+		var locations []locationdto.ResponseLocationDTO
+		api.expect("GET", "/travel-agency/locations", nil, http.StatusOK, &locations)
+		if len(locations) != 0 {
+			t.Errorf("locations = %+v, want none created", locations)
+		}
+	})
+}
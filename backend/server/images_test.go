@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	imagedto "github.com/nikolaypleshkov/uni-api/api/image/dto"
+)
+
+func TestLocationImages(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		path := fmt.Sprintf("/travel-agency/locations/%d/images", location.ID)
+
+		var added []imagedto.ResponseImageDTO
+		api.upload(path, http.StatusOK, &added, pngFile(1200, 900), pngFile(200, 100))
+		if len(added) != 2 {
+			t.Fatalf("added %d images, want 2", len(added))
+		}
+		first := added[0]
+		if first.Position != 0 || added[1].Position != 1 {
+			t.Errorf("positions = %d, %d, want 0, 1", first.Position, added[1].Position)
+		}
+		if first.ContentType != "image/png" || first.Width != 1200 || first.Height != 900 || first.Size == 0 {
+			t.Errorf("first image = %+v", first)
+		}
+
+		resp := api.do("GET", first.URL, nil)
+		original, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/png" || int64(len(original)) != first.Size {
+			t.Errorf("GET %s: status %d, Content-Type %q, %d bytes", first.URL, resp.StatusCode, resp.Header.Get("Content-Type"), len(original))
+		}
+		thumbnail := api.do("GET", first.ThumbnailURL, nil)
+		config, err := png.DecodeConfig(thumbnail.Body)
+		if err != nil || config.Width != 320 || config.Height != 240 {
+			t.Errorf("thumbnail is %dx%d (%v), want 320x240", config.Width, config.Height, err)
+		}
+
+		var listed []imagedto.ResponseImageDTO
+		api.expect("GET", path, nil, http.StatusOK, &listed)
+		if !slices.Equal(listed, added) {
+			t.Errorf("listed %+v, want %+v", listed, added)
+		}
+
+		var third []imagedto.ResponseImageDTO
+		api.upload(path, http.StatusOK, &third, pngFile(50, 50))
+		if third[0].Position != 2 {
+			t.Errorf("third image position = %d, want 2", third[0].Position)
+		}
+
+		ids := []int64{third[0].ID, added[0].ID, added[1].ID}
+		var reordered []imagedto.ResponseImageDTO
+		api.expect("PUT", path+"/order", imagedto.ReorderImagesDTO{ImageIDs: ids}, http.StatusOK, &reordered)
+		if got := imageIDs(reordered); !slices.Equal(got, ids) {
+			t.Errorf("order = %v, want %v", got, ids)
+		}
+		api.expect("PUT", path+"/order", imagedto.ReorderImagesDTO{ImageIDs: ids[:2]}, http.StatusBadRequest, nil)
+		api.expect("PUT", path+"/order", imagedto.ReorderImagesDTO{ImageIDs: []int64{ids[0], ids[0], ids[1]}}, http.StatusBadRequest, nil)
+
+		api.expect("DELETE", fmt.Sprintf("%s/%d", path, third[0].ID), nil, http.StatusNoContent, nil)
+		api.expect("DELETE", fmt.Sprintf("%s/%d", path, third[0].ID), nil, http.StatusNotFound, nil)
+		api.expect("GET", path, nil, http.StatusOK, &listed)
+		if got := imageIDs(listed); !slices.Equal(got, ids[1:]) || listed[0].Position != 0 || listed[1].Position != 1 {
+			t.Errorf("after delete: %+v", listed)
+		}
+		api.expect("GET", third[0].URL, nil, http.StatusNotFound, nil)
+		api.expect("GET", third[0].ThumbnailURL, nil, http.StatusNotFound, nil)
+	})
+}
+
+func TestImageUploadValidation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		path := fmt.Sprintf("/travel-agency/locations/%d/images", location.ID)
+
+		api.upload(path, http.StatusUnsupportedMediaType, nil, []byte("<svg xmlns='http://www.w3.org/2000/svg'/>"))
+		api.upload(path, http.StatusBadRequest, nil, pngFile(10, 10)[:40])
+		api.upload(path, http.StatusBadRequest, nil)
+		api.upload(path, http.StatusUnsupportedMediaType, nil, pngFile(10, 10), []byte("not an image"))
+		api.upload(path, http.StatusRequestEntityTooLarge, nil, make([]byte, 10<<20+1))
+		api.upload("/travel-agency/locations/999/images", http.StatusNotFound, nil, pngFile(10, 10))
+		api.expect("GET", "/travel-agency/locations/999/images", nil, http.StatusNotFound, nil)
+
+		resp := api.send("POST", path, "application/json", strings.NewReader("{}"), nil)
+		if resp.StatusCode != http.StatusUnsupportedMediaType {
+			t.Errorf("JSON upload: status = %d, want 415", resp.StatusCode)
+		}
+
+		var listed []imagedto.ResponseImageDTO
+		api.expect("GET", path, nil, http.StatusOK, &listed)
+		if len(listed) != 0 {
+			t.Errorf("rejected uploads stored %d images", len(listed))
+		}
+		api.expect("GET", "/images/missing.png", nil, http.StatusNotFound, nil)
+	})
+}
+
+func TestHolidayImagesArePurgedWithTheHoliday(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		holiday := api.createHoliday(location.ID, "2026-07-01", 7, 10)
+		path := fmt.Sprintf("/travel-agency/holidays/%d/images", holiday.ID)
+
+		var added []imagedto.ResponseImageDTO
+		api.upload(path, http.StatusOK, &added, pngFile(100, 100))
+		api.upload(fmt.Sprintf("/travel-agency/locations/%d/images", location.ID), http.StatusOK, nil, pngFile(100, 100))
+
+		api.write("DELETE", fmt.Sprintf("/travel-agency/holidays/%d", holiday.ID), nil, http.StatusNoContent, nil)
+		api.expect("GET", path, nil, http.StatusNotFound, nil)
+
+		purged, err := api.services.Images.PurgeOrphans(t.Context())
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeOrphans = %d, %v, want 1 image", purged, err)
+		}
+		api.expect("GET", added[0].URL, nil, http.StatusNotFound, nil)
+
+		var kept []imagedto.ResponseImageDTO
+		api.expect("GET", fmt.Sprintf("/travel-agency/locations/%d/images", location.ID), nil, http.StatusOK, &kept)
+		if len(kept) != 1 {
+			t.Errorf("the location has %d images after purging, want 1", len(kept))
+		}
+	})
+}
+
+// upload posts files as a multipart/form-data request and checks the
+// response like expect.
+func (api *testAPI) upload(path string, status int, out any, files ...[]byte) {
+	api.t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for i, file := range files {
+		part, err := writer.CreateFormFile("images", fmt.Sprintf("photo-%d.png", i))
+		if err != nil {
+			api.t.Fatal(err)
+		}
+		part.Write(file)
+	}
+	writer.Close()
+
+	api.check(api.send("POST", path, writer.FormDataContentType(), &body, nil), status, out)
+}
+
+func pngFile(width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func imageIDs(images []imagedto.ResponseImageDTO) []int64 {
+	var ids []int64
+	for _, image := range images {
+		ids = append(ids, image.ID)
+	}
+	return ids
+}
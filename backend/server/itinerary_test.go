@@ -0,0 +1,143 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+)
+
+func TestItinerary(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		varna := api.createLocation("Varna")
+		sofia := api.createLocation("Sofia")
+		plovdiv := api.createLocation("Plovdiv")
+		holiday := api.createHoliday(varna.ID, "2026-07-01", 7, 10)
+		path := fmt.Sprintf("/travel-agency/holidays/%d/itinerary", holiday.ID)
+
+		var itinerary holidaydto.ResponseItineraryDTO
+		api.expect("GET", path, nil, http.StatusOK, &itinerary)
+		if itinerary.HolidayID != holiday.ID || itinerary.Duration != 7 || len(itinerary.Stops) != 0 {
+			t.Errorf("new itinerary = %+v", itinerary)
+		}
+
+		etag := api.etag(path)
+		api.write("PUT", path, holidaydto.UpdateItineraryDTO{Stops: []holidaydto.StopDTO{
+			{Location: sofia.ID, DayOffset: 0, Nights: 2},
+			{Location: plovdiv.ID, DayOffset: 3, Nights: 4},
+		}}, http.StatusOK, &itinerary)
+		if len(itinerary.Stops) != 2 {
+			t.Fatalf("itinerary has %d stops, want 2", len(itinerary.Stops))
+		}
+		second := itinerary.Stops[1]
+		if second.Position != 1 || second.DayOffset != 3 || second.Nights != 4 || second.Location.City != "Plovdiv" {
+			t.Errorf("second stop = %+v", second)
+		}
+		if api.etag(path) == etag {
+			t.Error("replacing the itinerary did not change the holiday's ETag")
+		}
+
+		// The stops embed their locations, so editing one changes the tag.
+		cached := api.etag(path)
+		api.patch(fmt.Sprintf("/travel-agency/locations/%d", sofia.ID), `{"street": "Vitosha"}`, http.StatusOK, nil)
+		resp := api.doWithHeader("GET", path, nil, http.Header{"If-None-Match": {cached}})
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("If-None-Match after editing a stop's location: status = %d, want 200", resp.StatusCode)
+		}
+
+		resp = api.doWithHeader("PUT", path, holidaydto.UpdateItineraryDTO{}, http.Header{"If-Match": {cached}})
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("PUT with a stale ETag: status = %d, want 412", resp.StatusCode)
+		}
+		if resp := api.do("PUT", path, holidaydto.UpdateItineraryDTO{}); resp.StatusCode != http.StatusPreconditionRequired {
+			t.Errorf("PUT without If-Match: status = %d, want 428", resp.StatusCode)
+		}
+
+		// The stops end on day 7, so the holiday cannot get shorter.
+		holidayPath := fmt.Sprintf("/travel-agency/holidays/%d", holiday.ID)
+		api.patch(holidayPath, `{"duration": 6}`, http.StatusBadRequest, nil)
+		api.patch(holidayPath, `{"duration": 9}`, http.StatusOK, nil)
+
+		locationPath := fmt.Sprintf("/travel-agency/locations/%d", plovdiv.ID)
+		resp = api.doWithHeader("DELETE", locationPath, nil, api.ifMatch(locationPath))
+		if resp.StatusCode == http.StatusOK {
+			t.Error("deleted a location that an itinerary stops at")
+		}
+
+		api.write("DELETE", path, nil, http.StatusNoContent, nil)
+		api.expect("GET", path, nil, http.StatusOK, &itinerary)
+		if len(itinerary.Stops) != 0 {
+			t.Errorf("itinerary after DELETE = %+v", itinerary)
+		}
+		api.write("DELETE", locationPath, nil, http.StatusOK, nil)
+	})
+}
+
+func TestItineraryValidation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		varna := api.createLocation("Varna")
+		sofia := api.createLocation("Sofia")
+		holiday := api.createHoliday(varna.ID, "2026-07-01", 7, 10)
+		path := fmt.Sprintf("/travel-agency/holidays/%d/itinerary", holiday.ID)
+
+		for name, stops := range map[string][]holidaydto.StopDTO{
+			"no nights":        {{Location: sofia.ID, DayOffset: 0, Nights: 0}},
+			"before the start": {{Location: sofia.ID, DayOffset: -1, Nights: 2}},
+			"past the end":     {{Location: sofia.ID, DayOffset: 5, Nights: 3}},
+			"overlapping":      {{Location: sofia.ID, DayOffset: 0, Nights: 3}, {Location: varna.ID, DayOffset: 2, Nights: 2}},
+		} {
+			resp := api.doWithHeader("PUT", path, holidaydto.UpdateItineraryDTO{Stops: stops}, api.ifMatch(path))
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want 400", name, resp.StatusCode)
+			}
+		}
+
+		api.write("PUT", path, holidaydto.UpdateItineraryDTO{Stops: []holidaydto.StopDTO{
+			{Location: 999, DayOffset: 0, Nights: 2},
+		}}, http.StatusUnprocessableEntity, nil)
+		api.write("PUT", "/travel-agency/holidays/999/itinerary", holidaydto.UpdateItineraryDTO{}, http.StatusNotFound, nil)
+		api.expect("GET", "/travel-agency/holidays/999/itinerary", nil, http.StatusNotFound, nil)
+
+		var itinerary holidaydto.ResponseItineraryDTO
+		api.expect("GET", path, nil, http.StatusOK, &itinerary)
+		if len(itinerary.Stops) != 0 {
+			t.Errorf("rejected itineraries stored %d stops", len(itinerary.Stops))
+		}
+
+		// Stops may leave days free and use the whole duration.
+		api.write("PUT", path, holidaydto.UpdateItineraryDTO{Stops: []holidaydto.StopDTO{
+			{Location: sofia.ID, DayOffset: 1, Nights: 2},
+			{Location: varna.ID, DayOffset: 3, Nights: 4},
+		}}, http.StatusOK, nil)
+	})
+}
+
+func TestHolidaysByVisitedLocation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		varna := api.createLocation("Varna")
+		sofia := api.createLocation("Sofia")
+		coast := api.createHoliday(varna.ID, "2026-07-01", 7, 10)
+		tour := api.createHoliday(sofia.ID, "2026-08-01", 7, 10)
+		api.write("PUT", fmt.Sprintf("/travel-agency/holidays/%d/itinerary", tour.ID), holidaydto.UpdateItineraryDTO{Stops: []holidaydto.StopDTO{
+			{Location: varna.ID, DayOffset: 2, Nights: 3},
+		}}, http.StatusOK, nil)
+
+		for query, want := range map[string][]int64{
+			"location=Varna":                         {coast.ID, tour.ID},
+			"location=sofia":                         {tour.ID},
+			fmt.Sprintf("location=%d", varna.ID):     {coast.ID, tour.ID},
+			"location=Bulgaria":                      {coast.ID, tour.ID},
+			"location=Burgas":                        nil,
+			"location=Varna&startDate=2026-08-01":    {tour.ID},
+			"location=Varna&country=GR":              nil,
+			fmt.Sprintf("location=%d", sofia.ID+100): nil,
+		} {
+			var holidays []holidaydto.ResponseHolidayDTO
+			api.expect("GET", "/travel-agency/holidays?"+query, nil, http.StatusOK, &holidays)
+			if ids := holidayIDs(holidays); fmt.Sprint(ids) != fmt.Sprint(want) {
+				t.Errorf("%s: holidays %v, want %v", query, ids, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+)
+
+func TestLocationsCRUD(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		created := api.createLocation("Varna")
+		if created.ID == 0 || created.City != "Varna" {
+			t.Fatalf("created location = %+v", created)
+		}
+		path := fmt.Sprintf("/travel-agency/locations/%d", created.ID)
+
+		var fetched locationdto.ResponseLocationDTO
+		api.expect("GET", path, nil, http.StatusOK, &fetched)
+		if !reflect.DeepEqual(fetched, created) {
+			t.Errorf("fetched %+v, want %+v", fetched, created)
+		}
+
+		api.createLocation("Burgas")
+		var all []locationdto.ResponseLocationDTO
+		api.expect("GET", "/travel-agency/locations", nil, http.StatusOK, &all)
+		if len(all) != 2 {
+			t.Errorf("listed %d locations, want 2", len(all))
+		}
+
+		update := locationdto.UpdateLocationDTO{
+			Number:   "7A",
+			Country:  "Bulgaria",
+			City:     "Sozopol",
+			Street:   "Republikanska",
+			ImageURL: "https://example.com/sozopol.jpg",
+		}
+		var updated locationdto.ResponseLocationDTO
+		api.write("PUT", path, update, http.StatusOK, &updated)
+		if updated.City != "Sozopol" || updated.Number != "7A" {
+			t.Errorf("updated location = %+v", updated)
+		}
+
+		api.expect("GET", path, nil, http.StatusOK, &fetched)
+		if fetched.City != "Sozopol" {
+			t.Errorf("update not persisted, got %+v", fetched)
+		}
+		if updated.CountryCode != "BG" || updated.Version != fetched.Version {
+			t.Errorf("PUT response = %+v, want the stored location %+v", updated, fetched)
+		}
+
+		api.write("DELETE", path, nil, http.StatusOK, nil)
+		api.expect("GET", path, nil, http.StatusNotFound, nil)
+		api.write("DELETE", path, nil, http.StatusNotFound, nil)
+	})
+}
+
+func TestLocationErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		api.expect("GET", "/travel-agency/locations/999", nil, http.StatusNotFound, nil)
+		api.expect("GET", "/travel-agency/locations/abc", nil, http.StatusNotFound, nil)
+
+		location := api.createLocation("Varna")
+		api.write("PUT", fmt.Sprintf("/travel-agency/locations/%d", location.ID), locationdto.UpdateLocationDTO{ID: location.ID + 1}, http.StatusBadRequest, nil)
+		api.write("PUT", "/travel-agency/locations/999", locationdto.UpdateLocationDTO{City: "Nowhere"}, http.StatusNotFound, nil)
+
+		resp := api.do("POST", "/travel-agency/locations", nil)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("empty body: status = %d, want 400", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	countrydto "github.com/nikolaypleshkov/uni-api/api/country/dto"
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	"github.com/nikolaypleshkov/uni-api/api/image"
+	imagedto "github.com/nikolaypleshkov/uni-api/api/image/dto"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	reservationdto "github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+	searchdto "github.com/nikolaypleshkov/uni-api/api/search/dto"
+	tagdto "github.com/nikolaypleshkov/uni-api/api/tag/dto"
+	templatedto "github.com/nikolaypleshkov/uni-api/api/template/dto"
+	"github.com/nikolaypleshkov/uni-api/health"
+	"github.com/nikolaypleshkov/uni-api/storage/memory"
+)
+
+// specSchemas maps every schema in openapi.json to the Go type it documents.
+var specSchemas = map[string]any{
+	"CreateLocationDTO":        locationdto.CreateLocationDTO{},
+	"UpdateLocationDTO":        locationdto.UpdateLocationDTO{},
+	"ResponseLocationDTO":      locationdto.ResponseLocationDTO{},
+	"LocationTranslationsDTO":  locationdto.LocationTranslationsDTO{},
+	"LocationTranslationDTO":   locationdto.LocationTranslationDTO{},
+	"CreateHolidayDTO":         holidaydto.CreateHolidayDTO{},
+	"UpdateHolidayDTO":         holidaydto.UpdateHolidayDTO{},
+	"ResponseHolidayDTO":       holidaydto.ResponseHolidayDTO{},
+	"Holiday":                  holiday.Holiday{},
+	"StopDTO":                  holidaydto.StopDTO{},
+	"UpdateItineraryDTO":       holidaydto.UpdateItineraryDTO{},
+	"ResponseStopDTO":          holidaydto.ResponseStopDTO{},
+	"ResponseItineraryDTO":     holidaydto.ResponseItineraryDTO{},
+	"HolidayTagsDTO":           holidaydto.HolidayTagsDTO{},
+	"AccommodationDTO":         holidaydto.AccommodationDTO{},
+	"Accommodation":            holiday.Accommodation{},
+	"HolidayTranslationsDTO":   holidaydto.HolidayTranslationsDTO{},
+	"HolidayTranslationDTO":    holidaydto.HolidayTranslationDTO{},
+	"ResponseAvailabilityDTO":  holidaydto.ResponseAvailabilityDTO{},
+	"AvailabilityDayDTO":       holidaydto.AvailabilityDayDTO{},
+	"AvailableHolidayDTO":      holidaydto.AvailableHolidayDTO{},
+	"ResponseHolidaySearchDTO": holidaydto.ResponseHolidaySearchDTO{},
+	"HolidayFacetsDTO":         holidaydto.HolidayFacetsDTO{},
+	"TagFacetDTO":              holidaydto.TagFacetDTO{},
+	"CountryFacetDTO":          holidaydto.CountryFacetDTO{},
+	"RangeFacetDTO":            holidaydto.RangeFacetDTO{},
+	"CreateReservationDTO":     reservationdto.CreateReservationDTO{},
+	"UpdateReservationDTO":     reservationdto.UpdateReservationDTO{},
+	"ResponseReservationDTO":   reservationdto.ResponseReservationDTO{},
+	"ResponseCountryDTO":       countrydto.ResponseCountryDTO{},
+	"ResponseCityDTO":          countrydto.ResponseCityDTO{},
+	"CreateTagDTO":             tagdto.CreateTagDTO{},
+	"UpdateTagDTO":             tagdto.UpdateTagDTO{},
+	"ResponseTagDTO":           tagdto.ResponseTagDTO{},
+	"CreateTemplateDTO":        templatedto.CreateTemplateDTO{},
+	"UpdateTemplateDTO":        templatedto.UpdateTemplateDTO{},
+	"ResponseTemplateDTO":      templatedto.ResponseTemplateDTO{},
+	"ResponseSearchDTO":        searchdto.ResponseSearchDTO{},
+	"ResponseResultDTO":        searchdto.ResponseResultDTO{},
+	"ResponseImageDTO":         imagedto.ResponseImageDTO{},
+	"ReorderImagesDTO":         imagedto.ReorderImagesDTO{},
+	"CheckResult":              health.CheckResult{},
+	"Report":                   health.Report{},
+}
+
+// The documentation routes are not part of the API they describe.
+var undocumentedRoutes = []string{"GET /openapi.json", "GET /docs"}
+
+type specSchema struct {
+	Ref                  string                `json:"$ref"`
+	Type                 string                `json:"type"`
+	Format               string                `json:"format"`
+	Nullable             bool                  `json:"nullable"`
+	Items                *specSchema           `json:"items"`
+	Properties           map[string]specSchema `json:"properties"`
+	AdditionalProperties *specSchema           `json:"additionalProperties"`
+}
+
+type spec struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]specSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+
+	var doc spec
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return doc
+}
+
+var pathVariable = regexp.MustCompile(`\{(\w+):[^}]+\}`)
+
+func TestOpenAPISpecMatchesRouter(t *testing.T) {
+	doc := loadSpec(t)
+
+	var routes []string
+	router := newRouter(DefaultConfig(), Deps{Services: NewServices(memory.New(), nil, nil, image.DefaultOptions())})
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		path := pathVariable.ReplaceAllString(template, "{$1}")
+		for _, method := range methods {
+			if route := method + " " + path; !slices.Contains(undocumentedRoutes, route) {
+				routes = append(routes, route)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method != "parameters" {
+				documented = append(documented, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+
+	for _, route := range routes {
+		if !slices.Contains(documented, route) {
+			t.Errorf("route %s is not documented in openapi.json", route)
+		}
+	}
+	for _, operation := range documented {
+		if !slices.Contains(routes, operation) {
+			t.Errorf("openapi.json documents %s, which is not routed", operation)
+		}
+	}
+}
+
+func TestOpenAPISchemasMatchDTOs(t *testing.T) {
+	doc := loadSpec(t)
+
+	for name := range doc.Components.Schemas {
+		if _, ok := specSchemas[name]; !ok {
+			t.Errorf("schema %s has no Go type in specSchemas", name)
+		}
+	}
+
+	for name, value := range specSchemas {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("schema %s is missing from openapi.json", name)
+			continue
+		}
+
+		typ := reflect.TypeOf(value)
+		fields := make(map[string]reflect.Type)
+		for i := range typ.NumField() {
+			field := typ.Field(i)
+			tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if tag != "" && tag != "-" {
+				fields[tag] = field.Type
+			}
+		}
+
+		for property, fieldType := range fields {
+			propertySchema, ok := schema.Properties[property]
+			if !ok {
+				t.Errorf("%s: field %q is not documented", name, property)
+				continue
+			}
+			if want := schemaFor(fieldType); !sameSchema(propertySchema, want) {
+				t.Errorf("%s.%s: documented as %+v, Go type %s", name, property, propertySchema, fieldType)
+			}
+		}
+		for property := range schema.Properties {
+			if _, ok := fields[property]; !ok {
+				t.Errorf("%s: documented property %q does not exist on %s", name, property, typ)
+			}
+		}
+	}
+}
+
+// schemaFor derives the schema a Go type is expected to be documented with.
+func schemaFor(typ reflect.Type) specSchema {
+	if typ == reflect.TypeOf(time.Time{}) {
+		return specSchema{Type: "string", Format: "date-time"}
+	}
+	switch typ.Kind() {
+	case reflect.Pointer:
+		schema := schemaFor(typ.Elem())
+		schema.Nullable = true
+		return schema
+	case reflect.String:
+		return specSchema{Type: "string"}
+	case reflect.Bool:
+		return specSchema{Type: "boolean"}
+	case reflect.Int32:
+		return specSchema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return specSchema{Type: "integer", Format: "int64"}
+	case reflect.Float64:
+		return specSchema{Type: "number", Format: "double"}
+	case reflect.Slice:
+		items := schemaFor(typ.Elem())
+		return specSchema{Type: "array", Items: &items}
+	case reflect.Map:
+		values := schemaFor(typ.Elem())
+		return specSchema{Type: "object", AdditionalProperties: &values}
+	case reflect.Struct:
+		for name, value := range specSchemas {
+			if reflect.TypeOf(value) == typ {
+				return specSchema{Ref: "#/components/schemas/" + name}
+			}
+		}
+	}
+	return specSchema{Type: "unsupported " + typ.String()}
+}
+
+func sameSchema(documented, want specSchema) bool {
+	if documented.Ref != want.Ref || documented.Type != want.Type || documented.Format != want.Format || documented.Nullable != want.Nullable {
+		return false
+	}
+	if want.Items != nil && (documented.Items == nil || !sameSchema(*documented.Items, *want.Items)) {
+		return false
+	}
+	if want.AdditionalProperties != nil && (documented.AdditionalProperties == nil || !sameSchema(*documented.AdditionalProperties, *want.AdditionalProperties)) {
+		return false
+	}
+	return true
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		var doc spec
+		api.expect("GET", "/openapi.json", nil, http.StatusOK, &doc)
+		if !strings.HasPrefix(doc.OpenAPI, "3.") {
+			t.Errorf("openapi version = %q", doc.OpenAPI)
+		}
+
+		resp := api.do("GET", "/docs", nil)
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+			t.Errorf("GET /docs: status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	})
+}
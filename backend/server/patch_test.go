@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	reservationdto "github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+)
+
+func TestPatchHoliday(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		created := api.createHoliday(location.ID, "2026-07-01", 7, 10)
+		path := fmt.Sprintf("/travel-agency/holidays/%d", created.ID)
+
+		var patched holidaydto.ResponseHolidayDTO
+		api.patch(path, `{"price": 399.5}`, http.StatusOK, &patched)
+		if patched.Price != "399.5" && patched.Price != "399.50" {
+			t.Errorf("price = %q, want 399.50", patched.Price)
+		}
+		if patched.Title != created.Title || patched.Duration != 7 || patched.FreeSlots != 10 || patched.Location.ID != location.ID {
+			t.Errorf("fields missing from the patch changed: %+v", patched)
+		}
+		if patched.StartDate != "2026-07-01T00:00:00Z" {
+			t.Errorf("startDate = %q", patched.StartDate)
+		}
+
+		api.patch(path, `{"title": null, "duration": 10}`, http.StatusOK, &patched)
+		if patched.Title != "" || patched.Duration != 10 {
+			t.Errorf("after clearing title: %+v", patched)
+		}
+
+		api.patch(path, `{"duration": "ten"}`, http.StatusBadRequest, nil)
+		api.patch(path, `["duration"]`, http.StatusBadRequest, nil)
+		api.patch("/travel-agency/holidays/999", `{"title": "Gone"}`, http.StatusNotFound, nil)
+
+		resp := api.send("PATCH", path, "text/plain", strings.NewReader(`{"title": "Plain"}`), api.ifMatch(path))
+		if resp.StatusCode != http.StatusUnsupportedMediaType {
+			t.Errorf("text/plain patch: status = %d, want 415", resp.StatusCode)
+		}
+	})
+}
+
+func TestPatchLocation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		created := api.createLocation("Varna")
+		path := fmt.Sprintf("/travel-agency/locations/%d", created.ID)
+
+		var patched locationdto.ResponseLocationDTO
+		api.patch(path, `{"city": "Balchik", "id": 999}`, http.StatusOK, &patched)
+		want := created
+		want.City = "Balchik"
+		want.Latitude, want.Longitude = ptr(43.4069), ptr(28.1631)
+		want.Version = 2
+		if !reflect.DeepEqual(patched, want) {
+			t.Errorf("patched = %+v, want %+v", patched, want)
+		}
+
+		var fetched locationdto.ResponseLocationDTO
+		api.expect("GET", path, nil, http.StatusOK, &fetched)
+		if !reflect.DeepEqual(fetched, want) {
+			t.Errorf("patch not persisted, got %+v", fetched)
+		}
+
+		api.patch("/travel-agency/locations/999", `{"city": "Nowhere"}`, http.StatusNotFound, nil)
+		api.patch(path, `{"city": 5}`, http.StatusBadRequest, nil)
+	})
+}
+
+func TestPatchReservation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		from := api.createHoliday(location.ID, "2026-07-01", 7, 2)
+		to := api.createHoliday(location.ID, "2026-08-01", 7, 2)
+
+		var created reservationdto.ResponseReservationDTO
+		api.expect("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+			PhoneNumber: "+359888123456",
+			ContactName: "Ivan Petrov",
+			HolidayID:   from.ID,
+		}, http.StatusOK, &created)
+		path := fmt.Sprintf("/travel-agency/reservations/%d", created.ID)
+
+		var patched reservationdto.ResponseReservationDTO
+		api.patch(path, `{"contact_name": "Maria Petrova"}`, http.StatusOK, &patched)
+		if patched.ContactName != "Maria Petrova" || patched.PhoneNumber != "+359888123456" || patched.Holiday.ID != from.ID {
+			t.Errorf("patched = %+v", patched)
+		}
+
+		api.patch(path, fmt.Sprintf(`{"holiday_id": %d}`, to.ID), http.StatusOK, &patched)
+		if patched.Holiday.ID != to.ID || patched.ContactName != "Maria Petrova" {
+			t.Errorf("after move: %+v", patched)
+		}
+		if slots := api.freeSlots(from.ID); slots != 2 {
+			t.Errorf("old holiday freeSlots = %d, want 2", slots)
+		}
+
+		api.patch(path, `{"holiday_id": 999}`, http.StatusUnprocessableEntity, nil)
+		api.patch("/travel-agency/reservations/999", `{"contact_name": "Nobody"}`, http.StatusNotFound, nil)
+	})
+}
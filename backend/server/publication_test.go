@@ -0,0 +1,168 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	countrydto "github.com/nikolaypleshkov/uni-api/api/country/dto"
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	reservationdto "github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+)
+
+func TestHolidayPublication(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		// createHoliday sets no status, so this checks the default too.
+		published := api.createHoliday(location.ID, "2027-07-01", 7, 10)
+
+		var draft holidaydto.ResponseHolidayDTO
+		api.expect("POST", "/travel-agency/holidays", map[string]any{
+			"title":     "Next summer",
+			"startDate": "2027-07-08",
+			"duration":  7,
+			"freeSlots": 10,
+			"price":     "499.90",
+			"location":  location.ID,
+			"status":    "draft",
+		}, http.StatusOK, &draft)
+		if draft.Status != "draft" || published.Status != "published" {
+			t.Errorf("statuses = %q, %q, want draft, published", draft.Status, published.Status)
+
+		}
+
+		for query, want := range map[string][]int64{
+			"":                        {published.ID},
+			"?status=draft":           {draft.ID},
+			"?status=Published,draft": {published.ID, draft.ID},
+			"?status=archived":        nil,
+		} {
+			var holidays []holidaydto.ResponseHolidayDTO
+			api.expect("GET", "/travel-agency/holidays"+query, nil, http.StatusOK, &holidays)
+			if got := holidayIDs(holidays); !slices.Equal(got, want) {
+				t.Errorf("GET holidays%s = %v, want %v", query, got, want)
+			}
+		}
+		api.expect("GET", "/travel-agency/holidays?status=hidden", nil, http.StatusBadRequest, nil)
+		if results := api.search("next summer"); len(results) != 0 {
+			t.Errorf("search found the draft: %+v", results)
+		}
+		var countries []countrydto.ResponseCountryDTO
+		api.expect("GET", "/travel-agency/countries", nil, http.StatusOK, &countries)
+		if len(countries) != 1 || countries[0].HolidayCount != 1 {
+			t.Errorf("countries = %+v, want one published holiday", countries)
+		}
+
+		reserve := reservationdto.CreateReservationDTO{ContactName: "Ivan Petrov", HolidayID: draft.ID}
+		api.expect("POST", "/travel-agency/reservations", reserve, http.StatusConflict, nil)
+
+		path := fmt.Sprintf("/travel-agency/holidays/%d", draft.ID)
+		api.write("PUT", path, holidaydto.UpdateHolidayDTO{
+			Title:     "Next summer in Varna",
+			StartDate: "2027-07-08",
+			Duration:  7,
+			FreeSlots: 10,
+			Price:     499.90,
+			Location:  location.ID,
+		}, http.StatusOK, nil)
+		var holiday holidaydto.ResponseHolidayDTO
+		api.expect("GET", path, nil, http.StatusOK, &holiday)
+		if holiday.Status != "draft" {
+			t.Errorf("PUT without a status changed it to %q", holiday.Status)
+		}
+
+		api.patch(path, `{"status": "published"}`, http.StatusOK, &holiday)
+		if holiday.Status != "published" {
+			t.Errorf("patched status = %q", holiday.Status)
+		}
+		api.expect("POST", "/travel-agency/reservations", reserve, http.StatusOK, nil)
+
+		api.patch(path, `{"status": "hidden"}`, http.StatusBadRequest, nil)
+		api.patch(path, `{"status": "draft", "publishAt": "2027-06-02T00:00:00Z", "unpublishAt": "2027-06-01T00:00:00Z"}`, http.StatusBadRequest, nil)
+	})
+}
+
+func TestFreeSlotsMetric(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		onSale := api.createHoliday(location.ID, "2099-07-01", 7, 10)
+		api.createHoliday(location.ID, "2020-07-01", 7, 10)
+		draft := api.createHoliday(location.ID, "2099-07-08", 7, 10)
+		api.patch(fmt.Sprintf("/travel-agency/holidays/%d", draft.ID), `{"status": "draft"}`, http.StatusOK, nil)
+
+		slots, err := api.services.Holidays.GetFreeSlots(t.Context())
+		if err != nil || len(slots) != 1 || slots[onSale.ID] != 10 {
+			t.Errorf("GetFreeSlots = %v, %v, want only holiday %d with 10", slots, err, onSale.ID)
+		}
+	})
+}
+
+func TestHolidaySchedule(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		create := func(startDate string, fields map[string]any) holidaydto.ResponseHolidayDTO {
+			body := map[string]any{
+				"title":     "Summer in " + startDate,
+				"startDate": startDate,
+				"duration":  7,
+				"freeSlots": 10,
+				"price":     "499.90",
+				"location":  location.ID,
+			}
+			for name, value := range fields {
+				body[name] = value
+			}
+			var created holidaydto.ResponseHolidayDTO
+			api.expect("POST", "/travel-agency/holidays", body, http.StatusOK, &created)
+			return created
+		}
+
+		due := create("2027-07-01", map[string]any{"publishAt": "2027-06-01T10:00:00+02:00"})
+		later := create("2027-07-08", map[string]any{"publishAt": "2027-06-02T00:00:00Z", "unpublishAt": "2027-06-30T00:00:00Z"})
+		withdrawn := create("2027-07-15", map[string]any{"status": "published", "publishAt": "2027-05-01T00:00:00Z", "unpublishAt": "2027-06-01T11:00:00Z"})
+		started := create("2027-05-20", map[string]any{"status": "published"})
+		if later.PublishAt == nil || !later.PublishAt.Equal(time.Date(2027, 6, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("publishAt = %v", later.PublishAt)
+		}
+		if withdrawn.PublishAt != nil {
+			t.Errorf("published holiday kept publishAt %v", withdrawn.PublishAt)
+		}
+
+		dueETag := api.etag(fmt.Sprintf("/travel-agency/holidays/%d", due.ID))
+		changed, err := api.services.Holidays.ApplySchedule(t.Context(), time.Date(2027, 6, 1, 12, 0, 0, 0, time.UTC))
+		if err != nil || changed != 3 {
+			t.Fatalf("ApplySchedule = %d, %v, want 3 changes", changed, err)
+		}
+		if api.etag(fmt.Sprintf("/travel-agency/holidays/%d", due.ID)) == dueETag {
+			t.Error("publishing did not change the ETag")
+		}
+
+		for _, want := range []struct {
+			holiday holidaydto.ResponseHolidayDTO
+			status  string
+		}{
+			{due, "published"},
+			{later, "draft"},
+			{withdrawn, "draft"},
+			{started, "archived"},
+		} {
+			var holiday holidaydto.ResponseHolidayDTO
+			api.expect("GET", fmt.Sprintf("/travel-agency/holidays/%d", want.holiday.ID), nil, http.StatusOK, &holiday)
+			if holiday.Status != want.status {
+				t.Errorf("holiday %d: status = %q, want %q", holiday.ID, holiday.Status, want.status)
+			}
+		}
+
+		var holidays []holidaydto.ResponseHolidayDTO
+		api.expect("GET", "/travel-agency/holidays", nil, http.StatusOK, &holidays)
+		if got := holidayIDs(holidays); !slices.Equal(got, []int64{due.ID}) {
+			t.Errorf("listed %v, want only %d", got, due.ID)
+		}
+
+		if changed, err := api.services.Holidays.ApplySchedule(t.Context(), time.Date(2027, 6, 1, 12, 0, 0, 0, time.UTC)); err != nil || changed != 0 {
+			t.Errorf("second ApplySchedule = %d, %v, want no changes", changed, err)
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	reservationdto "github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+)
+
+func TestReservationsCRUD(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		holiday := api.createHoliday(location.ID, "2026-07-01", 7, 3)
+
+		var created reservationdto.ResponseReservationDTO
+		api.expect("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+			PhoneNumber: "+359888123456",
+			ContactName: "Ivan Petrov",
+			HolidayID:   holiday.ID,
+		}, http.StatusOK, &created)
+		if created.ID == 0 || created.Holiday.ID != holiday.ID {
+			t.Fatalf("created reservation = %+v", created)
+		}
+		path := fmt.Sprintf("/travel-agency/reservations/%d", created.ID)
+
+		var fetched reservationdto.ResponseReservationDTO
+		api.expect("GET", path, nil, http.StatusOK, &fetched)
+		if fetched.ContactName != "Ivan Petrov" || fetched.PhoneNumber != "+359888123456" {
+			t.Errorf("fetched reservation = %+v", fetched)
+		}
+
+		var all []reservationdto.ResponseReservationDTO
+		api.expect("GET", "/travel-agency/reservations", nil, http.StatusOK, &all)
+		if len(all) != 1 {
+			t.Errorf("listed %d reservations, want 1", len(all))
+		}
+
+		if slots := api.freeSlots(holiday.ID); slots != 2 {
+			t.Errorf("freeSlots after reserving = %d, want 2", slots)
+		}
+
+		var updated reservationdto.ResponseReservationDTO
+		api.write("PUT", path, reservationdto.UpdateReservationDTO{
+			PhoneNumber: "+359888654321",
+			ContactName: "Maria Petrova",
+		}, http.StatusOK, &updated)
+		if updated.ContactName != "Maria Petrova" || updated.PhoneNumber != "+359888654321" || updated.Holiday.ID != holiday.ID {
+			t.Errorf("updated reservation = %+v", updated)
+		}
+
+		api.expect("GET", path, nil, http.StatusOK, &fetched)
+		if fetched.ContactName != "Maria Petrova" {
+			t.Errorf("update not persisted, got %+v", fetched)
+		}
+
+		api.write("DELETE", path, nil, http.StatusOK, nil)
+		api.expect("GET", path, nil, http.StatusNotFound, nil)
+		api.write("DELETE", path, nil, http.StatusNotFound, nil)
+
+		if slots := api.freeSlots(holiday.ID); slots != 3 {
+			t.Errorf("freeSlots after cancelling = %d, want 3", slots)
+		}
+	})
+}
+
+func TestReservationErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		full := api.createHoliday(location.ID, "2026-07-01", 7, 0)
+
+		api.expect("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+			ContactName: "Nobody",
+			HolidayID:   999,
+		}, http.StatusUnprocessableEntity, nil)
+		api.expect("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+			ContactName: "Too late",
+			HolidayID:   full.ID,
+		}, http.StatusConflict, nil)
+
+		api.expect("GET", "/travel-agency/reservations/999", nil, http.StatusNotFound, nil)
+		api.expect("GET", "/travel-agency/reservations/abc", nil, http.StatusBadRequest, nil)
+		api.write("PUT", "/travel-agency/reservations/999", reservationdto.UpdateReservationDTO{ContactName: "Nobody"}, http.StatusNotFound, nil)
+
+		resp := api.do("POST", "/travel-agency/reservations", nil)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("empty body: status = %d, want 400", resp.StatusCode)
+		}
+	})
+}
+
+func TestMoveReservation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		from := api.createHoliday(location.ID, "2026-07-01", 7, 3)
+		full := api.createHoliday(location.ID, "2026-07-15", 7, 0)
+
+		var to holidaydto.ResponseHolidayDTO
+		api.expect("POST", "/travel-agency/holidays", map[string]any{
+			"title":     "Cheaper week",
+			"startDate": "2026-08-01",
+			"duration":  7,
+			"freeSlots": 2,
+			"price":     "449.90",
+			"location":  location.ID,
+		}, http.StatusOK, &to)
+
+		var created reservationdto.ResponseReservationDTO
+		api.expect("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+			PhoneNumber: "+359888123456",
+			ContactName: "Ivan Petrov",
+			HolidayID:   from.ID,
+		}, http.StatusOK, &created)
+		path := fmt.Sprintf("/travel-agency/reservations/%d", created.ID)
+
+		move := func(holidayID int64) reservationdto.UpdateReservationDTO {
+			return reservationdto.UpdateReservationDTO{
+				PhoneNumber: "+359888123456",
+				ContactName: "Ivan Petrov",
+				HolidayID:   holidayID,
+			}
+		}
+
+		api.write("PUT", path, move(999), http.StatusUnprocessableEntity, nil)
+		api.write("PUT", path, move(full.ID), http.StatusConflict, nil)
+		api.write("PUT", path, reservationdto.UpdateReservationDTO{ID: created.ID + 1, HolidayID: to.ID}, http.StatusBadRequest, nil)
+		if slots := api.freeSlots(from.ID); slots != 2 {
+			t.Fatalf("failed moves changed the old holiday's slots to %d, want 2", slots)
+		}
+
+		var moved reservationdto.ResponseReservationDTO
+		api.write("PUT", path, move(to.ID), http.StatusOK, &moved)
+		if moved.Holiday.ID != to.ID {
+			t.Errorf("reservation is on holiday %d, want %d", moved.Holiday.ID, to.ID)
+		}
+		if moved.PriceDifference != "-50.00" {
+			t.Errorf("priceDifference = %q, want -50.00", moved.PriceDifference)
+		}
+		if slots := api.freeSlots(from.ID); slots != 3 {
+			t.Errorf("old holiday freeSlots = %d, want 3", slots)
+		}
+		if slots := api.freeSlots(to.ID); slots != 1 {
+			t.Errorf("new holiday freeSlots = %d, want 1", slots)
+		}
+
+		var unchanged reservationdto.ResponseReservationDTO
+		api.write("PUT", path, move(to.ID), http.StatusOK, &unchanged)
+		if unchanged.PriceDifference != "" {
+			t.Errorf("priceDifference without a move = %q", unchanged.PriceDifference)
+		}
+		if slots := api.freeSlots(to.ID); slots != 1 {
+			t.Errorf("re-saving changed freeSlots to %d, want 1", slots)
+		}
+	})
+}
+
+// TestConcurrentMoves moves several reservations at once onto a holiday with
+// fewer free slots than movers.
+func TestConcurrentMoves(t *testing.T) {
+	const movers, slots = 6, 2
+
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		from := api.createHoliday(location.ID, "2026-07-01", 7, movers)
+		to := api.createHoliday(location.ID, "2026-08-01", 7, slots)
+
+		var ids []int64
+		for i := range movers {
+			var created reservationdto.ResponseReservationDTO
+			api.expect("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+				ContactName: fmt.Sprintf("Guest %d", i),
+				HolidayID:   from.ID,
+			}, http.StatusOK, &created)
+			ids = append(ids, created.ID)
+		}
+
+		var wg sync.WaitGroup
+		statuses := make(chan int, movers)
+		for i, id := range ids {
+			path := fmt.Sprintf("/travel-agency/reservations/%d", id)
+			header := api.ifMatch(path)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp := api.doWithHeader("PUT", path, reservationdto.UpdateReservationDTO{
+					ContactName: fmt.Sprintf("Guest %d", i),
+					HolidayID:   to.ID,
+				}, header)
+				statuses <- resp.StatusCode
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		counts := make(map[int]int)
+		for status := range statuses {
+			counts[status]++
+		}
+		if counts[http.StatusOK] != slots || counts[http.StatusConflict] != movers-slots {
+			t.Fatalf("statuses = %v, want %d OK and %d Conflict", counts, slots, movers-slots)
+		}
+		if free := api.freeSlots(to.ID); free != 0 {
+			t.Errorf("target freeSlots = %d, want 0", free)
+		}
+		if free := api.freeSlots(from.ID); free != slots {
+			t.Errorf("source freeSlots = %d, want %d", free, slots)
+		}
+	})
+}
+
+// TestConcurrentReservations races more bookings than there are slots and
+// checks that the store never oversells a holiday.
+func TestConcurrentReservations(t *testing.T) {
+	const slots, attempts = 5, 20
+
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		holiday := api.createHoliday(location.ID, "2026-07-01", 7, slots)
+
+		var wg sync.WaitGroup
+		statuses := make(chan int, attempts)
+		for i := range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp := api.do("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+					PhoneNumber: fmt.Sprintf("+3598880000%02d", i),
+					ContactName: fmt.Sprintf("Guest %d", i),
+					HolidayID:   holiday.ID,
+				})
+				statuses <- resp.StatusCode
+			}()
+		}
+		wg.Wait()
+		close(statuses)
+
+		counts := make(map[int]int)
+		for status := range statuses {
+			counts[status]++
+		}
+		if counts[http.StatusOK] != slots || counts[http.StatusConflict] != attempts-slots {
+			t.Fatalf("statuses = %v, want %d OK and %d Conflict", counts, slots, attempts-slots)
+		}
+
+		if free := api.freeSlots(holiday.ID); free != 0 {
+			t.Errorf("freeSlots = %d, want 0", free)
+		}
+
+		var reservations []reservationdto.ResponseReservationDTO
+		api.expect("GET", "/travel-agency/reservations", nil, http.StatusOK, &reservations)
+		if len(reservations) != slots {
+			t.Fatalf("stored %d reservations, want %d", len(reservations), slots)
+		}
+
+		api.write("DELETE", fmt.Sprintf("/travel-agency/reservations/%d", reservations[0].ID), nil, http.StatusOK, nil)
+		api.expect("POST", "/travel-agency/reservations", reservationdto.CreateReservationDTO{
+			ContactName: "Waiting list",
+			HolidayID:   holiday.ID,
+		}, http.StatusOK, nil)
+	})
+}
+
+func (api *testAPI) freeSlots(holidayID int64) int32 {
+	api.t.Helper()
+
+	var holiday holidaydto.ResponseHolidayDTO
+	api.expect("GET", fmt.Sprintf("/travel-agency/holidays/%d", holidayID), nil, http.StatusOK, &holiday)
+	return holiday.FreeSlots
+}
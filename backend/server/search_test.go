@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	searchdto "github.com/nikolaypleshkov/uni-api/api/search/dto"
+)
+
+func TestSearch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		sunnyBeach := api.createLocation("Sunny Beach")
+		varna := api.createLocation("Varna")
+		var athens locationdto.ResponseLocationDTO
+		api.expect("POST", "/travel-agency/locations", locationdto.CreateLocationDTO{City: "Athens", Country: "GR"}, http.StatusOK, &athens)
+
+		july := api.createHoliday(sunnyBeach.ID, "2026-07-01", 7, 10)
+		august := api.createHoliday(sunnyBeach.ID, "2026-08-05", 7, 10)
+		cityBreak := api.createHoliday(varna.ID, "2026-07-10", 3, 10)
+		api.patch(fmt.Sprintf("/travel-agency/holidays/%d", july.ID), `{"title": "Beach Week"}`, http.StatusOK, nil)
+		api.patch(fmt.Sprintf("/travel-agency/holidays/%d", august.ID), `{"title": "Beach Week"}`, http.StatusOK, nil)
+		api.patch(fmt.Sprintf("/travel-agency/holidays/%d", cityBreak.ID), `{"title": "Varna City Break"}`, http.StatusOK, nil)
+		api.createTag("family")
+		api.tagHoliday(cityBreak.ID, "family")
+
+		results := api.search("Sunny beach BULGARIA july")
+		if len(results) != 1 || results[0].Type != "holiday" || results[0].Holiday == nil || results[0].Holiday.ID != july.ID {
+			t.Fatalf("results = %+v, want the July holiday", results)
+		}
+		if want := "<mark>Beach</mark> Week · <mark>Sunny</mark> <mark>Beach</mark> · <mark>Bulgaria</mark>"; results[0].Highlight != want {
+			t.Errorf("highlight = %q, want %q", results[0].Highlight, want)
+		}
+
+		for query, want := range map[string][]string{
+			"sun bea":       {fmt.Sprint("holiday ", july.ID), fmt.Sprint("holiday ", august.ID), fmt.Sprint("location ", sunnyBeach.ID)},
+			"varn":          {fmt.Sprint("holiday ", cityBreak.ID), fmt.Sprint("location ", varna.ID)},
+			"famil":         {fmt.Sprint("holiday ", cityBreak.ID)},
+			"athens greece": {fmt.Sprint("location ", athens.ID)},
+			"august 2026":   {fmt.Sprint("holiday ", august.ID)},
+			"ski":           nil,
+		} {
+			results := api.search(query)
+			if got := hitKeys(results); !sameElements(got, want) {
+				t.Errorf("%q: results %v, want %v", query, got, want)
+			}
+			for i := 1; i < len(results); i++ {
+				if results[i].Rank > results[i-1].Rank {
+					t.Errorf("%q: results are not ordered by rank: %+v", query, results)
+				}
+			}
+		}
+
+		var response searchdto.ResponseSearchDTO
+		api.expect("GET", "/travel-agency/search?q=beach&limit=1", nil, http.StatusOK, &response)
+		if response.Query != "beach" || len(response.Results) != 1 {
+			t.Errorf("limited search = %+v", response)
+		}
+		api.expect("GET", "/travel-agency/search?q=ski", nil, http.StatusOK, &response)
+		if response.Results == nil || len(response.Results) != 0 {
+			t.Errorf("results without matches = %#v, want []", response.Results)
+		}
+		for _, query := range []string{"", "q=", "q=%21%3F", "q=beach&limit=0", "q=beach&limit=101", "q=beach&limit=many"} {
+			api.expect("GET", "/travel-agency/search?"+query, nil, http.StatusBadRequest, nil)
+		}
+
+		// Edits show up in the next search.
+		api.patch(fmt.Sprintf("/travel-agency/locations/%d", varna.ID), `{"city": "Golden Sands"}`, http.StatusOK, nil)
+		if got := hitKeys(api.search("golden")); !sameElements(got, []string{fmt.Sprint("holiday ", cityBreak.ID), fmt.Sprint("location ", varna.ID)}) {
+			t.Errorf("after renaming the city: results %v", got)
+		}
+		api.write("DELETE", fmt.Sprintf("/travel-agency/holidays/%d", cityBreak.ID), nil, http.StatusNoContent, nil)
+		if got := hitKeys(api.search("famil")); len(got) != 0 {
+			t.Errorf("after deleting the holiday: results %v", got)
+		}
+	})
+}
+
+func (api *testAPI) search(query string) []searchdto.ResponseResultDTO {
+	api.t.Helper()
+
+	var response searchdto.ResponseSearchDTO
+	api.expect("GET", "/travel-agency/search?q="+url.QueryEscape(query), nil, http.StatusOK, &response)
+	return response.Results
+}
+
+func hitKeys(results []searchdto.ResponseResultDTO) []string {
+	var keys []string
+	for _, result := range results {
+		keys = append(keys, fmt.Sprint(result.Type, " ", result.ID))
+	}
+	return keys
+}
+
+func sameElements(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
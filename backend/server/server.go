@@ -0,0 +1,158 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/nikolaypleshkov/uni-api/api/country"
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/image"
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	"github.com/nikolaypleshkov/uni-api/api/reservation"
+	"github.com/nikolaypleshkov/uni-api/api/search"
+	"github.com/nikolaypleshkov/uni-api/api/tag"
+	"github.com/nikolaypleshkov/uni-api/api/template"
+	"github.com/nikolaypleshkov/uni-api/health"
+	"github.com/nikolaypleshkov/uni-api/idempotency"
+	"github.com/nikolaypleshkov/uni-api/logging"
+	"github.com/nikolaypleshkov/uni-api/metrics"
+	"github.com/nikolaypleshkov/uni-api/tracing"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Config struct {
+	HealthCheckTimeout   time.Duration
+	AllowedOrigins       []string
+	IdempotencyRetention time.Duration
+	// IdempotencyMaxBodyBytes limits the bodies of requests with an
+	// Idempotency-Key, which are read into memory.
+	IdempotencyMaxBodyBytes int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		HealthCheckTimeout:      3 * time.Second,
+		AllowedOrigins:          []string{"*"},
+		IdempotencyRetention:    24 * time.Hour,
+		IdempotencyMaxBodyBytes: 1 << 20,
+	}
+}
+
+type Deps struct {
+	Services Services
+	Checks   []health.Check
+	// IdempotencyKeys backs the Idempotency-Key header on POST requests. The
+	// header is ignored when it is nil.
+	IdempotencyKeys idempotency.Store
+}
+
+// NewServer wires the controllers for deps into a router with the full
+// middleware chain and returns it ready to be served.
+func NewServer(cfg Config, deps Deps) http.Handler {
+	router := newRouter(cfg, deps)
+
+	corsHandler := handlers.CORS(
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "If-Match", "If-None-Match", idempotency.Header, logging.RequestIDHeader}),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+		handlers.AllowedOrigins(cfg.AllowedOrigins),
+		handlers.ExposedHeaders([]string{"ETag", idempotency.ReplayedHeader, logging.RequestIDHeader}),
+	)(router)
+
+	return logging.RequestID(corsHandler)
+}
+
+func newRouter(cfg Config, deps Deps) *mux.Router {
+	holidayController := holiday.NewController(deps.Services.Holidays)
+	locationController := location.NewLocationController(deps.Services.Locations)
+	reservationController := reservation.NewReservationController(deps.Services.Reservations)
+	countryController := country.NewController(deps.Services.Countries)
+	imageController := image.NewController(deps.Services.Images)
+	tagController := tag.NewController(deps.Services.Tags)
+	templateController := template.NewController(deps.Services.Templates)
+	searchController := search.NewController(deps.Services.Search)
+	healthController := health.NewController(cfg.HealthCheckTimeout, deps.Checks...)
+
+	router := mux.NewRouter()
+	router.Use(tracing.Middleware(), logging.AccessLog, metrics.Middleware)
+	// Requests no route matches skip the middleware above, so the fallback
+	// handlers are wrapped to be logged and counted as unmatched.
+	router.NotFoundHandler = logging.AccessLog(metrics.Middleware(http.NotFoundHandler()))
+	router.MethodNotAllowedHandler = logging.AccessLog(metrics.Middleware(http.HandlerFunc(methodNotAllowed)))
+	if deps.IdempotencyKeys != nil {
+		router.Use(idempotency.Middleware(deps.IdempotencyKeys, cfg.IdempotencyRetention, cfg.IdempotencyMaxBodyBytes))
+	}
+
+	router.HandleFunc("/health/live", healthController.Live).Methods("GET")
+	router.HandleFunc("/health/ready", healthController.Ready).Methods("GET")
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+	router.HandleFunc("/openapi.json", serveOpenAPISpec).Methods("GET")
+	router.HandleFunc("/docs", serveDocs).Methods("GET")
+
+	router.HandleFunc("/travel-agency/holidays", holidayController.CreateHoliday).Methods("POST")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}", holidayController.DeleteHoliday).Methods("DELETE")
+	router.HandleFunc("/travel-agency/holidays", holidayController.GetHolidays).Methods("GET")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}", holidayController.GetHoliday).Methods("GET")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}", holidayController.UpdateHoliday).Methods("PUT")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}", holidayController.PatchHoliday).Methods("PATCH")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}/itinerary", holidayController.GetItinerary).Methods("GET")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}/itinerary", holidayController.ReplaceItinerary).Methods("PUT")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}/itinerary", holidayController.DeleteItinerary).Methods("DELETE")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}/tags", holidayController.GetHolidayTags).Methods("GET")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}/tags", holidayController.ReplaceHolidayTags).Methods("PUT")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}/translations", holidayController.GetHolidayTranslations).Methods("GET")
+	router.HandleFunc("/travel-agency/holidays/{holidayId}/translations", holidayController.ReplaceHolidayTranslations).Methods("PUT")
+	router.HandleFunc("/travel-agency/availability", holidayController.GetAvailability).Methods("GET")
+
+	router.HandleFunc("/travel-agency/locations", locationController.CreateLocation).Methods("POST")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}", locationController.DeleteLocation).Methods("DELETE")
+	router.HandleFunc("/travel-agency/locations", locationController.GetAllLocations).Methods("GET")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}", locationController.GetLocation).Methods("GET")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}", locationController.UpdateLocation).Methods("PUT")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}", locationController.PatchLocation).Methods("PATCH")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}/translations", locationController.GetTranslations).Methods("GET")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}/translations", locationController.ReplaceTranslations).Methods("PUT")
+
+	router.HandleFunc("/travel-agency/reservations", reservationController.CreateReservation).Methods("POST")
+	router.HandleFunc("/travel-agency/reservations/{reservationId}", reservationController.GetReservationByID).Methods("GET")
+	router.HandleFunc("/travel-agency/reservations", reservationController.GetAllReservations).Methods("GET")
+	router.HandleFunc("/travel-agency/reservations/{reservationId}", reservationController.DeleteReservation).Methods("DELETE")
+	router.HandleFunc("/travel-agency/reservations/{reservationId}", reservationController.UpdateReservation).Methods("PUT")
+	router.HandleFunc("/travel-agency/reservations/{reservationId}", reservationController.PatchReservation).Methods("PATCH")
+
+	router.HandleFunc("/travel-agency/countries", countryController.GetCountries).Methods("GET")
+	router.HandleFunc("/travel-agency/countries/{countryCode}/cities", countryController.GetCities).Methods("GET")
+
+	router.HandleFunc("/travel-agency/tags", tagController.CreateTag).Methods("POST")
+	router.HandleFunc("/travel-agency/tags", tagController.GetTags).Methods("GET")
+	router.HandleFunc("/travel-agency/tags/{tagId:[0-9]+}", tagController.GetTag).Methods("GET")
+	router.HandleFunc("/travel-agency/tags/{tagId:[0-9]+}", tagController.UpdateTag).Methods("PUT")
+	router.HandleFunc("/travel-agency/tags/{tagId:[0-9]+}", tagController.DeleteTag).Methods("DELETE")
+
+	router.HandleFunc("/travel-agency/templates", templateController.CreateTemplate).Methods("POST")
+	router.HandleFunc("/travel-agency/templates", templateController.GetTemplates).Methods("GET")
+	router.HandleFunc("/travel-agency/templates/{templateId:[0-9]+}", templateController.GetTemplate).Methods("GET")
+	router.HandleFunc("/travel-agency/templates/{templateId:[0-9]+}", templateController.UpdateTemplate).Methods("PUT")
+	router.HandleFunc("/travel-agency/templates/{templateId:[0-9]+}", templateController.DeleteTemplate).Methods("DELETE")
+
+	router.HandleFunc("/travel-agency/search", searchController.Search).Methods("GET")
+
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}/images", imageController.AddImages).Methods("POST")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}/images", imageController.GetImages).Methods("GET")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}/images/order", imageController.ReorderImages).Methods("PUT")
+	router.HandleFunc("/travel-agency/locations/{locationId:[0-9]+}/images/{imageId:[0-9]+}", imageController.DeleteImage).Methods("DELETE")
+
+	router.HandleFunc("/travel-agency/holidays/{holidayId:[0-9]+}/images", imageController.AddImages).Methods("POST")
+	router.HandleFunc("/travel-agency/holidays/{holidayId:[0-9]+}/images", imageController.GetImages).Methods("GET")
+	router.HandleFunc("/travel-agency/holidays/{holidayId:[0-9]+}/images/order", imageController.ReorderImages).Methods("PUT")
+	router.HandleFunc("/travel-agency/holidays/{holidayId:[0-9]+}/images/{imageId:[0-9]+}", imageController.DeleteImage).Methods("DELETE")
+
+	router.HandleFunc("/images/{key}", imageController.GetFile).Methods("GET")
+
+	return router
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,351 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	"github.com/nikolaypleshkov/uni-api/api/image"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	"github.com/nikolaypleshkov/uni-api/blob"
+	"github.com/nikolaypleshkov/uni-api/database"
+	"github.com/nikolaypleshkov/uni-api/geo"
+	"github.com/nikolaypleshkov/uni-api/health"
+	"github.com/nikolaypleshkov/uni-api/mergepatch"
+	"github.com/nikolaypleshkov/uni-api/storage/memory"
+	"github.com/nikolaypleshkov/uni-api/storage/sqlstore"
+)
+
+// The end-to-end tests run the real router against every storage backend
+// available: the in-memory store and a temporary SQLite file always, and a
+// throwaway schema in PostgreSQL when TEST_DATABASE_URL points at a server.
+
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+type testBackend struct {
+	name string
+	open func(t *testing.T) (Store, []health.Check)
+}
+
+func testBackends() []testBackend {
+	backends := []testBackend{
+		{name: "memory", open: openMemoryStore},
+		{name: "sqlite", open: openSQLiteStore},
+	}
+	if os.Getenv("TEST_DATABASE_URL") != "" {
+		backends = append(backends, testBackend{name: "postgres", open: openPostgresStore})
+	}
+	return backends
+}
+
+func openMemoryStore(t *testing.T) (Store, []health.Check) {
+	return memory.New(), nil
+}
+
+func openSQLiteStore(t *testing.T) (Store, []health.Check) {
+	path := filepath.Join(t.TempDir(), "travel.db")
+	dialect, dsn, err := database.ParseURL("sqlite://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return openSQLStore(t, dialect, dsn)
+}
+
+func openPostgresStore(t *testing.T) (Store, []health.Check) {
+	ctx := context.Background()
+	baseURL := os.Getenv("TEST_DATABASE_URL")
+
+	admin, err := database.Open(ctx, database.Postgres, baseURL, testDBOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("e2e_%d", time.Now().UnixNano())
+	if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin.ExecContext(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	dsn, err := url.Parse(baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := dsn.Query()
+	query.Set("search_path", schema)
+	dsn.RawQuery = query.Encode()
+
+	return openSQLStore(t, database.Postgres, dsn.String())
+}
+
+func openSQLStore(t *testing.T, dialect database.Dialect, dsn string) (Store, []health.Check) {
+	ctx := context.Background()
+
+	db, err := database.Open(ctx, dialect, dsn, testDBOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := database.Migrate(ctx, db, dialect); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []health.Check{health.DatabaseCheck(db), health.MigrationsCheck(db)}
+	return sqlstore.New(db, dialect), checks
+}
+
+func testDBOptions() database.Options {
+	opts := database.DefaultOptions()
+	opts.Retry.MaxAttempts = 1
+	return opts
+}
+
+var gazetteer = func() *geo.Gazetteer {
+	g, err := geo.NewGazetteer()
+	if err != nil {
+		panic(err)
+	}
+	return g
+}()
+
+type testAPI struct {
+	t        *testing.T
+	server   *httptest.Server
+	services Services
+}
+
+// forEachBackend runs fn once per backend against a fresh server and store.
+func forEachBackend(t *testing.T, fn func(t *testing.T, api *testAPI)) {
+	for _, backend := range testBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			store, checks := backend.open(t)
+			blobs, err := blob.NewLocalStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { blobs.Close() })
+
+			services := NewServices(store, gazetteer, blobs, image.DefaultOptions())
+			server := httptest.NewServer(NewServer(DefaultConfig(), Deps{
+				Services:        services,
+				Checks:          checks,
+				IdempotencyKeys: store.IdempotencyKeys(),
+			}))
+			t.Cleanup(server.Close)
+
+			fn(t, &testAPI{t: t, server: server, services: services})
+		})
+	}
+}
+
+func (api *testAPI) do(method, path string, body any) *http.Response {
+	api.t.Helper()
+	return api.doWithHeader(method, path, body, nil)
+}
+
+func (api *testAPI) doWithHeader(method, path string, body any, header http.Header) *http.Response {
+	api.t.Helper()
+
+	if body == nil {
+		return api.send(method, path, "", nil, header)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		api.t.Fatal(err)
+	}
+	return api.send(method, path, "application/json", bytes.NewReader(payload), header)
+}
+
+func (api *testAPI) send(method, path, contentType string, body io.Reader, header http.Header) *http.Response {
+	api.t.Helper()
+
+	req, err := http.NewRequest(method, api.server.URL+path, body)
+	if err != nil {
+		api.t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := api.server.Client().Do(req)
+	if err != nil {
+		api.t.Fatal(err)
+	}
+	api.t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+// expect sends a request, fails the test unless the response has the wanted
+// status and decodes the JSON body into out when out is not nil.
+func (api *testAPI) expect(method, path string, body any, status int, out any) {
+	api.t.Helper()
+	api.check(api.do(method, path, body), status, out)
+}
+
+// write is expect for PUT and DELETE: it sends the resource's current ETag
+// in If-Match, or no If-Match when the resource does not exist.
+func (api *testAPI) write(method, path string, body any, status int, out any) {
+	api.t.Helper()
+	api.check(api.doWithHeader(method, path, body, api.ifMatch(path)), status, out)
+}
+
+// patch sends a JSON merge patch with the current ETag and checks the
+// response like expect.
+func (api *testAPI) patch(path, patch string, status int, out any) {
+	api.t.Helper()
+	api.check(api.send("PATCH", path, mergepatch.MediaType, strings.NewReader(patch), api.ifMatch(path)), status, out)
+}
+
+// etag returns the ETag of a GET on path, or "" when there is none.
+func (api *testAPI) etag(path string) string {
+	api.t.Helper()
+	return api.do("GET", path, nil).Header.Get("ETag")
+}
+
+func (api *testAPI) ifMatch(path string) http.Header {
+	api.t.Helper()
+
+	tag := api.etag(path)
+	if tag == "" {
+		return nil
+	}
+	return http.Header{"If-Match": {tag}}
+}
+
+func (api *testAPI) check(resp *http.Response, status int, out any) {
+	api.t.Helper()
+
+	method, path := resp.Request.Method, resp.Request.URL.Path
+	if resp.StatusCode != status {
+		message, _ := io.ReadAll(resp.Body)
+		api.t.Fatalf("%s %s: status = %d, want %d (body %q)", method, path, resp.StatusCode, status, message)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			api.t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+}
+
+func (api *testAPI) createLocation(city string) locationdto.ResponseLocationDTO {
+	api.t.Helper()
+
+	var created locationdto.ResponseLocationDTO
+	api.expect("POST", "/travel-agency/locations", locationdto.CreateLocationDTO{
+		Number:   "12",
+		Country:  "Bulgaria",
+		City:     city,
+		Street:   "Primorski",
+		ImageURL: "https://example.com/" + city + ".jpg",
+	}, http.StatusOK, &created)
+
+	return created
+}
+
+func (api *testAPI) createHoliday(locationID int64, startDate string, duration, freeSlots int32) holidaydto.ResponseHolidayDTO {
+	api.t.Helper()
+
+	var created holidaydto.ResponseHolidayDTO
+	api.expect("POST", "/travel-agency/holidays", map[string]any{
+		"title":     "Summer in " + startDate,
+		"startDate": startDate,
+		"duration":  duration,
+		"freeSlots": freeSlots,
+		"price":     "499.90",
+		"location":  locationID,
+	}, http.StatusOK, &created)
+
+	return created
+}
+
+func TestHealth(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		var live health.Report
+		api.expect("GET", "/health/live", nil, http.StatusOK, &live)
+		if live.Status != health.StatusOK {
+			t.Errorf("live status = %q", live.Status)
+		}
+
+		var ready health.Report
+		api.expect("GET", "/health/ready", nil, http.StatusOK, &ready)
+		if ready.Status != health.StatusOK {
+			t.Errorf("ready status = %q, checks %+v", ready.Status, ready.Checks)
+		}
+	})
+}
+
+func TestRequestID(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		req, _ := http.NewRequest("GET", api.server.URL+"/health/live", nil)
+		req.Header.Set("X-Request-ID", "trace-me-42")
+		resp, err := api.server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Get("X-Request-ID"); got != "trace-me-42" {
+			t.Errorf("X-Request-ID = %q, want the client's id", got)
+		}
+		if generated := api.do("GET", "/health/live", nil).Header.Get("X-Request-ID"); generated == "" {
+			t.Error("no X-Request-ID assigned")
+		}
+	})
+}
+
+// TestUnmatchedRequestMetrics checks that requests no route serves are
+// still counted, under the unmatched route label.
+func TestUnmatchedRequestMetrics(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		api.expect("GET", "/travel-agency/nowhere", nil, http.StatusNotFound, nil)
+		api.expect("DELETE", "/health/live", nil, http.StatusMethodNotAllowed, nil)
+
+		body, err := io.ReadAll(api.do("GET", "/metrics", nil).Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, labels := range [][]string{
+			{`method="GET"`, `route="unmatched"`, `status="404"`},
+			{`method="DELETE"`, `route="unmatched"`, `status="405"`},
+		} {
+			if !slices.ContainsFunc(strings.Split(string(body), "\n"), func(line string) bool {
+				return strings.HasPrefix(line, "travel_agency_http_requests_total{") && containsAll(line, labels)
+			}) {
+				t.Errorf("no request count labelled %v", labels)
+			}
+		}
+	})
+}
+
+func containsAll(s string, parts []string) bool {
+	for _, part := range parts {
+		if !strings.Contains(s, part) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"github.com/nikolaypleshkov/uni-api/api/country"
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/image"
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	"github.com/nikolaypleshkov/uni-api/api/reservation"
+	"github.com/nikolaypleshkov/uni-api/api/search"
+	"github.com/nikolaypleshkov/uni-api/api/tag"
+	"github.com/nikolaypleshkov/uni-api/api/template"
+	"github.com/nikolaypleshkov/uni-api/blob"
+	"github.com/nikolaypleshkov/uni-api/geo"
+	"github.com/nikolaypleshkov/uni-api/idempotency"
+)
+
+// Store is the storage backend the services are built on, implemented by
+// storage/memory and storage/sqlstore.
+type Store interface {
+	Holidays() holiday.HolidayRepository
+	Locations() location.LocationRepository
+	Reservations() reservation.ReservationRepository
+	Countries() country.CountryRepository
+	Images() image.ImageRepository
+	Tags() tag.TagRepository
+	Templates() template.TemplateRepository
+	Search() search.SearchRepository
+	IdempotencyKeys() idempotency.Store
+}
+
+type Services struct {
+	Locations    *location.LocationServiceImpl
+	Holidays     *holiday.Service
+	Reservations *reservation.ReservationServiceImpl
+	Countries    *country.Service
+	Images       *image.Service
+	Tags         *tag.Service
+	Templates    *template.Service
+	Search       *search.Service
+}
+
+// NewServices builds the services on store. geocoder places locations on the
+// map and may be nil. Uploaded images are kept in blobs.
+func NewServices(store Store, geocoder geo.Geocoder, blobs blob.BlobStore, imageOptions image.Options) Services {
+	locationService := location.NewLocationService(store.Locations(), geocoder)
+	tagService := tag.NewService(store.Tags())
+	holidayService := holiday.NewService(store.Holidays(), locationService, tagService)
+	reservationService := reservation.NewReservationService(store.Reservations(), holidayService)
+
+	return Services{
+		Locations:    locationService,
+		Holidays:     holidayService,
+		Reservations: reservationService,
+		Countries:    country.NewService(store.Countries()),
+		Images:       image.NewService(store.Images(), blobs, imageOptions),
+		Tags:         tagService,
+		Templates:    template.NewService(store.Templates()),
+		Search:       search.NewService(store.Search(), holidayService, locationService),
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	tagdto "github.com/nikolaypleshkov/uni-api/api/tag/dto"
+)
+
+func TestTagsCRUD(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		var cityBreak tagdto.ResponseTagDTO
+		api.expect("POST", "/travel-agency/tags", tagdto.CreateTagDTO{Name: "  City   break "}, http.StatusOK, &cityBreak)
+		if cityBreak.Slug != "city-break" || cityBreak.Name != "City break" || cityBreak.Version != 1 {
+			t.Errorf("created %+v", cityBreak)
+		}
+		beach := api.createTag("beach")
+
+		api.expect("POST", "/travel-agency/tags", tagdto.CreateTagDTO{Slug: "BEACH", Name: "Sea"}, http.StatusConflict, nil)
+		api.expect("POST", "/travel-agency/tags", tagdto.CreateTagDTO{Slug: "ski"}, http.StatusBadRequest, nil)
+		api.expect("POST", "/travel-agency/tags", tagdto.CreateTagDTO{Slug: "ski resort", Name: "Ski"}, http.StatusBadRequest, nil)
+
+		var tags []tagdto.ResponseTagDTO
+		api.expect("GET", "/travel-agency/tags", nil, http.StatusOK, &tags)
+		if len(tags) != 2 || tags[0].Slug != "beach" || tags[1].Slug != "city-break" {
+			t.Errorf("listed %+v", tags)
+		}
+
+		path := fmt.Sprintf("/travel-agency/tags/%d", beach.ID)
+		var updated tagdto.ResponseTagDTO
+		api.write("PUT", path, tagdto.UpdateTagDTO{Slug: "seaside", Name: "Seaside"}, http.StatusOK, &updated)
+		if updated.Slug != "seaside" || updated.Version != 2 {
+			t.Errorf("updated %+v", updated)
+		}
+		api.write("PUT", path, tagdto.UpdateTagDTO{Slug: "city-break", Name: "Seaside"}, http.StatusConflict, nil)
+		api.write("PUT", path, tagdto.UpdateTagDTO{ID: beach.ID + 1, Name: "Seaside"}, http.StatusBadRequest, nil)
+		if resp := api.do("DELETE", path, nil); resp.StatusCode != http.StatusPreconditionRequired {
+			t.Errorf("DELETE without If-Match: status = %d, want 428", resp.StatusCode)
+		}
+
+		api.write("DELETE", path, nil, http.StatusNoContent, nil)
+		api.expect("GET", path, nil, http.StatusNotFound, nil)
+		api.write("PUT", "/travel-agency/tags/999", tagdto.UpdateTagDTO{Name: "Nowhere"}, http.StatusNotFound, nil)
+	})
+}
+
+func TestHolidayTags(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+		beach := api.createTag("beach")
+		api.createTag("family")
+		api.createTag("ski")
+		seaside := api.createHoliday(location.ID, "2026-07-01", 7, 10)
+		resort := api.createHoliday(location.ID, "2026-07-08", 7, 10)
+		winter := api.createHoliday(location.ID, "2026-01-10", 7, 10)
+		if seaside.Tags == nil || len(seaside.Tags) != 0 {
+			t.Errorf("new holiday tags = %#v, want []", seaside.Tags)
+		}
+
+		api.tagHoliday(seaside.ID, "beach")
+		api.tagHoliday(resort.ID, "family", "Beach", "beach")
+		api.tagHoliday(winter.ID, "ski", "family")
+
+		var holiday holidaydto.ResponseHolidayDTO
+		api.expect("GET", fmt.Sprintf("/travel-agency/holidays/%d", resort.ID), nil, http.StatusOK, &holiday)
+		if !slices.Equal(holiday.Tags, []string{"beach", "family"}) {
+			t.Errorf("tags = %v, want [beach family]", holiday.Tags)
+		}
+
+		for query, want := range map[string][]int64{
+			"tags=beach":                       {seaside.ID, resort.ID},
+			"tags=beach,family":                {resort.ID},
+			"tags=FAMILY":                      {resort.ID, winter.ID},
+			"tags=family&startDate=2026-01-10": {winter.ID},
+			"tags=beach,ski":                   nil,
+			"tags=hiking":                      nil,
+		} {
+			var holidays []holidaydto.ResponseHolidayDTO
+			api.expect("GET", "/travel-agency/holidays?"+query, nil, http.StatusOK, &holidays)
+			if ids := holidayIDs(holidays); fmt.Sprint(ids) != fmt.Sprint(want) {
+				t.Errorf("%s: holidays %v, want %v", query, ids, want)
+			}
+		}
+		api.expect("GET", "/travel-agency/holidays?tags=beach,,family", nil, http.StatusBadRequest, nil)
+
+		path := fmt.Sprintf("/travel-agency/holidays/%d/tags", seaside.ID)
+		api.write("PUT", path, holidaydto.HolidayTagsDTO{Tags: []string{"hiking"}}, http.StatusUnprocessableEntity, nil)
+		api.write("PUT", "/travel-agency/holidays/999/tags", holidaydto.HolidayTagsDTO{}, http.StatusNotFound, nil)
+
+		// Renaming or deleting a tag changes the holidays that have it.
+		holidayPath := fmt.Sprintf("/travel-agency/holidays/%d", seaside.ID)
+		before := api.etag(holidayPath)
+		tagPath := fmt.Sprintf("/travel-agency/tags/%d", beach.ID)
+		api.write("PUT", tagPath, tagdto.UpdateTagDTO{Slug: "seaside", Name: "Seaside"}, http.StatusOK, nil)
+		after := api.etag(holidayPath)
+		if after == before {
+			t.Error("renaming a tag did not change the ETag of a holiday with it")
+		}
+		api.expect("GET", path, nil, http.StatusOK, &holidaydto.HolidayTagsDTO{})
+		api.write("DELETE", tagPath, nil, http.StatusNoContent, nil)
+		if api.etag(holidayPath) == after {
+			t.Error("deleting a tag did not change the ETag of a holiday with it")
+		}
+
+		var tags holidaydto.HolidayTagsDTO
+		api.expect("GET", path, nil, http.StatusOK, &tags)
+		if len(tags.Tags) != 0 {
+			t.Errorf("tags after deleting the tag = %v", tags.Tags)
+		}
+		api.write("DELETE", holidayPath, nil, http.StatusNoContent, nil)
+	})
+}
+
+func TestHolidayFacets(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		varna := api.createLocation("Varna")
+		var athens locationdto.ResponseLocationDTO
+		api.expect("POST", "/travel-agency/locations", locationdto.CreateLocationDTO{City: "Athens", Country: "GR"}, http.StatusOK, &athens)
+		api.createTag("beach")
+		api.createTag("family")
+
+		short := api.createHoliday(varna.ID, "2026-07-01", 3, 10)
+		week := api.createHoliday(varna.ID, "2026-07-08", 7, 10)
+		tour := api.createHoliday(athens.ID, "2026-08-01", 14, 10)
+		api.tagHoliday(short.ID, "beach")
+		api.tagHoliday(week.ID, "beach", "family")
+		api.write("PUT", fmt.Sprintf("/travel-agency/holidays/%d/itinerary", tour.ID), holidaydto.UpdateItineraryDTO{Stops: []holidaydto.StopDTO{
+			{Location: varna.ID, DayOffset: 7, Nights: 7},
+		}}, http.StatusOK, nil)
+		api.patch(fmt.Sprintf("/travel-agency/holidays/%d", tour.ID), `{"price": 1800}`, http.StatusOK, nil)
+
+		var search holidaydto.ResponseHolidaySearchDTO
+		api.expect("GET", "/travel-agency/holidays?facets=true", nil, http.StatusOK, &search)
+		if len(search.Holidays) != 3 {
+			t.Fatalf("listed %d holidays, want 3", len(search.Holidays))
+		}
+		facets := search.Facets
+		if want := []holidaydto.TagFacetDTO{{Slug: "beach", Name: "Beach", Count: 2}, {Slug: "family", Name: "Family", Count: 1}}; !slices.Equal(facets.Tags, want) {
+			t.Errorf("tag facets = %+v, want %+v", facets.Tags, want)
+		}
+		if want := []holidaydto.CountryFacetDTO{{Code: "BG", Name: "Bulgaria", Count: 3}, {Code: "GR", Name: "Greece", Count: 1}}; !slices.Equal(facets.Countries, want) {
+			t.Errorf("country facets = %+v, want %+v", facets.Countries, want)
+		}
+		if got := rangeCounts(facets.Durations); got != "1-3:1 4-7:1 8-14:1 15+:0" {
+			t.Errorf("duration facets = %s", got)
+		}
+		if got := rangeCounts(facets.Prices); got != "0-500:2 500-1000:0 1000-2000:1 2000+:0" {
+			t.Errorf("price facets = %s", got)
+		}
+		if last := facets.Durations[len(facets.Durations)-1]; last.From != 15 || last.To != nil {
+			t.Errorf("open-ended duration band = %+v", last)
+		}
+
+		api.expect("GET", "/travel-agency/holidays?facets=true&tags=family", nil, http.StatusOK, &search)
+		if len(search.Holidays) != 1 || len(search.Facets.Tags) != 2 || search.Facets.Countries[0].Count != 1 {
+			t.Errorf("facets of the family holidays = %+v", search.Facets)
+		}
+
+		api.expect("GET", "/travel-agency/holidays?facets=true&tags=ski", nil, http.StatusOK, &search)
+		if search.Holidays == nil || len(search.Holidays) != 0 || len(search.Facets.Tags) != 0 || rangeCounts(search.Facets.Prices) != "0-500:0 500-1000:0 1000-2000:0 2000+:0" {
+			t.Errorf("empty search = %+v", search)
+		}
+		api.expect("GET", "/travel-agency/holidays?facets=maybe", nil, http.StatusBadRequest, nil)
+	})
+}
+
+func (api *testAPI) createTag(slug string) tagdto.ResponseTagDTO {
+	api.t.Helper()
+
+	var created tagdto.ResponseTagDTO
+	api.expect("POST", "/travel-agency/tags", tagdto.CreateTagDTO{
+		Slug: slug,
+		Name: strings.ToUpper(slug[:1]) + slug[1:],
+	}, http.StatusOK, &created)
+
+	return created
+}
+
+func (api *testAPI) tagHoliday(holidayID int64, slugs ...string) {
+	api.t.Helper()
+	api.write("PUT", fmt.Sprintf("/travel-agency/holidays/%d/tags", holidayID), holidaydto.HolidayTagsDTO{Tags: slugs}, http.StatusOK, nil)
+}
+
+func rangeCounts(facets []holidaydto.RangeFacetDTO) string {
+	var counts []string
+	for _, facet := range facets {
+		counts = append(counts, fmt.Sprintf("%s:%d", facet.Key, facet.Count))
+	}
+	return strings.Join(counts, " ")
+}
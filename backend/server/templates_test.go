@@ -0,0 +1,169 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	reservationdto "github.com/nikolaypleshkov/uni-api/api/reservation/dto"
+	templatedto "github.com/nikolaypleshkov/uni-api/api/template/dto"
+)
+
+// departures returns the free slots of the template's departures by date.
+func (api *testAPI) departures(templateID int64) map[string]int32 {
+	api.t.Helper()
+
+	var holidays []holidaydto.ResponseHolidayDTO
+	api.expect("GET", fmt.Sprintf("/travel-agency/holidays?template=%d&status=draft,published", templateID), nil, http.StatusOK, &holidays)
+
+	freeSlots := make(map[string]int32)
+	for _, h := range holidays {
+		if h.Template == nil || *h.Template != templateID {
+			api.t.Errorf("departure %d has template %v, want %d", h.ID, h.Template, templateID)
+		}
+		freeSlots[h.StartDate[:10]] = h.FreeSlots
+	}
+	return freeSlots
+}
+
+func TestHolidayTemplates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Nessebar")
+		saturdays := templatedto.CreateTemplateDTO{
+			Title:         "A week in Nessebar",
+			Duration:      7,
+			FreeSlots:     20,
+			Price:         "899.00",
+			Location:      location.ID,
+			Status:        "published",
+			StartDate:     "2030-06-01",
+			Recurrence:    "rrule:freq=weekly;byday=sa;until=20300622",
+			ExcludedDates: []string{"2030-06-15"},
+		}
+
+		var created templatedto.ResponseTemplateDTO
+		api.expect("POST", "/travel-agency/templates", saturdays, http.StatusOK, &created)
+		if created.Recurrence != "FREQ=WEEKLY;BYDAY=SA;UNTIL=20300622" || created.Status != "published" {
+			t.Errorf("created template = %+v", created)
+		}
+		want := map[string]int32{"2030-06-01": 20, "2030-06-08": 20, "2030-06-22": 20}
+		if got := api.departures(created.ID); !maps.Equal(got, want) {
+			t.Errorf("departures = %v, want %v", got, want)
+		}
+
+		var holidays []holidaydto.ResponseHolidayDTO
+		api.expect("GET", fmt.Sprintf("/travel-agency/holidays?template=%d&startDate=2030-06-08", created.ID), nil, http.StatusOK, &holidays)
+		if len(holidays) != 1 {
+			t.Fatalf("departures on 2030-06-08 = %+v", holidays)
+		}
+		booked := holidays[0]
+		reserve := reservationdto.CreateReservationDTO{ContactName: "Maria Ivanova", HolidayID: booked.ID}
+		api.expect("POST", "/travel-agency/reservations", reserve, http.StatusOK, nil)
+
+		// The booked date is excluded but keeps its departure, which still
+		// takes the new price and capacity.
+		path := fmt.Sprintf("/travel-agency/templates/%d", created.ID)
+		update := templatedto.UpdateTemplateDTO{
+			Title:         "A week in Nessebar",
+			Duration:      7,
+			FreeSlots:     22,
+			Price:         "949.00",
+			Location:      location.ID,
+			Status:        "published",
+			StartDate:     "2030-06-01",
+			Recurrence:    "FREQ=WEEKLY;BYDAY=SA;UNTIL=20300706",
+			ExcludedDates: []string{"2030-06-08", "2030-06-22"},
+		}
+		var updated templatedto.ResponseTemplateDTO
+		api.write("PUT", path, update, http.StatusOK, &updated)
+		if updated.Version != created.Version+1 || !slices.Equal(updated.ExcludedDates, update.ExcludedDates) {
+			t.Errorf("updated template = %+v", updated)
+		}
+		want = map[string]int32{"2030-06-01": 22, "2030-06-08": 21, "2030-06-15": 22, "2030-06-29": 22, "2030-07-06": 22}
+		if got := api.departures(created.ID); !maps.Equal(got, want) {
+			t.Errorf("departures after update = %v, want %v", got, want)
+		}
+		var holiday holidaydto.ResponseHolidayDTO
+		api.expect("GET", fmt.Sprintf("/travel-agency/holidays/%d", booked.ID), nil, http.StatusOK, &holiday)
+		if holiday.Price != "949.00" || holiday.Version <= booked.Version {
+			t.Errorf("booked departure after update = %+v", holiday)
+		}
+
+		// Going back to draft hides the departures nobody has booked yet.
+		update.Status = "draft"
+		api.write("PUT", path, update, http.StatusOK, &updated)
+		api.expect("GET", fmt.Sprintf("/travel-agency/holidays/%d", booked.ID), nil, http.StatusOK, &holiday)
+		if holiday.Status != "published" {
+			t.Errorf("booked departure has status %s after the template went back to draft", holiday.Status)
+		}
+		api.expect("GET", fmt.Sprintf("/travel-agency/holidays?template=%d&status=published", created.ID), nil, http.StatusOK, &holidays)
+		if len(holidays) != 1 || holidays[0].ID != booked.ID {
+			t.Errorf("published departures = %v, want only %d", holidayIDs(holidays), booked.ID)
+		}
+
+		// The departures cannot get shorter than their itineraries.
+		api.write("PUT", fmt.Sprintf("/travel-agency/holidays/%d/itinerary", booked.ID), holidaydto.UpdateItineraryDTO{Stops: []holidaydto.StopDTO{
+			{Location: location.ID, DayOffset: 1, Nights: 5},
+		}}, http.StatusOK, nil)
+		update.Duration = 5
+		api.write("PUT", path, update, http.StatusBadRequest, nil)
+		api.expect("GET", fmt.Sprintf("/travel-agency/holidays/%d", booked.ID), nil, http.StatusOK, &holiday)
+		if holiday.Duration != 7 {
+			t.Errorf("rejected update changed the departure's duration to %d", holiday.Duration)
+		}
+		update.Duration, update.Status = 7, "published"
+		api.write("PUT", path, update, http.StatusOK, nil)
+
+		for _, invalid := range []map[string]any{
+			{"recurrence": "FREQ=DAILY;COUNT=3"},
+			{"recurrence": "FREQ=WEEKLY"},
+			{"recurrence": "FREQ=WEEKLY;COUNT=400"},
+			{"startDate": "1 June"},
+			{"status": "archived"},
+			{"excludedDates": []string{"2030-06-31"}},
+			{"included": []string{"spa"}},
+			{"duration": 0},
+			{"price": "cheap"},
+		} {
+			body := map[string]any{
+				"title":      "A week in Nessebar",
+				"duration":   7,
+				"freeSlots":  20,
+				"price":      "899.00",
+				"location":   location.ID,
+				"startDate":  "2030-06-01",
+				"recurrence": "FREQ=WEEKLY;COUNT=3",
+			}
+			for key, value := range invalid {
+				body[key] = value
+			}
+			api.expect("POST", "/travel-agency/templates", body, http.StatusBadRequest, nil)
+		}
+
+		unknown := saturdays
+		unknown.Location = location.ID + 100
+		api.expect("POST", "/travel-agency/templates", unknown, http.StatusUnprocessableEntity, nil)
+		update.Location = location.ID + 100
+		api.write("PUT", path, update, http.StatusUnprocessableEntity, nil)
+
+		var templates []templatedto.ResponseTemplateDTO
+		api.expect("GET", "/travel-agency/templates", nil, http.StatusOK, &templates)
+		if len(templates) != 1 || templates[0].ID != created.ID {
+			t.Errorf("templates = %+v", templates)
+		}
+
+		api.write("DELETE", path, nil, http.StatusNoContent, nil)
+		api.expect("GET", path, nil, http.StatusNotFound, nil)
+		api.expect("GET", fmt.Sprintf("/travel-agency/holidays/%d", booked.ID), nil, http.StatusOK, &holiday)
+		if holiday.Template != nil {
+			t.Errorf("departure still refers to the deleted template %d", *holiday.Template)
+		}
+		api.expect("GET", "/travel-agency/holidays?status=published", nil, http.StatusOK, &holidays)
+		if len(holidays) != 5 {
+			t.Errorf("%d holidays left after deleting the template, want 5", len(holidays))
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	holidaydto "github.com/nikolaypleshkov/uni-api/api/holiday/dto"
+	locationdto "github.com/nikolaypleshkov/uni-api/api/location/dto"
+	"github.com/nikolaypleshkov/uni-api/etag"
+)
+
+func TestHolidayContent(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Varna")
+
+		var created holidaydto.ResponseHolidayDTO
+		api.expect("POST", "/travel-agency/holidays", map[string]any{
+			"title":       "Golden Sands",
+			"description": "  A week by the sea.  ",
+			"startDate":   "2026-07-01",
+			"duration":    7,
+			"freeSlots":   10,
+			"price":       "499.90",
+			"location":    location.ID,
+			"included":    []string{"Flights", "meals", "flights"},
+			"excluded":    []string{"visa"},
+			"accommodation": map[string]any{
+				"name":  "Hotel Morsko Oko",
+				"type":  "Hotel",
+				"stars": 4,
+			},
+		}, http.StatusOK, &created)
+
+		path := fmt.Sprintf("/travel-agency/holidays/%d", created.ID)
+		var holiday holidaydto.ResponseHolidayDTO
+		api.expect("GET", path, nil, http.StatusOK, &holiday)
+		if holiday.Description != "A week by the sea." || !slices.Equal(holiday.Included, []string{"flights", "meals"}) || !slices.Equal(holiday.Excluded, []string{"visa"}) {
+			t.Errorf("content = %q %v %v", holiday.Description, holiday.Included, holiday.Excluded)
+		}
+		if holiday.Accommodation != (holidaydto.AccommodationDTO{Name: "Hotel Morsko Oko", Type: "hotel", Stars: 4}) {
+			t.Errorf("accommodation = %+v", holiday.Accommodation)
+		}
+
+		plain := api.createHoliday(location.ID, "2026-08-01", 7, 10)
+		if plain.Included == nil || plain.Excluded == nil {
+			t.Errorf("new holiday services = %#v %#v, want []", plain.Included, plain.Excluded)
+		}
+
+		api.patch(path, `{"excluded": ["visa", "insurance"], "accommodation": {"stars": 5}}`, http.StatusOK, &holiday)
+		if !slices.Equal(holiday.Excluded, []string{"visa", "insurance"}) || holiday.Accommodation.Stars != 5 || holiday.Accommodation.Name != "Hotel Morsko Oko" {
+			t.Errorf("patched %v %+v", holiday.Excluded, holiday.Accommodation)
+		}
+
+		for _, patch := range []string{
+			`{"included": ["spa"]}`,
+			`{"included": ["visa"]}`,
+			`{"accommodation": {"stars": 6}}`,
+			`{"accommodation": {"type": "castle"}}`,
+		} {
+			api.patch(path, patch, http.StatusBadRequest, nil)
+		}
+	})
+}
+
+func TestTranslations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, api *testAPI) {
+		location := api.createLocation("Athens")
+		created := api.createHoliday(location.ID, "2026-07-01", 7, 10)
+		holidayPath := fmt.Sprintf("/travel-agency/holidays/%d", created.ID)
+		locationPath := fmt.Sprintf("/travel-agency/locations/%d", location.ID)
+
+		var translations holidaydto.HolidayTranslationsDTO
+		api.expect("GET", holidayPath+"/translations", nil, http.StatusOK, &translations)
+		if translations.Translations == nil || len(translations.Translations) != 0 {
+			t.Errorf("new holiday translations = %#v, want {}", translations.Translations)
+		}
+
+		before := api.etag(holidayPath)
+		api.write("PUT", holidayPath+"/translations", holidaydto.HolidayTranslationsDTO{Translations: map[string]holidaydto.HolidayTranslationDTO{
+			"BG":    {Title: "Лято в Атина"},
+			"de_AT": {Title: "Sommer in Athen", Description: "Eine Woche in Athen."},
+		}}, http.StatusOK, &translations)
+		if len(translations.Translations) != 2 || translations.Translations["de-at"].Title != "Sommer in Athen" {
+			t.Errorf("replaced translations = %+v", translations.Translations)
+		}
+		if api.etag(holidayPath) == before {
+			t.Error("replacing the translations did not change the holiday's ETag")
+		}
+		api.write("PUT", locationPath+"/translations", locationdto.LocationTranslationsDTO{Translations: map[string]locationdto.LocationTranslationDTO{
+			"bg": {City: "Атина"},
+		}}, http.StatusOK, nil)
+
+		for language, want := range map[string]struct{ title, city, contentLanguage string }{
+			"":                       {"Summer in 2026-07-01", "Athens", "en"},
+			"bg":                     {"Лято в Атина", "Атина", "bg"},
+			"de-DE, bg;q=0.5":        {"Sommer in Athen", "Атина", "de-at, bg"},
+			"fr, en;q=0.9, bg;q=0.8": {"Summer in 2026-07-01", "Athens", "en"},
+		} {
+			resp := api.doWithHeader("GET", holidayPath, nil, http.Header{"Accept-Language": {language}})
+			var holiday holidaydto.ResponseHolidayDTO
+			api.check(resp, http.StatusOK, &holiday)
+			if holiday.Title != want.title || holiday.Location.City != want.city {
+				t.Errorf("Accept-Language %q: title %q, city %q, want %q, %q", language, holiday.Title, holiday.Location.City, want.title, want.city)
+			}
+			if got := resp.Header.Get("Content-Language"); got != want.contentLanguage {
+				t.Errorf("Accept-Language %q: Content-Language = %q, want %q", language, got, want.contentLanguage)
+			}
+			if got := resp.Header.Get("Vary"); got != "Accept-Language" {
+				t.Errorf("Accept-Language %q: Vary = %q", language, got)
+			}
+		}
+
+		// Each translation has its own ETag, and a write may follow any.
+		for _, path := range []string{holidayPath, locationPath} {
+			tags := map[string]string{}
+			for _, language := range []string{"en", "de", "bg"} {
+				resp := api.doWithHeader("GET", path, nil, http.Header{"Accept-Language": {language}})
+				api.check(resp, http.StatusOK, nil)
+				tags[language] = resp.Header.Get("ETag")
+				resp = api.doWithHeader("GET", path, nil, http.Header{"Accept-Language": {language}, "If-None-Match": {tags[language]}})
+				api.check(resp, http.StatusNotModified, nil)
+			}
+			resp := api.doWithHeader("GET", path, nil, http.Header{"Accept-Language": {"de"}, "If-None-Match": {tags["bg"]}})
+			api.check(resp, http.StatusOK, nil)
+			if resp.Header.Get("ETag") == tags["bg"] {
+				t.Errorf("GET %s: the de and bg translations share the ETag %s", path, tags["bg"])
+			}
+			if !etag.MatchesStrong(tags["bg"], tags["en"]) {
+				t.Errorf("GET %s: If-Match %s does not match %s", path, tags["bg"], tags["en"])
+			}
+		}
+
+		var holidays []holidaydto.ResponseHolidayDTO
+		api.check(api.doWithHeader("GET", "/travel-agency/holidays", nil, http.Header{"Accept-Language": {"bg"}}), http.StatusOK, &holidays)
+		if len(holidays) != 1 || holidays[0].Title != "Лято в Атина" {
+			t.Errorf("localized list = %+v", holidays)
+		}
+		var locations []locationdto.ResponseLocationDTO
+		api.check(api.doWithHeader("GET", "/travel-agency/locations", nil, http.Header{"Accept-Language": {"bg-BG"}}), http.StatusOK, &locations)
+		if len(locations) != 1 || locations[0].City != "Атина" || locations[0].Street != "Primorski" {
+			t.Errorf("localized locations = %+v", locations)
+		}
+
+		for _, invalid := range []map[string]holidaydto.HolidayTranslationDTO{
+			{"en": {Title: "Summer"}},
+			{"not a tag": {Title: "Summer"}},
+			{"bg": {}},
+			{"bg": {Title: "Лято"}, "BG": {Title: "Лято"}},
+		} {
+			api.write("PUT", holidayPath+"/translations", holidaydto.HolidayTranslationsDTO{Translations: invalid}, http.StatusBadRequest, nil)
+		}
+		api.expect("PUT", holidayPath+"/translations", holidaydto.HolidayTranslationsDTO{}, http.StatusPreconditionRequired, nil)
+
+		var cleared holidaydto.HolidayTranslationsDTO
+		api.write("PUT", holidayPath+"/translations", holidaydto.HolidayTranslationsDTO{}, http.StatusOK, &cleared)
+		if len(cleared.Translations) != 0 {
+			t.Errorf("cleared translations = %+v", cleared.Translations)
+		}
+		api.expect("GET", "/travel-agency/holidays/999/translations", nil, http.StatusNotFound, nil)
+		api.expect("GET", "/travel-agency/locations/999/translations", nil, http.StatusNotFound, nil)
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/database"
+	"github.com/nikolaypleshkov/uni-api/health"
+	"github.com/nikolaypleshkov/uni-api/metrics"
+	"github.com/nikolaypleshkov/uni-api/server"
+	"github.com/nikolaypleshkov/uni-api/storage/memory"
+	"github.com/nikolaypleshkov/uni-api/storage/sqlstore"
+)
+
+// openStore picks the storage backend from the database URL: "memory" keeps
+// everything in process, postgres:// and sqlite:// URLs use the SQL store.
+// The returned checks feed the readiness probe and close releases the
+// connection pool.
+func openStore(ctx context.Context, cfg config) (server.Store, []health.Check, func() error, error) {
+	if cfg.DatabaseURL == "memory" || strings.HasPrefix(cfg.DatabaseURL, "memory:") {
+		return memory.New(), nil, func() error { return nil }, nil
+	}
+
+	dialect, dsn, err := database.ParseURL(cfg.DatabaseURL)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	db, err := database.Open(ctx, dialect, dsn, cfg.Database)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if cfg.Migrate {
+		if err := database.Migrate(ctx, db, dialect); err != nil {
+			db.Close()
+			return nil, nil, nil, err
+		}
+	}
+
+	metrics.RegisterDBStats(db, dialect.Name)
+
+	checks := []health.Check{
+		health.DatabaseCheck(db),
+		health.MigrationsCheck(db),
+	}
+
+	return sqlstore.New(db, dialect), checks, db.Close, nil
+}
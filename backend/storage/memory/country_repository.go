@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/api/country"
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+)
+
+type countryRepository struct {
+	store *Store
+}
+
+func (r *countryRepository) ListStats(ctx context.Context) ([]country.Stats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	holidays := r.holidaysPerLocation()
+	byCode := make(map[string]*country.Stats)
+	cities := make(map[string]map[string]bool)
+	for _, l := range r.store.locations {
+		if l.CountryCode == "" {
+			continue
+		}
+		s, ok := byCode[l.CountryCode]
+		if !ok {
+			s = &country.Stats{CountryCode: l.CountryCode}
+			byCode[l.CountryCode] = s
+			cities[l.CountryCode] = make(map[string]bool)
+		}
+		cities[l.CountryCode][strings.ToLower(l.City)] = true
+		s.Locations++
+		s.Holidays += holidays[l.ID]
+	}
+
+	var stats []country.Stats
+	for code, s := range byCode {
+		s.Cities = int64(len(cities[code]))
+		stats = append(stats, *s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].CountryCode < stats[j].CountryCode })
+	return stats, nil
+}
+
+func (r *countryRepository) ListCityStats(ctx context.Context, countryCode string) ([]country.CityStats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	holidays := r.holidaysPerLocation()
+	byCity := make(map[string]*country.CityStats)
+	for _, l := range r.store.locations {
+		if l.CountryCode != countryCode {
+			continue
+		}
+		key := strings.ToLower(l.City)
+		s, ok := byCity[key]
+		if !ok {
+			s = &country.CityStats{City: l.City}
+			byCity[key] = s
+		}
+		// Match the SQL store, which reports the smallest spelling.
+		s.City = min(s.City, l.City)
+		s.Locations++
+		s.Holidays += holidays[l.ID]
+	}
+
+	var stats []country.CityStats
+	for _, s := range byCity {
+		stats = append(stats, *s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return strings.ToLower(stats[i].City) < strings.ToLower(stats[j].City) })
+	return stats, nil
+}
+
+// holidaysPerLocation counts published holidays by location id. Callers
+// must hold the read lock.
+func (r *countryRepository) holidaysPerLocation() map[int64]int64 {
+	counts := make(map[int64]int64)
+	for _, h := range r.store.holidays {
+		if h.Status == holiday.Published {
+			counts[h.LocationID]++
+		}
+	}
+	return counts
+}
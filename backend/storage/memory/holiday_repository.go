@@ -0,0 +1,409 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+)
+
+type holidayRepository struct {
+	store *Store
+}
+
+func (r *holidayRepository) checkLocation(locationID int64) error {
+	if locationID <= 0 {
+		return nil
+	}
+	if _, ok := r.store.locations[locationID]; !ok {
+		return fmt.Errorf("location %d does not exist", locationID)
+	}
+	return nil
+}
+
+func (r *holidayRepository) Create(ctx context.Context, h holiday.Holiday) (holiday.Holiday, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.insert(h)
+}
+
+// insert stores h as a new holiday, which templates also do for their
+// departures. Callers must hold the write lock.
+func (r *holidayRepository) insert(h holiday.Holiday) (holiday.Holiday, error) {
+	if err := r.checkLocation(h.LocationID); err != nil {
+		return holiday.Holiday{}, err
+	}
+	if h.LocationID < 0 {
+		h.LocationID = 0
+	}
+
+	h.StartDate = normalizeDate(h.StartDate)
+	h.Included, h.Excluded = slices.Clone(h.Included), slices.Clone(h.Excluded)
+	h.ID = r.store.sequence("holidays")
+	h.Version = 1
+	r.store.holidays[h.ID] = h
+
+	return h, nil
+}
+
+func (r *holidayRepository) Delete(ctx context.Context, holidayID int64, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.holidays[holidayID]
+	if !ok {
+		return holiday.ErrNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return holiday.ErrVersionMismatch
+	}
+	r.remove(holidayID)
+
+	return nil
+}
+
+// remove deletes a holiday with everything that belongs to it. Callers must
+// hold the write lock.
+func (r *holidayRepository) remove(holidayID int64) {
+	delete(r.store.holidays, holidayID)
+	delete(r.store.stops, holidayID)
+	delete(r.store.holidayTags, holidayID)
+	delete(r.store.holidayTranslations, holidayID)
+}
+
+func (r *holidayRepository) List(ctx context.Context, filter holiday.HolidayFilter) ([]holiday.Holiday, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var holidays []holiday.Holiday
+	for _, h := range r.store.holidays {
+		if filter.StartDate != "" && h.StartDate != normalizeDate(filter.StartDate) {
+			continue
+		}
+		date := h.StartDate[:min(len(h.StartDate), len(time.DateOnly))]
+		if filter.StartFrom != "" && date < filter.StartFrom {
+			continue
+		}
+		if filter.StartBefore != "" && date >= filter.StartBefore {
+			continue
+		}
+		if filter.Duration != nil && h.Duration != *filter.Duration {
+			continue
+		}
+		if filter.CountryCode != "" && !r.visits(h, holiday.LocationMatch{CountryCode: filter.CountryCode}) {
+			continue
+		}
+		if filter.Location != nil && !r.visits(h, *filter.Location) {
+			continue
+		}
+		if !containsAll(r.tagSlugs(h.ID), filter.Tags) {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, h.Status) {
+			continue
+		}
+		if filter.TemplateID != 0 && h.TemplateID != filter.TemplateID {
+			continue
+		}
+		holidays = append(holidays, h)
+	}
+
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].ID < holidays[j].ID })
+	return holidays, nil
+}
+
+func (r *holidayRepository) Get(ctx context.Context, holidayID int64) (holiday.Holiday, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	h, ok := r.store.holidays[holidayID]
+	if !ok {
+		return holiday.Holiday{}, holiday.ErrNotFound
+	}
+
+	return h, nil
+}
+
+func (r *holidayRepository) GetMany(ctx context.Context, holidayIDs []int64) (map[int64]holiday.Holiday, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	holidays := make(map[int64]holiday.Holiday)
+	for _, id := range holidayIDs {
+		if h, ok := r.store.holidays[id]; ok {
+			holidays[id] = h
+		}
+	}
+
+	return holidays, nil
+}
+
+func (r *holidayRepository) Update(ctx context.Context, h holiday.Holiday) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.holidays[h.ID]
+	if !ok {
+		return holiday.ErrNotFound
+	}
+	if h.Version != 0 && existing.Version != h.Version {
+		return holiday.ErrVersionMismatch
+	}
+	if err := r.checkLocation(h.LocationID); err != nil {
+		return err
+	}
+	if h.LocationID < 0 {
+		h.LocationID = 0
+	}
+
+	h.StartDate = normalizeDate(h.StartDate)
+	h.Included, h.Excluded = slices.Clone(h.Included), slices.Clone(h.Excluded)
+	h.TemplateID = existing.TemplateID
+	h.Version = existing.Version + 1
+	r.store.holidays[h.ID] = h
+
+	return nil
+}
+
+func (r *holidayRepository) GetItinerary(ctx context.Context, holidayID int64) ([]holiday.Stop, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if _, ok := r.store.holidays[holidayID]; !ok {
+		return nil, holiday.ErrNotFound
+	}
+
+	return slices.Clone(r.store.stops[holidayID]), nil
+}
+
+func (r *holidayRepository) ReplaceItinerary(ctx context.Context, holidayID int64, stops []holiday.Stop, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.holidays[holidayID]
+	if !ok {
+		return holiday.ErrNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return holiday.ErrVersionMismatch
+	}
+	for _, stop := range stops {
+		if _, ok := r.store.locations[stop.LocationID]; !ok {
+			return fmt.Errorf("%w: %d", holiday.ErrUnknownLocation, stop.LocationID)
+		}
+	}
+
+	if len(stops) == 0 {
+		delete(r.store.stops, holidayID)
+		delete(r.store.holidayTags, holidayID)
+	} else {
+		r.store.stops[holidayID] = slices.Clone(stops)
+	}
+	existing.Version++
+	r.store.holidays[holidayID] = existing
+
+	return nil
+}
+
+func (r *holidayRepository) ListItineraries(ctx context.Context, holidayIDs []int64) (map[int64][]holiday.Stop, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	itineraries := make(map[int64][]holiday.Stop)
+	for _, id := range holidayIDs {
+		if stops := r.store.stops[id]; len(stops) > 0 {
+			itineraries[id] = slices.Clone(stops)
+		}
+	}
+
+	return itineraries, nil
+}
+
+func (r *holidayRepository) ListTags(ctx context.Context, holidayIDs []int64) (map[int64][]string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tags := make(map[int64][]string)
+	for _, id := range holidayIDs {
+		if slugs := r.tagSlugs(id); len(slugs) > 0 {
+			tags[id] = slugs
+		}
+	}
+
+	return tags, nil
+}
+
+func (r *holidayRepository) ReplaceTags(ctx context.Context, holidayID int64, slugs []string, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.holidays[holidayID]
+	if !ok {
+		return holiday.ErrNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return holiday.ErrVersionMismatch
+	}
+
+	var tagIDs []int64
+	for _, slug := range slugs {
+		id, ok := r.tagID(slug)
+		if !ok {
+			return fmt.Errorf("%w: %q", holiday.ErrUnknownTag, slug)
+		}
+		if !slices.Contains(tagIDs, id) {
+			tagIDs = append(tagIDs, id)
+		}
+	}
+
+	if len(tagIDs) == 0 {
+		delete(r.store.holidayTags, holidayID)
+	} else {
+		r.store.holidayTags[holidayID] = tagIDs
+	}
+	existing.Version++
+	r.store.holidays[holidayID] = existing
+
+	return nil
+}
+
+func (r *holidayRepository) ListTranslations(ctx context.Context, holidayIDs []int64) (map[int64][]holiday.Translation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	translations := make(map[int64][]holiday.Translation)
+	for _, id := range holidayIDs {
+		if ts, ok := r.store.holidayTranslations[id]; ok {
+			translations[id] = slices.Clone(ts)
+		}
+	}
+
+	return translations, nil
+}
+
+func (r *holidayRepository) ReplaceTranslations(ctx context.Context, holidayID int64, translations []holiday.Translation, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.holidays[holidayID]
+	if !ok {
+		return holiday.ErrNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return holiday.ErrVersionMismatch
+	}
+
+	if len(translations) == 0 {
+		delete(r.store.holidayTranslations, holidayID)
+	} else {
+		translations = slices.Clone(translations)
+		sort.Slice(translations, func(i, j int) bool { return translations[i].Language < translations[j].Language })
+		r.store.holidayTranslations[holidayID] = translations
+	}
+	existing.Version++
+	r.store.holidays[holidayID] = existing
+
+	return nil
+}
+
+// tagID looks a tag up by slug. Callers must hold the lock.
+func (r *holidayRepository) tagID(slug string) (int64, bool) {
+	for _, t := range r.store.tags {
+		if t.Slug == slug {
+			return t.ID, true
+		}
+	}
+	return 0, false
+}
+
+// tagSlugs returns the slugs of the holiday's tags in order. Callers must
+// hold the lock.
+func (r *holidayRepository) tagSlugs(holidayID int64) []string {
+	var slugs []string
+	for _, id := range r.store.holidayTags[holidayID] {
+		slugs = append(slugs, r.store.tags[id].Slug)
+	}
+	sort.Strings(slugs)
+	return slugs
+}
+
+func containsAll(values, wanted []string) bool {
+	for _, w := range wanted {
+		if !slices.Contains(values, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// visits reports whether the holiday's location or one of its stops matches
+// match. Callers must hold the lock.
+func (r *holidayRepository) visits(h holiday.Holiday, match holiday.LocationMatch) bool {
+	locationIDs := []int64{h.LocationID}
+	for _, stop := range r.store.stops[h.ID] {
+		locationIDs = append(locationIDs, stop.LocationID)
+	}
+
+	for _, id := range locationIDs {
+		l, ok := r.store.locations[id]
+		if !ok {
+			continue
+		}
+		if match.LocationID != 0 && l.ID == match.LocationID {
+			return true
+		}
+		if match.City != "" && strings.EqualFold(l.City, match.City) {
+			return true
+		}
+		if match.CountryCode != "" && l.CountryCode == match.CountryCode {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplySchedule makes the same transitions, in the same order, as the SQL
+// store.
+func (r *holidayRepository) ApplySchedule(ctx context.Context, now time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	today := now.UTC().Format(time.DateOnly)
+	var changed int64
+	for id, h := range r.store.holidays {
+		before := changed
+		if h.Status == holiday.Draft && h.PublishAt != nil && !h.PublishAt.After(now) {
+			h.Status, h.PublishAt = holiday.Published, nil
+			changed++
+		}
+		if h.Status == holiday.Published && h.UnpublishAt != nil && !h.UnpublishAt.After(now) {
+			h.Status, h.UnpublishAt = holiday.Draft, nil
+			changed++
+		}
+		if h.Status != holiday.Archived && h.StartDate[:min(len(h.StartDate), len(time.DateOnly))] < today {
+			h.Status, h.PublishAt, h.UnpublishAt = holiday.Archived, nil, nil
+			changed++
+		}
+		if changed != before {
+			h.Version += changed - before
+			r.store.holidays[id] = h
+		}
+	}
+
+	return changed, nil
+}
+
+// normalizeDate renders dates the way the SQL drivers return DATE columns,
+// so responses look the same whichever backend is in use.
+func normalizeDate(value string) string {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return value
+	}
+	return date.Format(time.RFC3339)
+}
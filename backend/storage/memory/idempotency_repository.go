@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/idempotency"
+)
+
+type idempotencyRepository struct {
+	store *Store
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record idempotency.Record, expiredBefore, abandonedBefore time.Time) (idempotency.Record, bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.idempotencyKeys[record.Key]
+	if ok && !released(existing, expiredBefore, abandonedBefore) {
+		return copyRecord(existing), false, nil
+	}
+
+	r.store.idempotencyKeys[record.Key] = copyRecord(record)
+	return record, true, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record idempotency.Record) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.idempotencyKeys[record.Key]
+	if !ok {
+		return nil
+	}
+	existing.Status = record.Status
+	existing.ContentType = record.ContentType
+	existing.Body = record.Body
+	r.store.idempotencyKeys[record.Key] = copyRecord(existing)
+
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.idempotencyKeys, key)
+	return nil
+}
+
+func (r *idempotencyRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var purged int64
+	for key, record := range r.store.idempotencyKeys {
+		if record.CreatedAt.Before(before) {
+			delete(r.store.idempotencyKeys, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// released reports whether an existing record has stopped holding its key.
+func released(record idempotency.Record, expiredBefore, abandonedBefore time.Time) bool {
+	if record.Status == 0 {
+		return record.CreatedAt.Before(abandonedBefore)
+	}
+	return record.CreatedAt.Before(expiredBefore)
+}
+
+func copyRecord(record idempotency.Record) idempotency.Record {
+	record.Body = append([]byte(nil), record.Body...)
+	return record
+}
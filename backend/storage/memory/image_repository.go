@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nikolaypleshkov/uni-api/api/image"
+)
+
+type imageRepository struct {
+	store *Store
+}
+
+func (r *imageRepository) Create(ctx context.Context, img image.Image) (image.Image, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !r.ownerExists(img.Owner) {
+		return image.Image{}, image.ErrOwnerNotFound
+	}
+
+	img.ID = r.store.sequence("images")
+	img.Position = int32(len(r.imagesOf(img.Owner)))
+	r.store.images[img.ID] = img
+
+	return img, nil
+}
+
+func (r *imageRepository) List(ctx context.Context, owner image.Owner) ([]image.Image, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if !r.ownerExists(owner) {
+		return nil, image.ErrOwnerNotFound
+	}
+
+	return r.imagesOf(owner), nil
+}
+
+func (r *imageRepository) Delete(ctx context.Context, owner image.Owner, imageID int64) (image.Image, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	img, ok := r.store.images[imageID]
+	if !ok || img.Owner != owner {
+		return image.Image{}, image.ErrNotFound
+	}
+	delete(r.store.images, imageID)
+
+	for _, other := range r.imagesOf(owner) {
+		if other.Position > img.Position {
+			other.Position--
+			r.store.images[other.ID] = other
+		}
+	}
+
+	return img, nil
+}
+
+func (r *imageRepository) Reorder(ctx context.Context, owner image.Owner, imageIDs []int64) ([]image.Image, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !r.ownerExists(owner) {
+		return nil, image.ErrOwnerNotFound
+	}
+
+	images := r.imagesOf(owner)
+	if err := image.CheckOrder(images, imageIDs); err != nil {
+		return nil, err
+	}
+	for position, id := range imageIDs {
+		img := r.store.images[id]
+		img.Position = int32(position)
+		r.store.images[id] = img
+	}
+
+	return r.imagesOf(owner), nil
+}
+
+func (r *imageRepository) ListOrphans(ctx context.Context) ([]image.Image, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var orphans []image.Image
+	for _, img := range r.store.images {
+		if !r.ownerExists(img.Owner) {
+			orphans = append(orphans, img)
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].ID < orphans[j].ID })
+	return orphans, nil
+}
+
+// imagesOf returns the owner's images in order. Callers must hold the lock.
+func (r *imageRepository) imagesOf(owner image.Owner) []image.Image {
+	var images []image.Image
+	for _, img := range r.store.images {
+		if img.Owner == owner {
+			images = append(images, img)
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].Position != images[j].Position {
+			return images[i].Position < images[j].Position
+		}
+		return images[i].ID < images[j].ID
+	})
+	return images
+}
+
+// ownerExists reports whether the owner is stored. Callers must hold the
+// lock.
+func (r *imageRepository) ownerExists(owner image.Owner) bool {
+	switch owner.Kind {
+	case image.LocationOwner:
+		_, ok := r.store.locations[owner.ID]
+		return ok
+	case image.HolidayOwner:
+		_, ok := r.store.holidays[owner.ID]
+		return ok
+	default:
+		return false
+	}
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/nikolaypleshkov/uni-api/api/location"
+)
+
+type locationRepository struct {
+	store *Store
+}
+
+func (r *locationRepository) Create(ctx context.Context, l location.Location) (location.Location, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	l.ID = r.store.sequence("locations")
+	l.Version = 1
+	r.store.locations[l.ID] = l
+
+	return l, nil
+}
+
+func (r *locationRepository) Delete(ctx context.Context, locationID int64, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.locations[locationID]
+	if !ok {
+		return location.ErrNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return location.ErrVersionMismatch
+	}
+	for _, h := range r.store.holidays {
+		if h.LocationID == locationID {
+			return fmt.Errorf("location %d is still referenced by holiday %d", locationID, h.ID)
+		}
+	}
+	for _, t := range r.store.templates {
+		if t.LocationID == locationID {
+			return fmt.Errorf("location %d is still referenced by template %d", locationID, t.ID)
+		}
+	}
+	for holidayID, stops := range r.store.stops {
+		for _, stop := range stops {
+			if stop.LocationID == locationID {
+				return fmt.Errorf("location %d is still referenced by the itinerary of holiday %d", locationID, holidayID)
+			}
+		}
+	}
+	delete(r.store.locations, locationID)
+	delete(r.store.locationTranslations, locationID)
+
+	return nil
+}
+
+func (r *locationRepository) List(ctx context.Context) ([]location.Location, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var locations []location.Location
+	for _, l := range r.store.locations {
+		locations = append(locations, l)
+	}
+
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
+	return locations, nil
+}
+
+func (r *locationRepository) Get(ctx context.Context, locationID int64) (location.Location, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	l, ok := r.store.locations[locationID]
+	if !ok {
+		return location.Location{}, location.ErrNotFound
+	}
+
+	return l, nil
+}
+
+func (r *locationRepository) GetMany(ctx context.Context, locationIDs []int64) (map[int64]location.Location, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	locations := make(map[int64]location.Location)
+	for _, id := range locationIDs {
+		if l, ok := r.store.locations[id]; ok {
+			locations[id] = l
+		}
+	}
+
+	return locations, nil
+}
+
+func (r *locationRepository) Update(ctx context.Context, l location.Location) (location.Location, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.locations[l.ID]
+	if !ok {
+		return location.Location{}, location.ErrNotFound
+	}
+	if l.Version != 0 && existing.Version != l.Version {
+		return location.Location{}, location.ErrVersionMismatch
+	}
+	l.Version = existing.Version + 1
+	r.store.locations[l.ID] = l
+
+	return l, nil
+}
+
+func (r *locationRepository) ListTranslations(ctx context.Context, locationIDs []int64) (map[int64][]location.Translation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	translations := make(map[int64][]location.Translation)
+	for _, id := range locationIDs {
+		if ts, ok := r.store.locationTranslations[id]; ok {
+			translations[id] = slices.Clone(ts)
+		}
+	}
+
+	return translations, nil
+}
+
+func (r *locationRepository) ReplaceTranslations(ctx context.Context, locationID int64, translations []location.Translation, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.locations[locationID]
+	if !ok {
+		return location.ErrNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return location.ErrVersionMismatch
+	}
+
+	if len(translations) == 0 {
+		delete(r.store.locationTranslations, locationID)
+	} else {
+		translations = slices.Clone(translations)
+		sort.Slice(translations, func(i, j int) bool { return translations[i].Language < translations[j].Language })
+		r.store.locationTranslations[locationID] = translations
+	}
+	existing.Version++
+	r.store.locations[locationID] = existing
+
+	return nil
+}
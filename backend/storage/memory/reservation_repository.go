@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/reservation"
+)
+
+type reservationRepository struct {
+	store *Store
+}
+
+func (r *reservationRepository) Create(ctx context.Context, res reservation.Reservation) (reservation.Reservation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, ok := r.store.holidays[res.HolidayID]
+	if !ok {
+		return reservation.Reservation{}, holiday.ErrNotFound
+	}
+	if h.Status != holiday.Published {
+		return reservation.Reservation{}, reservation.ErrNotBookable
+	}
+	if h.FreeSlots <= 0 {
+		return reservation.Reservation{}, reservation.ErrNoFreeSlots
+	}
+	h.FreeSlots--
+	h.Version++
+	r.store.holidays[h.ID] = h
+
+	res.ID = r.store.sequence("reservations")
+	res.Version = 1
+	r.store.reservations[res.ID] = res
+
+	return res, nil
+}
+
+func (r *reservationRepository) Delete(ctx context.Context, reservationID int64, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	res, ok := r.store.reservations[reservationID]
+	if !ok {
+		return reservation.ErrNotFound
+	}
+	if version != 0 && res.Version != version {
+		return reservation.ErrVersionMismatch
+	}
+	delete(r.store.reservations, reservationID)
+
+	if h, ok := r.store.holidays[res.HolidayID]; ok {
+		h.FreeSlots++
+		h.Version++
+		r.store.holidays[h.ID] = h
+	}
+
+	return nil
+}
+
+func (r *reservationRepository) List(ctx context.Context) ([]reservation.Reservation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var reservations []reservation.Reservation
+	for _, res := range r.store.reservations {
+		reservations = append(reservations, res)
+	}
+
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].ID < reservations[j].ID })
+	return reservations, nil
+}
+
+func (r *reservationRepository) Get(ctx context.Context, reservationID int64) (reservation.Reservation, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	res, ok := r.store.reservations[reservationID]
+	if !ok {
+		return reservation.Reservation{}, reservation.ErrNotFound
+	}
+
+	return res, nil
+}
+
+func (r *reservationRepository) Update(ctx context.Context, res reservation.Reservation) (reservation.Reservation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.reservations[res.ID]
+	if !ok {
+		return reservation.Reservation{}, reservation.ErrNotFound
+	}
+	if res.Version != 0 && existing.Version != res.Version {
+		return reservation.Reservation{}, reservation.ErrVersionMismatch
+	}
+
+	if res.HolidayID != 0 && res.HolidayID != existing.HolidayID {
+		target, ok := r.store.holidays[res.HolidayID]
+		if !ok {
+			return reservation.Reservation{}, holiday.ErrNotFound
+		}
+		if target.Status != holiday.Published {
+			return reservation.Reservation{}, reservation.ErrNotBookable
+		}
+		if target.FreeSlots <= 0 {
+			return reservation.Reservation{}, reservation.ErrNoFreeSlots
+		}
+		target.FreeSlots--
+		target.Version++
+		r.store.holidays[target.ID] = target
+
+		if previous, ok := r.store.holidays[existing.HolidayID]; ok {
+			previous.FreeSlots++
+			previous.Version++
+			r.store.holidays[previous.ID] = previous
+		}
+		existing.HolidayID = res.HolidayID
+	}
+
+	existing.PhoneNumber = res.PhoneNumber
+	existing.ContactName = res.ContactName
+	existing.Version++
+	r.store.reservations[res.ID] = existing
+
+	return existing, nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/search"
+)
+
+type searchRepository struct {
+	store *Store
+}
+
+func (r *searchRepository) Search(ctx context.Context, query search.Query) ([]search.Hit, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.searchIndex.Search(query, r.fingerprint(), r.documents)
+}
+
+// fingerprint summarises the holidays and locations for the search index.
+// Callers must hold the read lock.
+func (r *searchRepository) fingerprint() string {
+	var holidays, locations [3]int64
+	for id, h := range r.store.holidays {
+		holidays[0]++
+		holidays[1] += h.Version
+		holidays[2] = max(holidays[2], id)
+	}
+	for id, l := range r.store.locations {
+		locations[0]++
+		locations[1] += l.Version
+		locations[2] = max(locations[2], id)
+	}
+
+	return search.Fingerprint(holidays[0], holidays[1], holidays[2]) + " " +
+		search.Fingerprint(locations[0], locations[1], locations[2])
+}
+
+// documents returns the searchable holidays, which are the published ones,
+// and then locations, each in id order. Callers must hold the read lock.
+func (r *searchRepository) documents() ([]search.Document, error) {
+	var documents []search.Document
+	for _, h := range r.store.holidays {
+		if h.Status != holiday.Published {
+			continue
+		}
+		var tagNames []string
+		for _, tagID := range r.store.holidayTags[h.ID] {
+			tagNames = append(tagNames, r.store.tags[tagID].Name)
+		}
+		documents = append(documents, search.HolidayDocument(h, r.store.locations[h.LocationID], tagNames))
+	}
+	for _, l := range r.store.locations {
+		documents = append(documents, search.LocationDocument(l))
+	}
+
+	sort.Slice(documents, func(i, j int) bool {
+		if documents[i].Kind != documents[j].Kind {
+			return documents[i].Kind < documents[j].Kind
+		}
+		return documents[i].ID < documents[j].ID
+	})
+	return documents, nil
+}
@@ -0,0 +1,102 @@
+// Package memory implements the repositories in process memory. It needs no
+// database and is meant for tests and local development; all data is lost on
+// restart.
+package memory
+
+import (
+	"sync"
+
+	"github.com/nikolaypleshkov/uni-api/api/country"
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/image"
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	"github.com/nikolaypleshkov/uni-api/api/reservation"
+	"github.com/nikolaypleshkov/uni-api/api/search"
+	"github.com/nikolaypleshkov/uni-api/api/tag"
+	"github.com/nikolaypleshkov/uni-api/api/template"
+	"github.com/nikolaypleshkov/uni-api/idempotency"
+)
+
+// Store holds every table behind one lock so that repositories can check
+// references across tables the way the database foreign keys do.
+type Store struct {
+	mu           sync.RWMutex
+	locations    map[int64]location.Location
+	holidays     map[int64]holiday.Holiday
+	stops        map[int64][]holiday.Stop
+	reservations map[int64]reservation.Reservation
+	images       map[int64]image.Image
+	tags         map[int64]tag.Tag
+	holidayTags  map[int64][]int64
+	templates    map[int64]template.Template
+	nextID       map[string]int64
+
+	holidayTranslations  map[int64][]holiday.Translation
+	locationTranslations map[int64][]location.Translation
+
+	idempotencyKeys map[string]idempotency.Record
+
+	// searchIndex caches the search documents between searches.
+	searchIndex search.Index
+}
+
+func New() *Store {
+	return &Store{
+		locations:    make(map[int64]location.Location),
+		holidays:     make(map[int64]holiday.Holiday),
+		stops:        make(map[int64][]holiday.Stop),
+		reservations: make(map[int64]reservation.Reservation),
+		images:       make(map[int64]image.Image),
+		tags:         make(map[int64]tag.Tag),
+		holidayTags:  make(map[int64][]int64),
+		templates:    make(map[int64]template.Template),
+		nextID:       make(map[string]int64),
+
+		holidayTranslations:  make(map[int64][]holiday.Translation),
+		locationTranslations: make(map[int64][]location.Translation),
+
+		idempotencyKeys: make(map[string]idempotency.Record),
+	}
+}
+
+func (s *Store) Holidays() holiday.HolidayRepository {
+	return &holidayRepository{store: s}
+}
+
+func (s *Store) Locations() location.LocationRepository {
+	return &locationRepository{store: s}
+}
+
+func (s *Store) Reservations() reservation.ReservationRepository {
+	return &reservationRepository{store: s}
+}
+
+func (s *Store) Countries() country.CountryRepository {
+	return &countryRepository{store: s}
+}
+
+func (s *Store) Images() image.ImageRepository {
+	return &imageRepository{store: s}
+}
+
+func (s *Store) Tags() tag.TagRepository {
+	return &tagRepository{store: s}
+}
+
+func (s *Store) Templates() template.TemplateRepository {
+	return &templateRepository{store: s}
+}
+
+func (s *Store) Search() search.SearchRepository {
+	return &searchRepository{store: s}
+}
+
+func (s *Store) IdempotencyKeys() idempotency.Store {
+	return &idempotencyRepository{store: s}
+}
+
+// sequence returns the next id for table. Callers must hold the write lock.
+func (s *Store) sequence(table string) int64 {
+	s.nextID[table]++
+	return s.nextID[table]
+}
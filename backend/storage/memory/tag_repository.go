@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/nikolaypleshkov/uni-api/api/tag"
+)
+
+type tagRepository struct {
+	store *Store
+}
+
+func (r *tagRepository) Create(ctx context.Context, t tag.Tag) (tag.Tag, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.slugTaken(t.Slug, 0) {
+		return tag.Tag{}, tag.ErrDuplicateSlug
+	}
+
+	t.ID = r.store.sequence("tags")
+	t.Version = 1
+	r.store.tags[t.ID] = t
+
+	return t, nil
+}
+
+func (r *tagRepository) Delete(ctx context.Context, tagID int64, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.tags[tagID]
+	if !ok {
+		return tag.ErrNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return tag.ErrVersionMismatch
+	}
+
+	r.touchHolidays(tagID)
+	for holidayID, tagIDs := range r.store.holidayTags {
+		r.store.holidayTags[holidayID] = slices.DeleteFunc(tagIDs, func(id int64) bool { return id == tagID })
+	}
+	delete(r.store.tags, tagID)
+
+	return nil
+}
+
+func (r *tagRepository) List(ctx context.Context) ([]tag.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var tags []tag.Tag
+	for _, t := range r.store.tags {
+		tags = append(tags, t)
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Slug < tags[j].Slug })
+	return tags, nil
+}
+
+func (r *tagRepository) Get(ctx context.Context, tagID int64) (tag.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	t, ok := r.store.tags[tagID]
+	if !ok {
+		return tag.Tag{}, tag.ErrNotFound
+	}
+
+	return t, nil
+}
+
+func (r *tagRepository) Update(ctx context.Context, t tag.Tag) (tag.Tag, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.tags[t.ID]
+	if !ok {
+		return tag.Tag{}, tag.ErrNotFound
+	}
+	if t.Version != 0 && existing.Version != t.Version {
+		return tag.Tag{}, tag.ErrVersionMismatch
+	}
+	if r.slugTaken(t.Slug, t.ID) {
+		return tag.Tag{}, tag.ErrDuplicateSlug
+	}
+
+	r.touchHolidays(t.ID)
+	t.Version = existing.Version + 1
+	r.store.tags[t.ID] = t
+
+	return t, nil
+}
+
+// slugTaken reports whether a tag other than exceptID has the slug. Callers
+// must hold the lock.
+func (r *tagRepository) slugTaken(slug string, exceptID int64) bool {
+	for _, t := range r.store.tags {
+		if t.Slug == slug && t.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// touchHolidays increments the version of every holiday with the tag.
+// Callers must hold the write lock.
+func (r *tagRepository) touchHolidays(tagID int64) {
+	for holidayID, tagIDs := range r.store.holidayTags {
+		if slices.Contains(tagIDs, tagID) {
+			h := r.store.holidays[holidayID]
+			h.Version++
+			r.store.holidays[holidayID] = h
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/template"
+)
+
+type templateRepository struct {
+	store *Store
+}
+
+func (r *templateRepository) departures() *holidayRepository {
+	return &holidayRepository{store: r.store}
+}
+
+func (r *templateRepository) Create(ctx context.Context, t template.Template, dates []string) (template.Template, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.checkLocation(t.LocationID); err != nil {
+		return template.Template{}, err
+	}
+
+	t = cloneTemplate(t)
+	t.ID = r.store.sequence("templates")
+	t.Version = 1
+	r.store.templates[t.ID] = t
+
+	for _, date := range dates {
+		if _, err := r.departures().insert(t.Departure(date)); err != nil {
+			return template.Template{}, err
+		}
+	}
+
+	return t, nil
+}
+
+// Delete detaches the departures, which is a write to each of them.
+func (r *templateRepository) Delete(ctx context.Context, templateID int64, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.templates[templateID]
+	if !ok {
+		return template.ErrNotFound
+	}
+	if version != 0 && existing.Version != version {
+		return template.ErrVersionMismatch
+	}
+	for id, h := range r.store.holidays {
+		if h.TemplateID == templateID {
+			h.TemplateID = 0
+			h.Version++
+			r.store.holidays[id] = h
+		}
+	}
+	delete(r.store.templates, templateID)
+
+	return nil
+}
+
+func (r *templateRepository) List(ctx context.Context) ([]template.Template, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var templates []template.Template
+	for _, t := range r.store.templates {
+		templates = append(templates, t)
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+	return templates, nil
+}
+
+func (r *templateRepository) Get(ctx context.Context, templateID int64) (template.Template, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	t, ok := r.store.templates[templateID]
+	if !ok {
+		return template.Template{}, template.ErrNotFound
+	}
+
+	return t, nil
+}
+
+// Update makes the same changes to the departures as the SQL store.
+func (r *templateRepository) Update(ctx context.Context, t template.Template, dates []string, after string) (template.Template, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.templates[t.ID]
+	if !ok {
+		return template.Template{}, template.ErrNotFound
+	}
+	if t.Version != 0 && existing.Version != t.Version {
+		return template.Template{}, template.ErrVersionMismatch
+	}
+	if err := r.checkLocation(t.LocationID); err != nil {
+		return template.Template{}, err
+	}
+
+	if err := r.checkItineraries(t, after); err != nil {
+		return template.Template{}, err
+	}
+
+	t = cloneTemplate(t)
+	t.Version = existing.Version + 1
+	r.store.templates[t.ID] = t
+
+	scheduled := make(map[string]bool)
+	for id, h := range r.store.holidays {
+		if h.TemplateID != t.ID {
+			continue
+		}
+		date := h.StartDate[:min(len(h.StartDate), len(time.DateOnly))]
+		scheduled[date] = true
+		if date <= after || h.Status == holiday.Archived {
+			continue
+		}
+		if !slices.Contains(dates, date) && !r.reserved(id) {
+			r.departures().remove(id)
+			continue
+		}
+
+		revised := t.Departure(h.StartDate)
+		revised.ID = id
+		revised.FreeSlots = max(h.FreeSlots+t.FreeSlots-existing.FreeSlots, 0)
+		revised.PublishAt, revised.UnpublishAt = h.PublishAt, h.UnpublishAt
+		if h.Status == holiday.Published && r.reserved(id) {
+			revised.Status = h.Status
+		}
+		if t.Status == holiday.Published {
+			revised.PublishAt = nil
+		}
+		revised.Included, revised.Excluded = slices.Clone(revised.Included), slices.Clone(revised.Excluded)
+		revised.Version = h.Version + 1
+		r.store.holidays[id] = revised
+	}
+
+	for _, date := range dates {
+		if scheduled[date] {
+			continue
+		}
+		if _, err := r.departures().insert(t.Departure(date)); err != nil {
+			return template.Template{}, err
+		}
+	}
+
+	return t, nil
+}
+
+// checkLocation fails with template.ErrUnknownLocation when the template
+// refers to a location that does not exist. Callers must hold the lock.
+func (r *templateRepository) checkLocation(locationID int64) error {
+	if locationID <= 0 {
+		return nil
+	}
+	if _, ok := r.store.locations[locationID]; !ok {
+		return fmt.Errorf("%w: %d", template.ErrUnknownLocation, locationID)
+	}
+	return nil
+}
+
+// checkItineraries refuses a duration that the itinerary of one of the
+// template's departures after the date after does not fit in. Callers must
+// hold the lock.
+func (r *templateRepository) checkItineraries(t template.Template, after string) error {
+	var ids []int64
+	for id, h := range r.store.holidays {
+		if h.TemplateID == t.ID && h.Status != holiday.Archived && h.StartDate[:min(len(h.StartDate), len(time.DateOnly))] > after {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b int64) int {
+		return strings.Compare(r.store.holidays[a].StartDate, r.store.holidays[b].StartDate)
+	})
+
+	for _, id := range ids {
+		for _, stop := range r.store.stops[id] {
+			if end := stop.DayOffset + stop.Nights; end > t.Duration {
+				startDate := r.store.holidays[id].StartDate
+				return fmt.Errorf("%w: the itinerary of the departure on %s ends on day %d, after the %d-day holiday",
+					template.ErrInvalidTemplate, startDate[:min(len(startDate), len(time.DateOnly))], end, t.Duration)
+			}
+		}
+	}
+	return nil
+}
+
+// reserved reports whether a holiday has any reservations. Callers must hold
+// the lock.
+func (r *templateRepository) reserved(holidayID int64) bool {
+	for _, res := range r.store.reservations {
+		if res.HolidayID == holidayID {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneTemplate(t template.Template) template.Template {
+	t.Included, t.Excluded = slices.Clone(t.Included), slices.Clone(t.Excluded)
+	t.ExcludedDates = slices.Clone(t.ExcludedDates)
+	return t
+}
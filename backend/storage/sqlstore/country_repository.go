@@ -0,0 +1,73 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/nikolaypleshkov/uni-api/api/country"
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+type countryRepository struct {
+	conn
+}
+
+func (r *countryRepository) ListStats(ctx context.Context) ([]country.Stats, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT l.country_code, COUNT(DISTINCT LOWER(l.city)), COUNT(DISTINCT l.id), COUNT(h.id)
+		FROM locations l
+		LEFT JOIN holidays h ON h.location_id = l.id AND h.status = ?
+		WHERE l.country_code <> ''
+		GROUP BY l.country_code
+		ORDER BY l.country_code`
+
+	rows, err := r.query(ctx, query, holiday.Published)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []country.Stats
+	for rows.Next() {
+		var s country.Stats
+		if err := rows.Scan(&s.CountryCode, &s.Cities, &s.Locations, &s.Holidays); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+func (r *countryRepository) ListCityStats(ctx context.Context, countryCode string) ([]country.CityStats, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT MIN(l.city), COUNT(DISTINCT l.id), COUNT(h.id)
+		FROM locations l
+		LEFT JOIN holidays h ON h.location_id = l.id AND h.status = ?
+		WHERE l.country_code = ?
+		GROUP BY LOWER(l.city)
+		ORDER BY LOWER(l.city)`
+
+	rows, err := r.query(ctx, query, holiday.Published, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []country.CityStats
+	for rows.Next() {
+		var s country.CityStats
+		if err := rows.Scan(&s.City, &s.Locations, &s.Holidays); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
@@ -0,0 +1,620 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+const holidayColumns = "id, title, description, start_date, duration, free_slots, price, location_id, " +
+	"included, excluded, accommodation_name, accommodation_type, accommodation_stars, accommodation_description, " +
+	"status, publish_at, unpublish_at, template_id, version"
+
+type holidayRepository struct {
+	conn
+}
+
+func scanHoliday(row scanner) (holiday.Holiday, error) {
+	var h holiday.Holiday
+	var locationID sql.NullInt64
+	var included, excluded string
+	var publishAt, unpublishAt sql.NullInt64
+	var templateID sql.NullInt64
+	err := row.Scan(
+		&h.ID,
+		&h.Title,
+		&h.Description,
+		&h.StartDate,
+		&h.Duration,
+		&h.FreeSlots,
+		decimal{&h.Price},
+		&locationID,
+		&included,
+		&excluded,
+		&h.Accommodation.Name,
+		&h.Accommodation.Type,
+		&h.Accommodation.Stars,
+		&h.Accommodation.Description,
+		&h.Status,
+		&publishAt,
+		&unpublishAt,
+		&templateID,
+		&h.Version,
+	)
+	h.LocationID, h.TemplateID = locationID.Int64, templateID.Int64
+	h.PublishAt, h.UnpublishAt = timeOrNil(publishAt), timeOrNil(unpublishAt)
+	h.Included, h.Excluded = splitServices(included), splitServices(excluded)
+	return h, err
+}
+
+// Service codes are stored comma-separated; they contain no commas.
+func joinServices(services []string) string {
+	return strings.Join(services, ",")
+}
+
+func splitServices(column string) []string {
+	if column == "" {
+		return []string{}
+	}
+	return strings.Split(column, ",")
+}
+
+func (r *holidayRepository) Create(ctx context.Context, h holiday.Holiday) (holiday.Holiday, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return insertHoliday(ctx, r, h)
+}
+
+// insertHoliday inserts h, which templates also do for their departures.
+func insertHoliday(ctx context.Context, q rowQuerier, h holiday.Holiday) (holiday.Holiday, error) {
+	query := `
+        INSERT INTO holidays (title, description, start_date, duration, free_slots, price, location_id,
+            included, excluded, accommodation_name, accommodation_type, accommodation_stars, accommodation_description,
+            status, publish_at, unpublish_at, template_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING ` + holidayColumns
+
+	row := q.queryRow(
+		ctx,
+		query,
+		h.Title,
+		h.Description,
+		h.StartDate,
+		h.Duration,
+		h.FreeSlots,
+		h.Price,
+		nullableID(h.LocationID),
+		joinServices(h.Included),
+		joinServices(h.Excluded),
+		h.Accommodation.Name,
+		h.Accommodation.Type,
+		h.Accommodation.Stars,
+		h.Accommodation.Description,
+		h.Status,
+		nullableTime(h.PublishAt),
+		nullableTime(h.UnpublishAt),
+		nullableID(h.TemplateID),
+	)
+
+	return scanHoliday(row)
+}
+
+func (r *holidayRepository) Delete(ctx context.Context, holidayID int64, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	condition, versionArgs := versionCondition(version)
+	result, err := r.exec(ctx, "DELETE FROM holidays WHERE id = ?"+condition, append([]any{holidayID}, versionArgs...)...)
+	if err != nil {
+		return err
+	}
+
+	return expectWrite(ctx, r, result, "holidays", holidayID, holiday.ErrNotFound, holiday.ErrVersionMismatch)
+}
+
+func (r *holidayRepository) List(ctx context.Context, filter holiday.HolidayFilter) ([]holiday.Holiday, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	where := "1 = 1"
+	var args []any
+
+	if filter.StartDate != "" {
+		args = append(args, filter.StartDate)
+		where += " AND start_date = ?"
+	}
+	if filter.StartFrom != "" {
+		args = append(args, filter.StartFrom)
+		where += " AND start_date >= ?"
+	}
+	if filter.StartBefore != "" {
+		args = append(args, filter.StartBefore)
+		where += " AND start_date < ?"
+	}
+	if filter.Duration != nil {
+		args = append(args, *filter.Duration)
+		where += " AND duration = ?"
+	}
+	if filter.CountryCode != "" {
+		condition, conditionArgs := visitsCondition(holiday.LocationMatch{CountryCode: filter.CountryCode})
+		args = append(args, conditionArgs...)
+		where += " AND " + condition
+	}
+	if filter.Location != nil {
+		condition, conditionArgs := visitsCondition(*filter.Location)
+		args = append(args, conditionArgs...)
+		where += " AND " + condition
+	}
+	if len(filter.Statuses) > 0 {
+		where += " AND status IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(filter.Statuses)), ", ") + ")"
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.TemplateID != 0 {
+		args = append(args, filter.TemplateID)
+		where += " AND template_id = ?"
+	}
+	for _, slug := range filter.Tags {
+		args = append(args, slug)
+		where += " AND id IN (SELECT holiday_id FROM holiday_tags JOIN tags ON tags.id = holiday_tags.tag_id WHERE tags.slug = ?)"
+	}
+
+	rows, err := r.query(ctx, "SELECT "+holidayColumns+" FROM holidays WHERE "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var holidays []holiday.Holiday
+	for rows.Next() {
+		h, err := scanHoliday(rows)
+		if err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, h)
+	}
+
+	return holidays, rows.Err()
+}
+
+func (r *holidayRepository) Get(ctx context.Context, holidayID int64) (holiday.Holiday, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	row := r.queryRow(ctx, "SELECT "+holidayColumns+" FROM holidays WHERE id = ?", holidayID)
+
+	h, err := scanHoliday(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return holiday.Holiday{}, holiday.ErrNotFound
+	}
+
+	return h, err
+}
+
+func (r *holidayRepository) GetMany(ctx context.Context, holidayIDs []int64) (map[int64]holiday.Holiday, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	holidays := make(map[int64]holiday.Holiday)
+	if len(holidayIDs) == 0 {
+		return holidays, nil
+	}
+
+	in, args := inList(holidayIDs)
+	rows, err := r.query(ctx, "SELECT "+holidayColumns+" FROM holidays WHERE id IN "+in, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h, err := scanHoliday(rows)
+		if err != nil {
+			return nil, err
+		}
+		holidays[h.ID] = h
+	}
+
+	return holidays, rows.Err()
+}
+
+func (r *holidayRepository) Update(ctx context.Context, h holiday.Holiday) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+        UPDATE holidays
+        SET title = ?, description = ?, start_date = ?, duration = ?, free_slots = ?, price = ?,
+            location_id = ?, included = ?, excluded = ?, accommodation_name = ?, accommodation_type = ?,
+            accommodation_stars = ?, accommodation_description = ?, status = ?, publish_at = ?,
+            unpublish_at = ?, version = version + 1
+        WHERE id = ?`
+
+	condition, versionArgs := versionCondition(h.Version)
+	args := []any{
+		h.Title,
+		h.Description,
+		h.StartDate,
+		h.Duration,
+		h.FreeSlots,
+		h.Price,
+		nullableID(h.LocationID),
+		joinServices(h.Included),
+		joinServices(h.Excluded),
+		h.Accommodation.Name,
+		h.Accommodation.Type,
+		h.Accommodation.Stars,
+		h.Accommodation.Description,
+		h.Status,
+		nullableTime(h.PublishAt),
+		nullableTime(h.UnpublishAt),
+		h.ID,
+	}
+
+	result, err := r.exec(ctx, query+condition, append(args, versionArgs...)...)
+	if err != nil {
+		return err
+	}
+
+	return expectWrite(ctx, r, result, "holidays", h.ID, holiday.ErrNotFound, holiday.ErrVersionMismatch)
+}
+
+// visitsCondition returns a condition on holidays whose location or any
+// itinerary stop matches match.
+func visitsCondition(match holiday.LocationMatch) (string, []any) {
+	var conditions []string
+	var args []any
+	if match.LocationID != 0 {
+		conditions = append(conditions, "id = ?")
+		args = append(args, match.LocationID)
+	}
+	if match.City != "" {
+		conditions = append(conditions, "LOWER(city) = LOWER(?)")
+		args = append(args, match.City)
+	}
+	if match.CountryCode != "" {
+		conditions = append(conditions, "country_code = ?")
+		args = append(args, match.CountryCode)
+	}
+	if len(conditions) == 0 {
+		return "1 = 0", nil
+	}
+
+	locations := "SELECT id FROM locations WHERE " + strings.Join(conditions, " OR ")
+	condition := "(location_id IN (" + locations + ")" +
+		" OR id IN (SELECT holiday_id FROM holiday_stops WHERE location_id IN (" + locations + ")))"
+	return condition, append(args, args...)
+}
+
+func (r *holidayRepository) GetItinerary(ctx context.Context, holidayID int64) ([]holiday.Stop, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var exists int
+	err := r.queryRow(ctx, "SELECT 1 FROM holidays WHERE id = ?", holidayID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, holiday.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.query(
+		ctx,
+		"SELECT location_id, day_offset, nights FROM holiday_stops WHERE holiday_id = ? ORDER BY sort_order",
+		holidayID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stops []holiday.Stop
+	for rows.Next() {
+		var stop holiday.Stop
+		if err := rows.Scan(&stop.LocationID, &stop.DayOffset, &stop.Nights); err != nil {
+			return nil, err
+		}
+		stops = append(stops, stop)
+	}
+
+	return stops, rows.Err()
+}
+
+func (r *holidayRepository) ReplaceItinerary(ctx context.Context, holidayID int64, stops []holiday.Stop, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.inTx(ctx, func(tx txConn) error {
+		// Bumping the version first locks the holiday row, so concurrent
+		// replacements of the same itinerary are serialised.
+		condition, versionArgs := versionCondition(version)
+		result, err := tx.exec(
+			ctx,
+			"UPDATE holidays SET version = version + 1 WHERE id = ?"+condition,
+			append([]any{holidayID}, versionArgs...)...,
+		)
+		if err != nil {
+			return err
+		}
+		if err := expectWrite(ctx, tx, result, "holidays", holidayID, holiday.ErrNotFound, holiday.ErrVersionMismatch); err != nil {
+			return err
+		}
+
+		if _, err := tx.exec(ctx, "DELETE FROM holiday_stops WHERE holiday_id = ?", holidayID); err != nil {
+			return err
+		}
+		for position, stop := range stops {
+			var exists int
+			err := tx.queryRow(ctx, "SELECT 1 FROM locations WHERE id = ?", stop.LocationID).Scan(&exists)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %d", holiday.ErrUnknownLocation, stop.LocationID)
+			}
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.exec(
+				ctx,
+				"INSERT INTO holiday_stops (holiday_id, sort_order, location_id, day_offset, nights) VALUES (?, ?, ?, ?, ?)",
+				holidayID,
+				position,
+				stop.LocationID,
+				stop.DayOffset,
+				stop.Nights,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *holidayRepository) ListItineraries(ctx context.Context, holidayIDs []int64) (map[int64][]holiday.Stop, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	itineraries := make(map[int64][]holiday.Stop)
+	if len(holidayIDs) == 0 {
+		return itineraries, nil
+	}
+
+	in, args := inList(holidayIDs)
+	rows, err := r.query(
+		ctx,
+		"SELECT holiday_id, location_id, day_offset, nights FROM holiday_stops WHERE holiday_id IN "+in+" ORDER BY holiday_id, sort_order",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var holidayID int64
+		var stop holiday.Stop
+		if err := rows.Scan(&holidayID, &stop.LocationID, &stop.DayOffset, &stop.Nights); err != nil {
+			return nil, err
+		}
+		itineraries[holidayID] = append(itineraries[holidayID], stop)
+	}
+
+	return itineraries, rows.Err()
+}
+
+func (r *holidayRepository) ListTags(ctx context.Context, holidayIDs []int64) (map[int64][]string, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	tags := make(map[int64][]string)
+	if len(holidayIDs) == 0 {
+		return tags, nil
+	}
+
+	in, args := inList(holidayIDs)
+	rows, err := r.query(
+		ctx,
+		`SELECT holiday_tags.holiday_id, tags.slug FROM holiday_tags
+		JOIN tags ON tags.id = holiday_tags.tag_id
+		WHERE holiday_tags.holiday_id IN `+in+`
+		ORDER BY tags.slug`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var holidayID int64
+		var slug string
+		if err := rows.Scan(&holidayID, &slug); err != nil {
+			return nil, err
+		}
+		tags[holidayID] = append(tags[holidayID], slug)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *holidayRepository) ReplaceTags(ctx context.Context, holidayID int64, slugs []string, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.inTx(ctx, func(tx txConn) error {
+		condition, versionArgs := versionCondition(version)
+		result, err := tx.exec(
+			ctx,
+			"UPDATE holidays SET version = version + 1 WHERE id = ?"+condition,
+			append([]any{holidayID}, versionArgs...)...,
+		)
+		if err != nil {
+			return err
+		}
+		if err := expectWrite(ctx, tx, result, "holidays", holidayID, holiday.ErrNotFound, holiday.ErrVersionMismatch); err != nil {
+			return err
+		}
+
+		if _, err := tx.exec(ctx, "DELETE FROM holiday_tags WHERE holiday_id = ?", holidayID); err != nil {
+			return err
+		}
+		added := make(map[int64]bool)
+		for _, slug := range slugs {
+			var tagID int64
+			err := tx.queryRow(ctx, "SELECT id FROM tags WHERE slug = ?", slug).Scan(&tagID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %q", holiday.ErrUnknownTag, slug)
+			}
+			if err != nil {
+				return err
+			}
+			if added[tagID] {
+				continue
+			}
+			added[tagID] = true
+
+			if _, err := tx.exec(ctx, "INSERT INTO holiday_tags (holiday_id, tag_id) VALUES (?, ?)", holidayID, tagID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *holidayRepository) ListTranslations(ctx context.Context, holidayIDs []int64) (map[int64][]holiday.Translation, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	translations := make(map[int64][]holiday.Translation)
+	if len(holidayIDs) == 0 {
+		return translations, nil
+	}
+
+	in, args := inList(holidayIDs)
+	rows, err := r.query(
+		ctx,
+		`SELECT holiday_id, language, title, description, accommodation_description
+		FROM holiday_translations
+		WHERE holiday_id IN `+in+`
+		ORDER BY language`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var holidayID int64
+		var t holiday.Translation
+		if err := rows.Scan(&holidayID, &t.Language, &t.Title, &t.Description, &t.AccommodationDescription); err != nil {
+			return nil, err
+		}
+		translations[holidayID] = append(translations[holidayID], t)
+	}
+
+	return translations, rows.Err()
+}
+
+func (r *holidayRepository) ReplaceTranslations(ctx context.Context, holidayID int64, translations []holiday.Translation, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.inTx(ctx, func(tx txConn) error {
+		condition, versionArgs := versionCondition(version)
+		result, err := tx.exec(
+			ctx,
+			"UPDATE holidays SET version = version + 1 WHERE id = ?"+condition,
+			append([]any{holidayID}, versionArgs...)...,
+		)
+		if err != nil {
+			return err
+		}
+		if err := expectWrite(ctx, tx, result, "holidays", holidayID, holiday.ErrNotFound, holiday.ErrVersionMismatch); err != nil {
+			return err
+		}
+
+		if _, err := tx.exec(ctx, "DELETE FROM holiday_translations WHERE holiday_id = ?", holidayID); err != nil {
+			return err
+		}
+		for _, t := range translations {
+			_, err := tx.exec(
+				ctx,
+				`INSERT INTO holiday_translations (holiday_id, language, title, description, accommodation_description)
+				VALUES (?, ?, ?, ?, ?)`,
+				holidayID, t.Language, t.Title, t.Description, t.AccommodationDescription,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// inList returns a parenthesised list of placeholders for ids and the
+// matching arguments. ids must not be empty.
+func inList(ids []int64) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return "(?" + strings.Repeat(", ?", len(ids)-1) + ")", args
+}
+
+// ApplySchedule runs the three transitions as separate statements in one
+// transaction. Archiving comes last, so a holiday published or taken back
+// to draft after it started is still archived.
+func (r *holidayRepository) ApplySchedule(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{
+			"UPDATE holidays SET status = ?, publish_at = NULL, version = version + 1 WHERE status = ? AND publish_at <= ?",
+			[]any{holiday.Published, holiday.Draft, now.UnixMilli()},
+		},
+		{
+			"UPDATE holidays SET status = ?, unpublish_at = NULL, version = version + 1 WHERE status = ? AND unpublish_at <= ?",
+			[]any{holiday.Draft, holiday.Published, now.UnixMilli()},
+		},
+		{
+			"UPDATE holidays SET status = ?, publish_at = NULL, unpublish_at = NULL, version = version + 1 WHERE status <> ? AND start_date < ?",
+			[]any{holiday.Archived, holiday.Archived, now.UTC().Format(time.DateOnly)},
+		},
+	}
+
+	var changed int64
+	err := r.inTx(ctx, func(tx txConn) error {
+		changed = 0
+		for _, statement := range statements {
+			result, err := tx.exec(ctx, statement.query, statement.args...)
+			if err != nil {
+				return err
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			changed += rowsAffected
+		}
+		return nil
+	})
+
+	return changed, err
+}
@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/database"
+	"github.com/nikolaypleshkov/uni-api/idempotency"
+)
+
+// idempotencyRepository stores timestamps as Unix milliseconds so that both
+// dialects compare them the same way.
+type idempotencyRepository struct {
+	conn
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record idempotency.Record, expiredBefore, abandonedBefore time.Time) (idempotency.Record, bool, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := r.exec(
+		ctx,
+		`DELETE FROM idempotency_keys
+		WHERE idempotency_key = ? AND created_at < CASE WHEN status = 0 THEN ? ELSE ? END`,
+		record.Key,
+		abandonedBefore.UnixMilli(),
+		expiredBefore.UnixMilli(),
+	)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	result, err := r.exec(
+		ctx,
+		`INSERT INTO idempotency_keys (idempotency_key, fingerprint, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (idempotency_key) DO NOTHING`,
+		record.Key,
+		record.Fingerprint,
+		record.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	if rowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var (
+		existing  idempotency.Record
+		createdAt int64
+		body      string
+	)
+	err = r.queryRow(
+		ctx,
+		`SELECT idempotency_key, fingerprint, created_at, status, content_type, body
+		FROM idempotency_keys WHERE idempotency_key = ?`,
+		record.Key,
+	).Scan(
+		&existing.Key,
+		&existing.Fingerprint,
+		&createdAt,
+		&existing.Status,
+		&existing.ContentType,
+		&body,
+	)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+	existing.CreatedAt = time.UnixMilli(createdAt)
+	existing.Body = []byte(body)
+
+	return existing, false, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record idempotency.Record) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := r.exec(
+		ctx,
+		"UPDATE idempotency_keys SET status = ?, content_type = ?, body = ? WHERE idempotency_key = ?",
+		record.Status,
+		record.ContentType,
+		string(record.Body),
+		record.Key,
+	)
+	return err
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, key string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := r.exec(ctx, "DELETE FROM idempotency_keys WHERE idempotency_key = ?", key)
+	return err
+}
+
+func (r *idempotencyRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.exec(ctx, "DELETE FROM idempotency_keys WHERE created_at < ?", before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
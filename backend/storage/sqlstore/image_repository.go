@@ -0,0 +1,224 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/nikolaypleshkov/uni-api/api/image"
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+const imageColumns = "id, owner_type, owner_id, sort_order, blob_key, thumbnail_key, content_type, width, height, byte_size"
+
+// The owner of an image is stored as its kind and ID rather than as foreign
+// keys, so that deleting a location or holiday leaves its images for
+// image.Service.PurgeOrphans, which removes their files as well.
+type imageRepository struct {
+	conn
+}
+
+func scanImage(row scanner) (image.Image, error) {
+	var i image.Image
+	err := row.Scan(
+		&i.ID,
+		&i.Owner.Kind,
+		&i.Owner.ID,
+		&i.Position,
+		&i.Key,
+		&i.ThumbnailKey,
+		&i.ContentType,
+		&i.Width,
+		&i.Height,
+		&i.Size,
+	)
+	return i, err
+}
+
+func (r *imageRepository) Create(ctx context.Context, i image.Image) (image.Image, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var created image.Image
+	err := r.inTx(ctx, func(tx txConn) error {
+		if err := ownerExists(ctx, tx, i.Owner); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO images (owner_type, owner_id, sort_order, blob_key, thumbnail_key, content_type, width, height, byte_size)
+			VALUES (?, ?, (SELECT COALESCE(MAX(sort_order) + 1, 0) FROM images WHERE owner_type = ? AND owner_id = ?), ?, ?, ?, ?, ?, ?)
+			RETURNING ` + imageColumns
+
+		var err error
+		created, err = scanImage(tx.queryRow(
+			ctx,
+			query,
+			i.Owner.Kind,
+			i.Owner.ID,
+			i.Owner.Kind,
+			i.Owner.ID,
+			i.Key,
+			i.ThumbnailKey,
+			i.ContentType,
+			i.Width,
+			i.Height,
+			i.Size,
+		))
+		return err
+	})
+
+	return created, err
+}
+
+func (r *imageRepository) List(ctx context.Context, owner image.Owner) ([]image.Image, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if err := ownerExists(ctx, r, owner); err != nil {
+		return nil, err
+	}
+	return listImages(ctx, r, owner)
+}
+
+func (r *imageRepository) Delete(ctx context.Context, owner image.Owner, imageID int64) (image.Image, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var deleted image.Image
+	err := r.inTx(ctx, func(tx txConn) error {
+		var err error
+		deleted, err = scanImage(tx.queryRow(
+			ctx,
+			"SELECT "+imageColumns+" FROM images WHERE id = ? AND owner_type = ? AND owner_id = ?",
+			imageID,
+			owner.Kind,
+			owner.ID,
+		))
+		if errors.Is(err, sql.ErrNoRows) {
+			return image.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.exec(ctx, "DELETE FROM images WHERE id = ?", imageID); err != nil {
+			return err
+		}
+		_, err = tx.exec(
+			ctx,
+			"UPDATE images SET sort_order = sort_order - 1 WHERE owner_type = ? AND owner_id = ? AND sort_order > ?",
+			owner.Kind,
+			owner.ID,
+			deleted.Position,
+		)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (r *imageRepository) Reorder(ctx context.Context, owner image.Owner, imageIDs []int64) ([]image.Image, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var reordered []image.Image
+	err := r.inTx(ctx, func(tx txConn) error {
+		if err := ownerExists(ctx, tx, owner); err != nil {
+			return err
+		}
+
+		images, err := listImages(ctx, tx, owner)
+		if err != nil {
+			return err
+		}
+		if err := image.CheckOrder(images, imageIDs); err != nil {
+			return err
+		}
+
+		for position, id := range imageIDs {
+			if _, err := tx.exec(ctx, "UPDATE images SET sort_order = ? WHERE id = ?", position, id); err != nil {
+				return err
+			}
+		}
+
+		reordered, err = listImages(ctx, tx, owner)
+		return err
+	})
+
+	return reordered, err
+}
+
+func (r *imageRepository) ListOrphans(ctx context.Context) ([]image.Image, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.query(
+		ctx,
+		`SELECT `+imageColumns+` FROM images
+		WHERE (owner_type = ? AND NOT EXISTS (SELECT 1 FROM locations WHERE locations.id = images.owner_id))
+		OR (owner_type = ? AND NOT EXISTS (SELECT 1 FROM holidays WHERE holidays.id = images.owner_id))
+		ORDER BY id`,
+		image.LocationOwner,
+		image.HolidayOwner,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanImages(rows)
+}
+
+type rowsQuerier interface {
+	query(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func listImages(ctx context.Context, q rowsQuerier, owner image.Owner) ([]image.Image, error) {
+	rows, err := q.query(
+		ctx,
+		"SELECT "+imageColumns+" FROM images WHERE owner_type = ? AND owner_id = ? ORDER BY sort_order, id",
+		owner.Kind,
+		owner.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanImages(rows)
+}
+
+func scanImages(rows *sql.Rows) ([]image.Image, error) {
+	var images []image.Image
+	for rows.Next() {
+		i, err := scanImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, i)
+	}
+
+	return images, rows.Err()
+}
+
+// ownerExists fails with image.ErrOwnerNotFound unless the owner is stored.
+func ownerExists(ctx context.Context, q rowQuerier, owner image.Owner) error {
+	var table string
+	switch owner.Kind {
+	case image.LocationOwner:
+		table = "locations"
+	case image.HolidayOwner:
+		table = "holidays"
+	default:
+		return fmt.Errorf("%w: unknown owner kind %q", image.ErrOwnerNotFound, owner.Kind)
+	}
+
+	var exists int
+	err := q.queryRow(ctx, "SELECT 1 FROM "+table+" WHERE id = ?", owner.ID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return image.ErrOwnerNotFound
+	}
+	return err
+}
@@ -0,0 +1,235 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+const locationColumns = "id, number, country, country_code, city, street, image_url, latitude, longitude, version"
+
+type locationRepository struct {
+	conn
+}
+
+func scanLocation(row scanner) (location.Location, error) {
+	var l location.Location
+	err := row.Scan(
+		&l.ID,
+		&l.Number,
+		&l.Country,
+		&l.CountryCode,
+		&l.City,
+		&l.Street,
+		&l.ImageURL,
+		&l.Latitude,
+		&l.Longitude,
+		&l.Version,
+	)
+	return l, err
+}
+
+func (r *locationRepository) GetMany(ctx context.Context, locationIDs []int64) (map[int64]location.Location, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	locations := make(map[int64]location.Location)
+	if len(locationIDs) == 0 {
+		return locations, nil
+	}
+
+	in, args := inList(locationIDs)
+	rows, err := r.query(ctx, "SELECT "+locationColumns+" FROM locations WHERE id IN "+in, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations[l.ID] = l
+	}
+
+	return locations, rows.Err()
+}
+
+func (r *locationRepository) Create(ctx context.Context, l location.Location) (location.Location, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO locations (number, country, country_code, city, street, image_url, latitude, longitude)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING ` + locationColumns
+
+	row := r.queryRow(
+		ctx,
+		query,
+		l.Number,
+		l.Country,
+		l.CountryCode,
+		l.City,
+		l.Street,
+		l.ImageURL,
+		l.Latitude,
+		l.Longitude,
+	)
+
+	return scanLocation(row)
+}
+
+func (r *locationRepository) Delete(ctx context.Context, locationID int64, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	condition, versionArgs := versionCondition(version)
+	result, err := r.exec(ctx, "DELETE FROM locations WHERE id = ?"+condition, append([]any{locationID}, versionArgs...)...)
+	if err != nil {
+		return err
+	}
+
+	return expectWrite(ctx, r, result, "locations", locationID, location.ErrNotFound, location.ErrVersionMismatch)
+}
+
+func (r *locationRepository) List(ctx context.Context) ([]location.Location, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.query(ctx, "SELECT "+locationColumns+" FROM locations ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locations []location.Location
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+func (r *locationRepository) Get(ctx context.Context, locationID int64) (location.Location, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	row := r.queryRow(ctx, "SELECT "+locationColumns+" FROM locations WHERE id = ?", locationID)
+
+	l, err := scanLocation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return location.Location{}, location.ErrNotFound
+	}
+
+	return l, err
+}
+
+func (r *locationRepository) Update(ctx context.Context, l location.Location) (location.Location, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE locations
+		SET number = ?, country = ?, country_code = ?, city = ?, street = ?, image_url = ?,
+			latitude = ?, longitude = ?, version = version + 1
+		WHERE id = ?`
+
+	condition, versionArgs := versionCondition(l.Version)
+	args := []any{
+		l.Number,
+		l.Country,
+		l.CountryCode,
+		l.City,
+		l.Street,
+		l.ImageURL,
+		l.Latitude,
+		l.Longitude,
+		l.ID,
+	}
+
+	row := r.queryRow(ctx, query+condition+" RETURNING "+locationColumns, append(args, versionArgs...)...)
+
+	updated, err := scanLocation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return location.Location{}, missingOrStale(ctx, r, "locations", l.ID, location.ErrNotFound, location.ErrVersionMismatch)
+	}
+
+	return updated, err
+}
+
+func (r *locationRepository) ListTranslations(ctx context.Context, locationIDs []int64) (map[int64][]location.Translation, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	translations := make(map[int64][]location.Translation)
+	if len(locationIDs) == 0 {
+		return translations, nil
+	}
+
+	in, args := inList(locationIDs)
+	rows, err := r.query(
+		ctx,
+		"SELECT location_id, language, city, street FROM location_translations WHERE location_id IN "+in+" ORDER BY language",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var locationID int64
+		var t location.Translation
+		if err := rows.Scan(&locationID, &t.Language, &t.City, &t.Street); err != nil {
+			return nil, err
+		}
+		translations[locationID] = append(translations[locationID], t)
+	}
+
+	return translations, rows.Err()
+}
+
+func (r *locationRepository) ReplaceTranslations(ctx context.Context, locationID int64, translations []location.Translation, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.inTx(ctx, func(tx txConn) error {
+		condition, versionArgs := versionCondition(version)
+		result, err := tx.exec(
+			ctx,
+			"UPDATE locations SET version = version + 1 WHERE id = ?"+condition,
+			append([]any{locationID}, versionArgs...)...,
+		)
+		if err != nil {
+			return err
+		}
+		if err := expectWrite(ctx, tx, result, "locations", locationID, location.ErrNotFound, location.ErrVersionMismatch); err != nil {
+			return err
+		}
+
+		if _, err := tx.exec(ctx, "DELETE FROM location_translations WHERE location_id = ?", locationID); err != nil {
+			return err
+		}
+		for _, t := range translations {
+			_, err := tx.exec(
+				ctx,
+				"INSERT INTO location_translations (location_id, language, city, street) VALUES (?, ?, ?, ?)",
+				locationID, t.Language, t.City, t.Street,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
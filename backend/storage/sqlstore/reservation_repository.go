@@ -0,0 +1,207 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/reservation"
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+const reservationColumns = "id, phone_number, contact_name, holiday_id, version"
+
+type reservationRepository struct {
+	conn
+}
+
+func scanReservation(row scanner) (reservation.Reservation, error) {
+	var r reservation.Reservation
+	err := row.Scan(
+		&r.ID,
+		&r.PhoneNumber,
+		&r.ContactName,
+		&r.HolidayID,
+		&r.Version,
+	)
+	return r, err
+}
+
+// Create takes one of the holiday's free slots and inserts the reservation
+// in the same transaction. The conditional decrement keeps concurrent
+// bookings from overselling a holiday.
+func (r *reservationRepository) Create(ctx context.Context, res reservation.Reservation) (reservation.Reservation, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+        INSERT INTO reservations (phone_number, contact_name, holiday_id)
+        VALUES (?, ?, ?)
+        RETURNING ` + reservationColumns
+
+	var created reservation.Reservation
+	err := r.inTx(ctx, func(tx txConn) error {
+		if err := takeSlot(ctx, tx, res.HolidayID); err != nil {
+			return err
+		}
+
+		row := tx.queryRow(
+			ctx,
+			query,
+			res.PhoneNumber,
+			res.ContactName,
+			res.HolidayID,
+		)
+
+		var err error
+		created, err = scanReservation(row)
+		return err
+	})
+
+	return created, err
+}
+
+func (r *reservationRepository) Delete(ctx context.Context, reservationID int64, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	condition, versionArgs := versionCondition(version)
+	query := "DELETE FROM reservations WHERE id = ?" + condition + " RETURNING holiday_id"
+
+	return r.inTx(ctx, func(tx txConn) error {
+		var holidayID int64
+		err := tx.queryRow(ctx, query, append([]any{reservationID}, versionArgs...)...).Scan(&holidayID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return missingOrStale(ctx, tx, "reservations", reservationID, reservation.ErrNotFound, reservation.ErrVersionMismatch)
+		}
+		if err != nil {
+			return err
+		}
+
+		return releaseSlot(ctx, tx, holidayID)
+	})
+}
+
+// takeSlot fails with reservation.ErrNotBookable unless the holiday is
+// published.
+func takeSlot(ctx context.Context, tx txConn, holidayID int64) error {
+	result, err := tx.exec(
+		ctx,
+		"UPDATE holidays SET free_slots = free_slots - 1, version = version + 1 WHERE id = ? AND free_slots > 0 AND status = ?",
+		holidayID,
+		holiday.Published,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var status string
+	err = tx.queryRow(ctx, "SELECT status FROM holidays WHERE id = ?", holidayID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return holiday.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != holiday.Published {
+		return reservation.ErrNotBookable
+	}
+
+	return reservation.ErrNoFreeSlots
+}
+
+func releaseSlot(ctx context.Context, tx txConn, holidayID int64) error {
+	_, err := tx.exec(ctx, "UPDATE holidays SET free_slots = free_slots + 1, version = version + 1 WHERE id = ?", holidayID)
+	return err
+}
+
+func (r *reservationRepository) List(ctx context.Context) ([]reservation.Reservation, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.query(ctx, "SELECT "+reservationColumns+" FROM reservations ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []reservation.Reservation
+	for rows.Next() {
+		res, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, res)
+	}
+
+	return reservations, rows.Err()
+}
+
+func (r *reservationRepository) Get(ctx context.Context, reservationID int64) (reservation.Reservation, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	row := r.queryRow(ctx, "SELECT "+reservationColumns+" FROM reservations WHERE id = ?", reservationID)
+
+	res, err := scanReservation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return reservation.Reservation{}, reservation.ErrNotFound
+	}
+
+	return res, err
+}
+
+// Update replaces the contact details and, when res.HolidayID names a
+// different holiday, moves the reservation there: the first UPDATE locks the
+// row, then a slot is taken on the target and released on the old holiday
+// in the same transaction.
+func (r *reservationRepository) Update(ctx context.Context, res reservation.Reservation) (reservation.Reservation, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	condition, versionArgs := versionCondition(res.Version)
+	query := `
+		UPDATE reservations
+		SET phone_number = ?, contact_name = ?, version = version + 1
+		WHERE id = ?` + condition + `
+		RETURNING holiday_id`
+	args := append([]any{res.PhoneNumber, res.ContactName, res.ID}, versionArgs...)
+
+	var updated reservation.Reservation
+	err := r.inTx(ctx, func(tx txConn) error {
+		var currentHolidayID int64
+		err := tx.queryRow(ctx, query, args...).Scan(&currentHolidayID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return missingOrStale(ctx, tx, "reservations", res.ID, reservation.ErrNotFound, reservation.ErrVersionMismatch)
+		}
+		if err != nil {
+			return err
+		}
+
+		if res.HolidayID != 0 && res.HolidayID != currentHolidayID {
+			if err := takeSlot(ctx, tx, res.HolidayID); err != nil {
+				return err
+			}
+			if err := releaseSlot(ctx, tx, currentHolidayID); err != nil {
+				return err
+			}
+			if _, err := tx.exec(ctx, "UPDATE reservations SET holiday_id = ? WHERE id = ?", res.HolidayID, res.ID); err != nil {
+				return err
+			}
+		}
+
+		updated, err = scanReservation(tx.queryRow(ctx, "SELECT "+reservationColumns+" FROM reservations WHERE id = ?", res.ID))
+		return err
+	})
+
+	return updated, err
+}
@@ -0,0 +1,183 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	"github.com/nikolaypleshkov/uni-api/api/search"
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+// searchRepository searches with PostgreSQL's full-text search, and from an
+// in-memory index on SQLite, which has no prefix search or ranking to match.
+type searchRepository struct {
+	conn
+	index *search.Index
+}
+
+func (r *searchRepository) Search(ctx context.Context, query search.Query) ([]search.Hit, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	if r.dialect == database.Postgres {
+		return r.searchPostgres(ctx, query)
+	}
+
+	// Rows written between reading the fingerprint and loading the
+	// documents only make the index newer than its fingerprint, so the
+	// next search rebuilds it rather than missing them.
+	fingerprint, err := r.fingerprint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.index.Search(query, fingerprint, func() ([]search.Document, error) {
+		return r.documents(ctx)
+	})
+}
+
+// searchPostgres matches the search_document columns, which hold the
+// documents of search.HolidayDocument and search.LocationDocument as
+// weighted tsvectors. They use the "simple" configuration so that words are
+// matched as typed, in any language, and have GIN indexes. The query is
+// repeated in each branch so that both indexes can be used.
+func (r *searchRepository) searchPostgres(ctx context.Context, query search.Query) ([]search.Hit, error) {
+	statement := `
+		SELECT d.kind, d.id, ts_rank(d.document, to_tsquery('simple', ?)),
+			ts_headline('simple', d.text, to_tsquery('simple', ?), 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+		FROM (
+			SELECT 'holiday' AS kind, h.id, h.search_document AS document,
+				concat_ws(' · ', NULLIF(h.title, ''), NULLIF(l.city, ''), NULLIF(l.country, '')) AS text
+			FROM holidays h
+			LEFT JOIN locations l ON l.id = h.location_id
+			WHERE h.status = ? AND h.search_document @@ to_tsquery('simple', ?)
+			UNION ALL
+			SELECT 'location', l.id, l.search_document,
+				concat_ws(' · ', NULLIF(l.city, ''), NULLIF(l.country, ''), NULLIF(l.street, ''))
+			FROM locations l
+			WHERE l.search_document @@ to_tsquery('simple', ?)
+		) d
+		ORDER BY 3 DESC, d.kind, d.id
+		LIMIT ?`
+
+	terms := tsquery(query.Terms)
+	rows, err := r.query(ctx, statement, terms, terms, holiday.Published, terms, terms, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []search.Hit
+	for rows.Next() {
+		var hit search.Hit
+		if err := rows.Scan(&hit.Kind, &hit.ID, &hit.Rank, &hit.Highlight); err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, rows.Err()
+}
+
+// tsquery matches documents with a word starting with every term. Terms
+// are letters and digits only, so they need no quoting.
+func tsquery(terms []string) string {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	return strings.Join(prefixes, " & ")
+}
+
+func (r *searchRepository) fingerprint(ctx context.Context) (string, error) {
+	var holidays, locations [3]int64
+	err := r.queryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM holidays),
+			(SELECT COALESCE(SUM(version), 0) FROM holidays),
+			(SELECT COALESCE(MAX(id), 0) FROM holidays),
+			(SELECT COUNT(*) FROM locations),
+			(SELECT COALESCE(SUM(version), 0) FROM locations),
+			(SELECT COALESCE(MAX(id), 0) FROM locations)`,
+	).Scan(&holidays[0], &holidays[1], &holidays[2], &locations[0], &locations[1], &locations[2])
+	if err != nil {
+		return "", err
+	}
+
+	return search.Fingerprint(holidays[0], holidays[1], holidays[2]) + " " +
+		search.Fingerprint(locations[0], locations[1], locations[2]), nil
+}
+
+// documents loads the searchable holidays, which are the published ones,
+// and then locations, each in id order.
+func (r *searchRepository) documents(ctx context.Context) ([]search.Document, error) {
+	locations := make(map[int64]location.Location)
+	var documents []search.Document
+
+	rows, err := r.query(ctx, "SELECT "+locationColumns+" FROM locations ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		l, err := scanLocation(rows)
+		if err != nil {
+			return nil, err
+		}
+		locations[l.ID] = l
+		documents = append(documents, search.LocationDocument(l))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tagNames, err := r.tagNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	holidayRows, err := r.query(ctx, "SELECT "+holidayColumns+" FROM holidays WHERE status = ? ORDER BY id", holiday.Published)
+	if err != nil {
+		return nil, err
+	}
+	defer holidayRows.Close()
+	var holidays []search.Document
+	for holidayRows.Next() {
+		h, err := scanHoliday(holidayRows)
+		if err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, search.HolidayDocument(h, locations[h.LocationID], tagNames[h.ID]))
+	}
+	if err := holidayRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return append(holidays, documents...), nil
+}
+
+// tagNames returns the names of each holiday's tags.
+func (r *searchRepository) tagNames(ctx context.Context) (map[int64][]string, error) {
+	rows, err := r.query(ctx, `
+		SELECT ht.holiday_id, t.name
+		FROM holiday_tags ht
+		JOIN tags t ON t.id = ht.tag_id
+		ORDER BY ht.holiday_id, t.slug`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[int64][]string)
+	for rows.Next() {
+		var holidayID int64
+		var name string
+		if err := rows.Scan(&holidayID, &name); err != nil {
+			return nil, err
+		}
+		names[holidayID] = append(names[holidayID], name)
+	}
+
+	return names, rows.Err()
+}
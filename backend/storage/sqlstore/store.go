@@ -0,0 +1,211 @@
+// Package sqlstore implements the repositories on top of database/sql.
+// Queries use "?" placeholders and are rebound for the configured dialect,
+// so the same code serves PostgreSQL and SQLite.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/country"
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/image"
+	"github.com/nikolaypleshkov/uni-api/api/location"
+	"github.com/nikolaypleshkov/uni-api/api/reservation"
+	"github.com/nikolaypleshkov/uni-api/api/search"
+	"github.com/nikolaypleshkov/uni-api/api/tag"
+	"github.com/nikolaypleshkov/uni-api/api/template"
+	"github.com/nikolaypleshkov/uni-api/database"
+	"github.com/nikolaypleshkov/uni-api/idempotency"
+)
+
+type Store struct {
+	conn conn
+	// searchIndex caches the search documents on databases without
+	// full-text search.
+	searchIndex *search.Index
+}
+
+func New(db *sql.DB, dialect database.Dialect) *Store {
+	return &Store{conn: conn{db: db, dialect: dialect}, searchIndex: &search.Index{}}
+}
+
+func (s *Store) Holidays() holiday.HolidayRepository {
+	return &holidayRepository{s.conn}
+}
+
+func (s *Store) Locations() location.LocationRepository {
+	return &locationRepository{s.conn}
+}
+
+func (s *Store) Reservations() reservation.ReservationRepository {
+	return &reservationRepository{s.conn}
+}
+
+func (s *Store) Countries() country.CountryRepository {
+	return &countryRepository{s.conn}
+}
+
+func (s *Store) Images() image.ImageRepository {
+	return &imageRepository{s.conn}
+}
+
+func (s *Store) Tags() tag.TagRepository {
+	return &tagRepository{s.conn}
+}
+
+func (s *Store) Templates() template.TemplateRepository {
+	return &templateRepository{s.conn}
+}
+
+func (s *Store) Search() search.SearchRepository {
+	return &searchRepository{s.conn, s.searchIndex}
+}
+
+func (s *Store) IdempotencyKeys() idempotency.Store {
+	return &idempotencyRepository{s.conn}
+}
+
+type conn struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+func (c conn) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.db.QueryRowContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+func (c conn) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.db.QueryContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+func (c conn) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.db.ExecContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+// inTx runs fn in a transaction that is committed when fn returns nil and
+// rolled back otherwise.
+func (c conn) inTx(ctx context.Context, fn func(tx txConn) error) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(txConn{tx: tx, dialect: c.dialect}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type txConn struct {
+	tx      *sql.Tx
+	dialect database.Dialect
+}
+
+func (c txConn) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.tx.QueryRowContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+func (c txConn) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.tx.QueryContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+func (c txConn) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.tx.ExecContext(ctx, c.dialect.Rebind(query), args...)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func nullableID(id int64) sql.NullInt64 {
+	if id <= 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: id, Valid: true}
+}
+
+// nullableTime stores a time as Unix milliseconds, and nil as NULL.
+func nullableTime(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixMilli(), Valid: true}
+}
+
+func timeOrNil(millis sql.NullInt64) *time.Time {
+	if !millis.Valid {
+		return nil
+	}
+	t := time.UnixMilli(millis.Int64).UTC()
+	return &t
+}
+
+type rowQuerier interface {
+	queryRow(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// versionCondition narrows a write to the expected row version. Version 0
+// leaves the write unconditional.
+func versionCondition(version int64) (string, []any) {
+	if version == 0 {
+		return "", nil
+	}
+	return " AND version = ?", []any{version}
+}
+
+// missingOrStale explains why a write to row id of table matched nothing:
+// either the row is gone or its version has moved on.
+func missingOrStale(ctx context.Context, q rowQuerier, table string, id int64, notFound, stale error) error {
+	var exists int
+	err := q.queryRow(ctx, "SELECT 1 FROM "+table+" WHERE id = ?", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+	return stale
+}
+
+func expectWrite(ctx context.Context, q rowQuerier, result sql.Result, table string, id int64, notFound, stale error) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return missingOrStale(ctx, q, table, id, notFound, stale)
+	}
+	return nil
+}
+
+// decimal reads a DECIMAL column as text. PostgreSQL returns the exact
+// value while SQLite hands back a float, which is formatted with the column's
+// two decimal places.
+type decimal struct {
+	value *string
+}
+
+func (d decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d.value = ""
+	case float64:
+		*d.value = strconv.FormatFloat(v, 'f', 2, 64)
+	case int64:
+		*d.value = strconv.FormatInt(v, 10) + ".00"
+	case []byte:
+		*d.value = string(v)
+	case string:
+		*d.value = v
+	default:
+		return fmt.Errorf("unsupported decimal value %T", src)
+	}
+	return nil
+}
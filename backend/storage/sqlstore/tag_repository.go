@@ -0,0 +1,152 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/nikolaypleshkov/uni-api/api/tag"
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+const tagColumns = "id, slug, name, version"
+
+type tagRepository struct {
+	conn
+}
+
+func scanTag(row scanner) (tag.Tag, error) {
+	var t tag.Tag
+	err := row.Scan(&t.ID, &t.Slug, &t.Name, &t.Version)
+	return t, err
+}
+
+func (r *tagRepository) Create(ctx context.Context, t tag.Tag) (tag.Tag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var created tag.Tag
+	err := r.inTx(ctx, func(tx txConn) error {
+		if err := slugAvailable(ctx, tx, t.Slug, 0); err != nil {
+			return err
+		}
+
+		var err error
+		created, err = scanTag(tx.queryRow(
+			ctx,
+			"INSERT INTO tags (slug, name) VALUES (?, ?) RETURNING "+tagColumns,
+			t.Slug,
+			t.Name,
+		))
+		return err
+	})
+
+	return created, err
+}
+
+func (r *tagRepository) Delete(ctx context.Context, tagID int64, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.inTx(ctx, func(tx txConn) error {
+		if err := touchTaggedHolidays(ctx, tx, tagID); err != nil {
+			return err
+		}
+
+		condition, versionArgs := versionCondition(version)
+		result, err := tx.exec(ctx, "DELETE FROM tags WHERE id = ?"+condition, append([]any{tagID}, versionArgs...)...)
+		if err != nil {
+			return err
+		}
+
+		return expectWrite(ctx, tx, result, "tags", tagID, tag.ErrNotFound, tag.ErrVersionMismatch)
+	})
+}
+
+func (r *tagRepository) List(ctx context.Context) ([]tag.Tag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.query(ctx, "SELECT "+tagColumns+" FROM tags ORDER BY slug")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []tag.Tag
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *tagRepository) Get(ctx context.Context, tagID int64) (tag.Tag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	t, err := scanTag(r.queryRow(ctx, "SELECT "+tagColumns+" FROM tags WHERE id = ?", tagID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return tag.Tag{}, tag.ErrNotFound
+	}
+
+	return t, err
+}
+
+func (r *tagRepository) Update(ctx context.Context, t tag.Tag) (tag.Tag, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var updated tag.Tag
+	err := r.inTx(ctx, func(tx txConn) error {
+		if err := slugAvailable(ctx, tx, t.Slug, t.ID); err != nil {
+			return err
+		}
+
+		condition, versionArgs := versionCondition(t.Version)
+		var err error
+		updated, err = scanTag(tx.queryRow(
+			ctx,
+			"UPDATE tags SET slug = ?, name = ?, version = version + 1 WHERE id = ?"+condition+" RETURNING "+tagColumns,
+			append([]any{t.Slug, t.Name, t.ID}, versionArgs...)...,
+		))
+		if errors.Is(err, sql.ErrNoRows) {
+			return missingOrStale(ctx, tx, "tags", t.ID, tag.ErrNotFound, tag.ErrVersionMismatch)
+		}
+		if err != nil {
+			return err
+		}
+
+		return touchTaggedHolidays(ctx, tx, t.ID)
+	})
+
+	return updated, err
+}
+
+// slugAvailable fails with tag.ErrDuplicateSlug when a tag other than
+// exceptID has the slug. The unique index still guards against races.
+func slugAvailable(ctx context.Context, q rowQuerier, slug string, exceptID int64) error {
+	var exists int
+	err := q.queryRow(ctx, "SELECT 1 FROM tags WHERE slug = ? AND id <> ?", slug, exceptID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return tag.ErrDuplicateSlug
+}
+
+// touchTaggedHolidays increments the version of every holiday with the tag.
+func touchTaggedHolidays(ctx context.Context, tx txConn, tagID int64) error {
+	_, err := tx.exec(
+		ctx,
+		"UPDATE holidays SET version = version + 1 WHERE id IN (SELECT holiday_id FROM holiday_tags WHERE tag_id = ?)",
+		tagID,
+	)
+	return err
+}
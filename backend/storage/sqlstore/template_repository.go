@@ -0,0 +1,341 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nikolaypleshkov/uni-api/api/holiday"
+	"github.com/nikolaypleshkov/uni-api/api/template"
+	"github.com/nikolaypleshkov/uni-api/database"
+)
+
+const templateColumns = "id, title, description, duration, free_slots, price, location_id, included, excluded, " +
+	"accommodation_name, accommodation_type, accommodation_stars, accommodation_description, " +
+	"status, start_date, recurrence, excluded_dates, version"
+
+type templateRepository struct {
+	conn
+}
+
+func scanTemplate(row scanner) (template.Template, error) {
+	var t template.Template
+	var locationID sql.NullInt64
+	var included, excluded, excludedDates string
+	err := row.Scan(
+		&t.ID,
+		&t.Title,
+		&t.Description,
+		&t.Duration,
+		&t.FreeSlots,
+		decimal{&t.Price},
+		&locationID,
+		&included,
+		&excluded,
+		&t.Accommodation.Name,
+		&t.Accommodation.Type,
+		&t.Accommodation.Stars,
+		&t.Accommodation.Description,
+		&t.Status,
+		&t.StartDate,
+		&t.Recurrence,
+		&excludedDates,
+		&t.Version,
+	)
+	t.LocationID = locationID.Int64
+	t.Included, t.Excluded = splitServices(included), splitServices(excluded)
+	t.ExcludedDates = splitServices(excludedDates)
+	return t, err
+}
+
+// templateArgs are the values of every column but id and version, in
+// column order. Excluded dates are stored comma-separated like services.
+func templateArgs(t template.Template) []any {
+	return []any{
+		t.Title,
+		t.Description,
+		t.Duration,
+		t.FreeSlots,
+		t.Price,
+		nullableID(t.LocationID),
+		joinServices(t.Included),
+		joinServices(t.Excluded),
+		t.Accommodation.Name,
+		t.Accommodation.Type,
+		t.Accommodation.Stars,
+		t.Accommodation.Description,
+		t.Status,
+		t.StartDate,
+		t.Recurrence,
+		strings.Join(t.ExcludedDates, ","),
+	}
+}
+
+func (r *templateRepository) Create(ctx context.Context, t template.Template, dates []string) (template.Template, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+        INSERT INTO holiday_templates (title, description, duration, free_slots, price, location_id, included, excluded,
+            accommodation_name, accommodation_type, accommodation_stars, accommodation_description,
+            status, start_date, recurrence, excluded_dates)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        RETURNING ` + templateColumns
+
+	var created template.Template
+	err := r.inTx(ctx, func(tx txConn) error {
+		if err := checkTemplateLocation(ctx, tx, t.LocationID); err != nil {
+			return err
+		}
+
+		var err error
+		created, err = scanTemplate(tx.queryRow(ctx, query, templateArgs(t)...))
+		if err != nil {
+			return err
+		}
+
+		return addDepartures(ctx, tx, created, dates)
+	})
+
+	return created, err
+}
+
+// Delete detaches the departures itself rather than leave it to the foreign
+// key, so that their versions are incremented.
+func (r *templateRepository) Delete(ctx context.Context, templateID int64, version int64) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.inTx(ctx, func(tx txConn) error {
+		_, err := tx.exec(ctx, "UPDATE holidays SET template_id = NULL, version = version + 1 WHERE template_id = ?", templateID)
+		if err != nil {
+			return err
+		}
+
+		condition, versionArgs := versionCondition(version)
+		result, err := tx.exec(ctx, "DELETE FROM holiday_templates WHERE id = ?"+condition, append([]any{templateID}, versionArgs...)...)
+		if err != nil {
+			return err
+		}
+
+		return expectWrite(ctx, tx, result, "holiday_templates", templateID, template.ErrNotFound, template.ErrVersionMismatch)
+	})
+}
+
+func (r *templateRepository) List(ctx context.Context) ([]template.Template, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.query(ctx, "SELECT "+templateColumns+" FROM holiday_templates ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []template.Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *templateRepository) Get(ctx context.Context, templateID int64) (template.Template, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	t, err := scanTemplate(r.queryRow(ctx, "SELECT "+templateColumns+" FROM holiday_templates WHERE id = ?", templateID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return template.Template{}, template.ErrNotFound
+	}
+
+	return t, err
+}
+
+// Update revises the future departures in place, moving their free slots
+// relative to what is left so that concurrent bookings are not lost.
+func (r *templateRepository) Update(ctx context.Context, t template.Template, dates []string, after string) (template.Template, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	condition, versionArgs := versionCondition(t.Version)
+	query := `
+        UPDATE holiday_templates
+        SET title = ?, description = ?, duration = ?, free_slots = ?, price = ?, location_id = ?, included = ?,
+            excluded = ?, accommodation_name = ?, accommodation_type = ?, accommodation_stars = ?,
+            accommodation_description = ?, status = ?, start_date = ?, recurrence = ?, excluded_dates = ?,
+            version = version + 1
+        WHERE id = ?` + condition + `
+        RETURNING ` + templateColumns
+	args := append(append(templateArgs(t), t.ID), versionArgs...)
+
+	var updated template.Template
+	err := r.inTx(ctx, func(tx txConn) error {
+		var previousFreeSlots int32
+		err := tx.queryRow(ctx, "SELECT free_slots FROM holiday_templates WHERE id = ?", t.ID).Scan(&previousFreeSlots)
+		if errors.Is(err, sql.ErrNoRows) {
+			return template.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkTemplateLocation(ctx, tx, t.LocationID); err != nil {
+			return err
+		}
+
+		updated, err = scanTemplate(tx.queryRow(ctx, query, args...))
+		if errors.Is(err, sql.ErrNoRows) {
+			return missingOrStale(ctx, tx, "holiday_templates", t.ID, template.ErrNotFound, template.ErrVersionMismatch)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := checkDepartureItineraries(ctx, tx, updated, after); err != nil {
+			return err
+		}
+		if err := reviseDepartures(ctx, tx, updated, updated.FreeSlots-previousFreeSlots, after); err != nil {
+			return err
+		}
+		if err := deleteUnscheduledDepartures(ctx, tx, updated.ID, dates, after); err != nil {
+			return err
+		}
+		return addDepartures(ctx, tx, updated, dates)
+	})
+
+	return updated, err
+}
+
+// checkTemplateLocation fails with template.ErrUnknownLocation rather than
+// leave a missing location to the foreign key.
+func checkTemplateLocation(ctx context.Context, tx txConn, locationID int64) error {
+	if locationID <= 0 {
+		return nil
+	}
+
+	var exists int
+	err := tx.queryRow(ctx, "SELECT 1 FROM locations WHERE id = ?", locationID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %d", template.ErrUnknownLocation, locationID)
+	}
+	return err
+}
+
+// checkDepartureItineraries refuses a duration that the itinerary of one of
+// the template's departures after the date after does not fit in.
+func checkDepartureItineraries(ctx context.Context, tx txConn, t template.Template, after string) error {
+	var startDate string
+	var end int32
+	err := tx.queryRow(
+		ctx,
+		`SELECT h.start_date, MAX(s.day_offset + s.nights)
+        FROM holidays h
+        JOIN holiday_stops s ON s.holiday_id = h.id
+        WHERE h.template_id = ? AND h.start_date > ? AND h.status <> ?
+        GROUP BY h.id, h.start_date
+        HAVING MAX(s.day_offset + s.nights) > ?
+        ORDER BY h.start_date
+        LIMIT 1`,
+		t.ID,
+		after,
+		holiday.Archived,
+		t.Duration,
+	).Scan(&startDate, &end)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: the itinerary of the departure on %s ends on day %d, after the %d-day holiday",
+		template.ErrInvalidTemplate, startDate[:min(len(startDate), len(time.DateOnly))], end, t.Duration)
+}
+
+// reviseDepartures gives the template's departures after the date after its
+// content and status, and moves their free slots by freeSlotsChange, to no
+// less than zero. Published departures with reservations stay published, so
+// that their customers can still find them.
+func reviseDepartures(ctx context.Context, tx txConn, t template.Template, freeSlotsChange int32, after string) error {
+	set := `title = ?, description = ?, duration = ?, price = ?, location_id = ?, included = ?, excluded = ?,
+            accommodation_name = ?, accommodation_type = ?, accommodation_stars = ?, accommodation_description = ?,
+            free_slots = CASE WHEN free_slots + ? < 0 THEN 0 ELSE free_slots + ? END,
+            status = CASE
+                WHEN status = ? AND EXISTS (SELECT 1 FROM reservations WHERE reservations.holiday_id = holidays.id) THEN status
+                ELSE ?
+            END,
+            version = version + 1`
+	if t.Status == holiday.Published {
+		set += ", publish_at = NULL"
+	}
+
+	_, err := tx.exec(
+		ctx,
+		"UPDATE holidays SET "+set+" WHERE template_id = ? AND start_date > ? AND status <> ?",
+		t.Title,
+		t.Description,
+		t.Duration,
+		t.Price,
+		nullableID(t.LocationID),
+		joinServices(t.Included),
+		joinServices(t.Excluded),
+		t.Accommodation.Name,
+		t.Accommodation.Type,
+		t.Accommodation.Stars,
+		t.Accommodation.Description,
+		freeSlotsChange,
+		freeSlotsChange,
+		holiday.Published,
+		t.Status,
+		t.ID,
+		after,
+		holiday.Archived,
+	)
+	return err
+}
+
+// deleteUnscheduledDepartures deletes the template's departures after the
+// date after that are on none of dates and have no reservations.
+func deleteUnscheduledDepartures(ctx context.Context, tx txConn, templateID int64, dates []string, after string) error {
+	query := `
+        DELETE FROM holidays
+        WHERE template_id = ? AND start_date > ? AND status <> ?
+            AND NOT EXISTS (SELECT 1 FROM reservations WHERE reservations.holiday_id = holidays.id)`
+	args := []any{templateID, after, holiday.Archived}
+	if len(dates) > 0 {
+		query += " AND start_date NOT IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(dates)), ", ") + ")"
+		for _, date := range dates {
+			args = append(args, date)
+		}
+	}
+
+	_, err := tx.exec(ctx, query, args...)
+	return err
+}
+
+// addDepartures inserts a departure of the template on each of dates that
+// does not have one yet.
+func addDepartures(ctx context.Context, tx txConn, t template.Template, dates []string) error {
+	for _, date := range dates {
+		var exists int
+		err := tx.queryRow(ctx, "SELECT 1 FROM holidays WHERE template_id = ? AND start_date = ?", t.ID, date).Scan(&exists)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if _, err := insertHoliday(ctx, tx, t.Departure(date)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,17 @@ const instance = axios.create({
   baseURL: BASE_URL,
 });
 
+// Updates and deletes must send If-Match with the ETag of the version the
+// user was looking at, so the server can reject writes over newer changes.
+// ETags are the versions of the rows in a response joined by dots, e.g. a
+// holiday's version followed by its location's.
+export const etagFor = (...versions) => `"${versions.join(".")}"`;
+
+const ifMatch = (etag) => ({ headers: { "If-Match": etag } });
+
+// Image URLs in responses are paths on the API server.
+export const imageUrl = (path) => new URL(path, BASE_URL).href;
+
 const api = {
   // holidays
   async fetchHolidays() {
@@ -63,18 +74,25 @@ const api = {
     }
   },
 
-  async updateHoliday(holiday) {
+  // The edit form covers only some of a holiday's fields, so it sends them
+  // as a merge patch; a PUT would clear the content it does not show.
+  async updateHoliday(id, changes, etag) {
     try {
-      await instance.put(`/holidays`, holiday);
+      await instance.patch(`/holidays/${id}`, changes, {
+        headers: {
+          "Content-Type": "application/merge-patch+json",
+          "If-Match": etag,
+        },
+      });
     } catch (error) {
       console.error("Error updating journey:", error);
       throw error;
     }
   },
 
-  async deleteHoliday(id) {
+  async deleteHoliday(id, etag) {
     try {
-      await instance.delete(`/holidays/${id}`);
+      await instance.delete(`/holidays/${id}`, ifMatch(etag));
     } catch (error) {
       console.error("Error deleting journey:", error);
       throw error;
@@ -111,24 +129,56 @@ const api = {
     }
   },
 
-  async updateLocation(location) {
+  async updateLocation(location, etag) {
     try {
-      await instance.put(`/locations`, location);
+      await instance.put(`/locations/${location.id}`, location, ifMatch(etag));
     } catch (error) {
       console.error("Error updating location:", error);
       throw error;
     }
   },
 
-  async deleteLocation(id) {
+  async deleteLocation(id, etag) {
     try {
-      await instance.delete(`/locations/${id}`);
+      await instance.delete(`/locations/${id}`, ifMatch(etag));
     } catch (error) {
       console.error("Error deleting location:", error);
       throw error;
     }
   },
 
+  async fetchLocationImages(id) {
+    try {
+      const response = await instance.get(`/locations/${id}/images`);
+      return response.data;
+    } catch (error) {
+      console.error("Error fetching location images:", error);
+      throw error;
+    }
+  },
+
+  async uploadLocationImages(id, files) {
+    const form = new FormData();
+    for (const file of files) {
+      form.append("images", file);
+    }
+    try {
+      await instance.post(`/locations/${id}/images`, form);
+    } catch (error) {
+      console.error("Error uploading location images:", error);
+      throw error;
+    }
+  },
+
+  async deleteLocationImage(id, imageId) {
+    try {
+      await instance.delete(`/locations/${id}/images/${imageId}`);
+    } catch (error) {
+      console.error("Error deleting location image:", error);
+      throw error;
+    }
+  },
+
   // reservations
   async fetchReservations() {
     try {
@@ -150,27 +200,31 @@ const api = {
     }
   },
 
-  async createReservation(reservation) {
+  // Retrying with the same idempotencyKey cannot book twice; the server
+  // answers with the original reservation instead.
+  async createReservation(reservation, idempotencyKey) {
     try {
-      await instance.post("/reservations", reservation);
+      await instance.post("/reservations", reservation, {
+        headers: { "Idempotency-Key": idempotencyKey },
+      });
     } catch (error) {
       console.error("Error creating reservation:", error);
       throw error;
     }
   },
 
-  async updateReservation(reservation) {
+  async updateReservation(reservation, etag) {
     try {
-      await instance.put(`/reservations`, reservation);
+      await instance.put(`/reservations/${reservation.id}`, reservation, ifMatch(etag));
     } catch (error) {
       console.error("Error updating reservation:", error);
       throw error;
     }
   },
 
-  async deleteReservation(id) {
+  async deleteReservation(id, etag) {
     try {
-      await instance.delete(`/reservations/${id}`);
+      await instance.delete(`/reservations/${id}`, ifMatch(etag));
     } catch (error) {
       console.error("Error deleting reservation:", error);
       throw error;
@@ -56,18 +56,18 @@ export const useHolidayStore = defineStore({
       }
     },
 
-    async updateHoliday(updatedHoliday) {
+    async updateHoliday(holidayId, changes, etag) {
       try {
-        await api.updateHoliday(updatedHoliday);
+        await api.updateHoliday(holidayId, changes, etag);
         this.fetchHolidays();
       } catch (error) {
         console.error("Error updating holiday:", error);
       }
     },
 
-    async deleteHoliday(holidayId) {
+    async deleteHoliday(holidayId, etag) {
       try {
-        await api.deleteHoliday(holidayId);
+        await api.deleteHoliday(holidayId, etag);
         this.fetchHolidays();
       } catch (error) {
         console.error("Error deleting holiday:", error);
@@ -105,24 +105,49 @@ export const useHolidayStore = defineStore({
       }
     },
 
-    async updateLocation(updatedLocation) {
+    async updateLocation(updatedLocation, etag) {
       try {
-        await api.updateLocation(updatedLocation);
+        await api.updateLocation(updatedLocation, etag);
         this.fetchLocations();
       } catch (error) {
         console.error("Error updating holiday:", error);
       }
     },
 
-    async deleteLocation(locationId) {
+    async deleteLocation(locationId, etag) {
       try {
-        await api.deleteLocation(locationId);
+        await api.deleteLocation(locationId, etag);
         this.fetchLocations();
       } catch (error) {
         console.error("Error deleting holiday:", error);
       }
     },
 
+    async fetchLocationImages(locationId) {
+      try {
+        return await api.fetchLocationImages(locationId);
+      } catch (error) {
+        console.error("Error fetching location images:", error);
+        return [];
+      }
+    },
+
+    async uploadLocationImages(locationId, files) {
+      try {
+        await api.uploadLocationImages(locationId, files);
+      } catch (error) {
+        console.error("Error uploading location images:", error);
+      }
+    },
+
+    async deleteLocationImage(locationId, imageId) {
+      try {
+        await api.deleteLocationImage(locationId, imageId);
+      } catch (error) {
+        console.error("Error deleting location image:", error);
+      }
+    },
+
     // reservations
     async fetchReservations() {
       try {
@@ -148,27 +173,27 @@ export const useHolidayStore = defineStore({
       return this.reservations.find((r) => r.phoneNumber === phoneNumber);
     },
 
-    async createReservation(newReservation) {
+    async createReservation(newReservation, idempotencyKey) {
       try {
-        await api.createReservation(newReservation);
+        await api.createReservation(newReservation, idempotencyKey);
         this.fetchReservations();
       } catch (error) {
         console.error("Error creating reservation:", error);
       }
     },
 
-    async updateReservation(updatedReservation) {
+    async updateReservation(updatedReservation, etag) {
       try {
-        await api.updateReservation(updatedReservation);
+        await api.updateReservation(updatedReservation, etag);
         this.fetchReservations();
       } catch (error) {
         console.error("Error updating reservation:", error);
       }
     },
 
-    async deleteReservation(reservationId) {
+    async deleteReservation(reservationId, etag) {
       try {
-        await api.deleteReservation(reservationId);
+        await api.deleteReservation(reservationId, etag);
         this.fetchReservations();
       } catch (error) {
         console.error("Error deleting reservation:", error);